@@ -0,0 +1,46 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// DecodeBody decodes the JSON body of r into v, rejecting keys v has no
+// field for, so a misspelt key fails instead of being dropped. On failure it
+// responds 400, naming the unknown field when there is one, or 413 when the
+// body is over the limit set by http.MaxBytesReader, and returns false.
+func DecodeBody(w http.ResponseWriter, r *http.Request, v any) bool {
+	dec := json.NewDecoder(r.Body)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			ErrorResponse(w, http.StatusRequestEntityTooLarge, "request body too large")
+			return false
+		}
+		if field, ok := unknownField(err); ok {
+			ErrorResponse(w, http.StatusBadRequest, "unknown field: "+field)
+			return false
+		}
+		ErrorResponse(w, http.StatusBadRequest, "invalid request body")
+		return false
+	}
+	return true
+}
+
+// unknownField returns the key err reports as unknown. encoding/json has no
+// error type for it, only the message `json: unknown field "name"`.
+func unknownField(err error) (string, bool) {
+	quoted, ok := strings.CutPrefix(err.Error(), "json: unknown field ")
+	if !ok {
+		return "", false
+	}
+	field, err := strconv.Unquote(quoted)
+	if err != nil {
+		return "", false
+	}
+	return field, true
+}
@@ -0,0 +1,60 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDecodeBody(t *testing.T) {
+	type request struct {
+		Name  string `json:"name"`
+		Price int    `json:"price"`
+	}
+
+	tests := []struct {
+		name     string
+		body     string
+		ok       bool
+		response string
+	}{
+		{name: "known fields", body: `{"name":"Hat","price":5}`, ok: true},
+		{name: "unknown field", body: `{"namme":"Hat"}`, response: `{"error":"unknown field: namme"}`},
+		{name: "malformed", body: `{"name":`, response: `{"error":"invalid request body"}`},
+		{name: "wrong type", body: `{"price":"5"}`, response: `{"error":"invalid request body"}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(tt.body))
+			recorder := httptest.NewRecorder()
+
+			var v request
+			ok := DecodeBody(recorder, req, &v)
+
+			assert.Equal(t, tt.ok, ok)
+			if tt.ok {
+				assert.Equal(t, request{Name: "Hat", Price: 5}, v)
+				return
+			}
+			assert.Equal(t, http.StatusBadRequest, recorder.Code)
+			assert.JSONEq(t, tt.response, recorder.Body.String())
+		})
+	}
+
+	t.Run("body too large", func(t *testing.T) {
+		recorder := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"name":"Hat","price":5}`))
+		req.Body = http.MaxBytesReader(recorder, req.Body, 10)
+
+		var v request
+		ok := DecodeBody(recorder, req, &v)
+
+		assert.False(t, ok)
+		assert.Equal(t, http.StatusRequestEntityTooLarge, recorder.Code)
+		assert.JSONEq(t, `{"error":"request body too large"}`, recorder.Body.String())
+	})
+}
@@ -0,0 +1,53 @@
+package api
+
+import (
+	"net/http"
+	"time"
+)
+
+// ContentTypeEnvelope is the media type a client accepts to get the JSON body
+// of successful responses wrapped in an EnvelopedResponse.
+const ContentTypeEnvelope = "application/vnd.envelope+json"
+
+// EnvelopedResponse wraps the body of a successful response, for clients
+// that expect {"data":...,"meta":...} documents.
+type EnvelopedResponse struct {
+	Data any          `json:"data"`
+	Meta EnvelopeMeta `json:"meta"`
+}
+
+// EnvelopeMeta describes the request an EnvelopedResponse answers.
+type EnvelopeMeta struct {
+	RequestID string    `json:"request_id"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// envelopeWriter marks a response to be enveloped.
+type envelopeWriter struct {
+	http.ResponseWriter
+	requestID string
+}
+
+func (w *envelopeWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// WithEnvelope returns a writer the response helpers wrap successful JSON
+// bodies in an EnvelopedResponse for, with requestID in its meta. Error
+// bodies and XML documents are written as usual.
+func WithEnvelope(w http.ResponseWriter, requestID string) http.ResponseWriter {
+	return &envelopeWriter{ResponseWriter: w, requestID: requestID}
+}
+
+// envelope wraps data in an EnvelopedResponse when w was marked with
+// WithEnvelope and status is a success, and returns it as is otherwise.
+func envelope(w http.ResponseWriter, status int, data any) any {
+	ew, ok := find[*envelopeWriter](w)
+	if !ok || status >= http.StatusBadRequest {
+		return data
+	}
+	return EnvelopedResponse{
+		Data: data,
+		Meta: EnvelopeMeta{RequestID: ew.requestID, Timestamp: time.Now().UTC()},
+	}
+}
@@ -0,0 +1,117 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"log"
+	"net"
+	"net/http"
+)
+
+// Sentinel errors returned by the application services. Handlers use
+// errors.Is to map them to the appropriate HTTP status code.
+var (
+	ErrNotFound      = errors.New("resource not found")
+	ErrValidation    = errors.New("validation failed")
+	ErrConflict      = errors.New("conflict with the current state of the resource")
+	ErrLimitExceeded = errors.New("limit exceeded")
+	ErrUnavailable   = errors.New("database unavailable")
+)
+
+// Machine-readable codes of error responses, for clients that can't match on
+// the message.
+const (
+	CodeDatabaseUnavailable = "DATABASE_UNAVAILABLE"
+	CodeRequestTimeout      = "REQUEST_TIMEOUT"
+)
+
+// Conflict marks err as a conflict, so errors.Is matches both err and
+// ErrConflict, while keeping the message of err.
+func Conflict(err error) error {
+	return conflictError{err}
+}
+
+type conflictError struct {
+	error
+}
+
+func (e conflictError) Unwrap() []error {
+	return []error{e.error, ErrConflict}
+}
+
+// HandleServiceError responds to an error returned by a service:
+//
+//	*ValidationError  400 listing every invalid field
+//	ErrValidation     400
+//	ErrNotFound       404
+//	ErrConflict       409
+//	ErrLimitExceeded  422
+//	ErrUnavailable    503, also for lost or refused database connections
+//	DeadlineExceeded  504, once the request timeout cancelled the request
+//	anything else     500
+//
+// The message of err is the error body of 4xx responses. 5xx responses
+// carry a generic message instead, so database errors naming tables and
+// constraints don't reach clients: err is their detail only when w was
+// marked with WithErrorDetails. 503s and 504s also carry
+// CodeDatabaseUnavailable or CodeRequestTimeout.
+func HandleServiceError(w http.ResponseWriter, err error) {
+	var verr *ValidationError
+	if errors.As(err, &verr) {
+		ValidationErrorResponse(w, verr)
+		return
+	}
+
+	status, body := serviceError(err)
+	if status == http.StatusInternalServerError {
+		log.Printf("internal error: %s", err)
+	}
+	if status >= 500 && detailed(w) {
+		body.Detail = err.Error()
+	}
+	write(w, status, body)
+}
+
+// ErrorMessage returns the message HandleServiceError responds to err with,
+// for responses whose status was already sent, such as streams failing
+// halfway.
+func ErrorMessage(err error) string {
+	var verr *ValidationError
+	if errors.As(err, &verr) {
+		return ErrValidation.Error()
+	}
+	_, body := serviceError(err)
+	return body.Error
+}
+
+// serviceError maps err to the status and body of its error response, see
+// HandleServiceError.
+func serviceError(err error) (int, errorBody) {
+	switch {
+	case errors.Is(err, ErrValidation):
+		return http.StatusBadRequest, errorBody{Error: err.Error()}
+	case errors.Is(err, ErrNotFound):
+		return http.StatusNotFound, errorBody{Error: err.Error()}
+	case errors.Is(err, ErrConflict):
+		return http.StatusConflict, errorBody{Error: err.Error()}
+	case errors.Is(err, ErrLimitExceeded):
+		return http.StatusUnprocessableEntity, errorBody{Error: err.Error()}
+	case errors.Is(err, context.DeadlineExceeded):
+		return http.StatusGatewayTimeout, errorBody{Error: "request timed out", Code: CodeRequestTimeout}
+	case unavailable(err):
+		return http.StatusServiceUnavailable, errorBody{Error: ErrUnavailable.Error(), Code: CodeDatabaseUnavailable}
+	default:
+		return http.StatusInternalServerError, errorBody{Error: "internal server error"}
+	}
+}
+
+// unavailable reports whether err means the database can't be reached.
+func unavailable(err error) bool {
+	var opErr *net.OpError
+	return errors.Is(err, ErrUnavailable) ||
+		errors.Is(err, driver.ErrBadConn) ||
+		errors.Is(err, sql.ErrConnDone) ||
+		errors.As(err, &opErr)
+}
@@ -0,0 +1,95 @@
+package api
+
+import "net/http"
+
+// Content types the response helpers can write.
+const (
+	ContentTypeJSON = "application/json"
+	ContentTypeXML  = "application/xml"
+)
+
+// negotiatedWriter carries the content type negotiated for a response. The
+// helpers only get the ResponseWriter, not the request, so the choice travels
+// with the writer rather than in the request context.
+type negotiatedWriter struct {
+	http.ResponseWriter
+	contentType string
+}
+
+func (w *negotiatedWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// WithContentType returns a writer the response helpers write to in
+// contentType, ContentTypeJSON or ContentTypeXML.
+func WithContentType(w http.ResponseWriter, contentType string) http.ResponseWriter {
+	return &negotiatedWriter{ResponseWriter: w, contentType: contentType}
+}
+
+// prettyWriter marks a response to be indented.
+type prettyWriter struct {
+	http.ResponseWriter
+}
+
+func (w *prettyWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// WithIndent returns a writer the response helpers write indented documents
+// to, two spaces per level, instead of compact ones.
+func WithIndent(w http.ResponseWriter) http.ResponseWriter {
+	return &prettyWriter{ResponseWriter: w}
+}
+
+// detailedWriter marks a response to carry the details of its errors.
+type detailedWriter struct {
+	http.ResponseWriter
+}
+
+func (w *detailedWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// WithErrorDetails returns a writer HandleServiceError adds the underlying
+// error to, as the detail of responses whose message hides it. Meant for
+// non-production environments: the detail can name database hosts.
+func WithErrorDetails(w http.ResponseWriter) http.ResponseWriter {
+	return &detailedWriter{ResponseWriter: w}
+}
+
+// contentType returns the content type negotiated for w, and JSON when there
+// is none.
+func contentType(w http.ResponseWriter) string {
+	if nw, ok := find[*negotiatedWriter](w); ok {
+		return nw.contentType
+	}
+	return ContentTypeJSON
+}
+
+// indented reports whether responses to w are indented.
+func indented(w http.ResponseWriter) bool {
+	_, ok := find[*prettyWriter](w)
+	return ok
+}
+
+// detailed reports whether error responses to w carry their details.
+func detailed(w http.ResponseWriter) bool {
+	_, ok := find[*detailedWriter](w)
+	return ok
+}
+
+// find returns the first writer of type T in w, looking through writers that
+// wrap it with an Unwrap method.
+func find[T http.ResponseWriter](w http.ResponseWriter) (T, bool) {
+	for {
+		if t, ok := w.(T); ok {
+			return t, true
+		}
+		u, ok := w.(interface{ Unwrap() http.ResponseWriter })
+		if !ok {
+			var zero T
+			return zero, false
+		}
+		w = u.Unwrap()
+	}
+}
@@ -0,0 +1,110 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// PaginationParams holds the paging and sorting options of a listing request.
+type PaginationParams struct {
+	// Page is the 1-based page number.
+	Page int
+	// Limit is the page size, between 1 and MaxLimit.
+	Limit int
+	// MaxLimit caps the limit a request may ask for; 0 means no cap. It is
+	// only read from the defaults passed to ParsePaginationParams.
+	MaxLimit int
+	// Offset is the number of items to skip: (Page-1)*Limit, unless the
+	// request sets offset explicitly.
+	Offset int
+	// Sort is passed through as is; endpoints validate their own sort keys.
+	Sort string
+}
+
+// ParsePaginationParams reads the page, offset, limit and sort query params
+// of r. Missing values fall back to defaults, and the limit is clamped to
+// [1, defaults.MaxLimit] rather than rejected.
+//
+// Malformed values are reported as a *ValidationError naming each invalid
+// param, so the error maps to a 400 response.
+func ParsePaginationParams(r *http.Request, defaults PaginationParams) (PaginationParams, error) {
+	q := r.URL.Query()
+	params := PaginationParams{
+		Page:     max(defaults.Page, 1),
+		Limit:    defaults.Limit,
+		MaxLimit: defaults.MaxLimit,
+		Sort:     defaults.Sort,
+	}
+	if v := q.Get("sort"); v != "" {
+		params.Sort = v
+	}
+
+	verr := &ValidationError{}
+	if v := q.Get("page"); v != "" {
+		page, err := strconv.Atoi(v)
+		if err != nil || page < 1 {
+			verr.Add("page", fmt.Sprintf("invalid page %q", v))
+		}
+		params.Page = page
+	}
+
+	if v := q.Get("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil {
+			verr.Add("limit", fmt.Sprintf("invalid limit %q", v))
+		}
+		params.Limit = max(limit, 1)
+		if params.MaxLimit > 0 {
+			params.Limit = min(params.Limit, params.MaxLimit)
+		}
+	}
+
+	params.Offset = (params.Page - 1) * params.Limit
+	if v := q.Get("offset"); v != "" {
+		offset, err := strconv.Atoi(v)
+		if err != nil || offset < 0 {
+			verr.Add("offset", fmt.Sprintf("invalid offset %q", v))
+		}
+		params.Offset = offset
+	}
+
+	if err := verr.Err(); err != nil {
+		return PaginationParams{}, err
+	}
+	return params, nil
+}
+
+// SetLinkHeader sets the RFC 8288 (formerly RFC 5988) Link header of a page
+// of a listing, e.g.
+//
+//	Link: </catalog?limit=10&offset=0>; rel="first", </catalog?limit=10&offset=10>; rel="prev",
+//	      </catalog?limit=10&offset=30>; rel="next", </catalog?limit=10&offset=90>; rel="last"
+//
+// for the page at offset of limit items out of total. The URLs are those of r
+// with offset and limit replaced, and page dropped; every other query param
+// is kept. They are relative to the host, which is left to the client, as
+// it can't be trusted from the request behind a proxy. prev is omitted on
+// the first page and next on the last one.
+func SetLinkHeader(w http.ResponseWriter, r *http.Request, offset, limit int, total int64) {
+	limit = max(limit, 1)
+	link := func(offset int64, rel string) string {
+		q := r.URL.Query()
+		q.Del("page")
+		q.Set("offset", strconv.FormatInt(offset, 10))
+		q.Set("limit", strconv.Itoa(limit))
+		return fmt.Sprintf(`<%s?%s>; rel="%s"`, r.URL.EscapedPath(), q.Encode(), rel)
+	}
+
+	size, from := int64(limit), int64(offset)
+	links := []string{link(0, "first")}
+	if from > 0 {
+		links = append(links, link(max(from-size, 0), "prev"))
+	}
+	if from+size < total {
+		links = append(links, link(from+size, "next"))
+	}
+	links = append(links, link(max(total-1, 0)/size*size, "last"))
+	w.Header().Set("Link", strings.Join(links, ", "))
+}
@@ -0,0 +1,130 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParsePaginationParams(t *testing.T) {
+	defaults := PaginationParams{Limit: 10, MaxLimit: 100, Sort: "code"}
+	parse := func(query string) (PaginationParams, error) {
+		return ParsePaginationParams(httptest.NewRequest(http.MethodGet, "/items?"+query, nil), defaults)
+	}
+
+	t.Run("defaults", func(t *testing.T) {
+		params, err := parse("")
+
+		require.NoError(t, err)
+		assert.Equal(t, PaginationParams{Page: 1, Limit: 10, MaxLimit: 100, Offset: 0, Sort: "code"}, params)
+	})
+
+	t.Run("offset is computed from the page", func(t *testing.T) {
+		params, err := parse("page=3&limit=20&sort=-price")
+
+		require.NoError(t, err)
+		assert.Equal(t, 3, params.Page)
+		assert.Equal(t, 20, params.Limit)
+		assert.Equal(t, 40, params.Offset)
+		assert.Equal(t, "-price", params.Sort)
+	})
+
+	t.Run("explicit offset wins over the page", func(t *testing.T) {
+		params, err := parse("page=3&offset=5")
+
+		require.NoError(t, err)
+		assert.Equal(t, 5, params.Offset)
+	})
+
+	t.Run("limit is clamped", func(t *testing.T) {
+		tests := map[string]int{"0": 1, "-5": 1, "100": 100, "101": 100}
+		for limit, expected := range tests {
+			params, err := parse("limit=" + limit)
+
+			require.NoError(t, err)
+			assert.Equal(t, expected, params.Limit, limit)
+		}
+	})
+
+	t.Run("no cap without a max limit", func(t *testing.T) {
+		params, err := ParsePaginationParams(httptest.NewRequest(http.MethodGet, "/items?limit=500", nil), PaginationParams{Limit: 10})
+
+		require.NoError(t, err)
+		assert.Equal(t, 500, params.Limit)
+	})
+
+	t.Run("reports every invalid param", func(t *testing.T) {
+		_, err := parse("page=0&limit=abc&offset=-1")
+
+		var verr *ValidationError
+		require.ErrorAs(t, err, &verr)
+		assert.True(t, errors.Is(err, ErrValidation))
+		assert.Equal(t, []FieldError{
+			{Field: "page", Message: `invalid page "0"`},
+			{Field: "limit", Message: `invalid limit "abc"`},
+			{Field: "offset", Message: `invalid offset "-1"`},
+		}, verr.Errors)
+	})
+}
+
+func TestSetLinkHeader(t *testing.T) {
+	tests := []struct {
+		name   string
+		target string
+		offset int
+		limit  int
+		total  int64
+		link   string
+	}{
+		{
+			name:   "first page",
+			target: "/catalog",
+			offset: 0, limit: 10, total: 25,
+			link: `</catalog?limit=10&offset=0>; rel="first", </catalog?limit=10&offset=10>; rel="next", </catalog?limit=10&offset=20>; rel="last"`,
+		},
+		{
+			name:   "middle page keeps the other params",
+			target: "/v1/catalog?category=Shoes&page=2&limit=10",
+			offset: 10, limit: 10, total: 25,
+			link: `</v1/catalog?category=Shoes&limit=10&offset=0>; rel="first", </v1/catalog?category=Shoes&limit=10&offset=0>; rel="prev", </v1/catalog?category=Shoes&limit=10&offset=20>; rel="next", </v1/catalog?category=Shoes&limit=10&offset=20>; rel="last"`,
+		},
+		{
+			name:   "last page",
+			target: "/catalog?offset=20",
+			offset: 20, limit: 10, total: 25,
+			link: `</catalog?limit=10&offset=0>; rel="first", </catalog?limit=10&offset=10>; rel="prev", </catalog?limit=10&offset=20>; rel="last"`,
+		},
+		{
+			name:   "offset off the page grid",
+			target: "/catalog?offset=5",
+			offset: 5, limit: 10, total: 30,
+			link: `</catalog?limit=10&offset=0>; rel="first", </catalog?limit=10&offset=0>; rel="prev", </catalog?limit=10&offset=15>; rel="next", </catalog?limit=10&offset=20>; rel="last"`,
+		},
+		{
+			name:   "total a multiple of the limit",
+			target: "/catalog",
+			offset: 0, limit: 10, total: 20,
+			link: `</catalog?limit=10&offset=0>; rel="first", </catalog?limit=10&offset=10>; rel="next", </catalog?limit=10&offset=10>; rel="last"`,
+		},
+		{
+			name:   "empty listing",
+			target: "/categories/SHOES/products",
+			offset: 0, limit: 10, total: 0,
+			link: `</categories/SHOES/products?limit=10&offset=0>; rel="first", </categories/SHOES/products?limit=10&offset=0>; rel="last"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+
+			SetLinkHeader(recorder, httptest.NewRequest(http.MethodGet, tt.target, nil), tt.offset, tt.limit, tt.total)
+
+			assert.Equal(t, tt.link, recorder.Header().Get("Link"))
+		})
+	}
+}
@@ -2,17 +2,81 @@ package api
 
 import (
 	"encoding/json"
+	"encoding/xml"
+	"io"
 	"net/http"
 )
 
+// errorBody is the body of error responses. Code is a machine-readable
+// error code and Detail the underlying error, both only set by
+// HandleServiceError for errors whose message is generic.
+type errorBody struct {
+	XMLName xml.Name `json:"-" xml:"response"`
+	Error   string   `json:"error" xml:"error"`
+	Code    string   `json:"code,omitempty" xml:"code,omitempty"`
+	Detail  string   `json:"detail,omitempty" xml:"detail,omitempty"`
+}
+
+type validationErrorsBody struct {
+	XMLName xml.Name     `json:"-" xml:"response"`
+	Errors  []FieldError `json:"errors" xml:"errors>error"`
+}
+
 func OKResponse(w http.ResponseWriter, data any) {
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(data)
+	write(w, http.StatusOK, data)
+}
+
+// CreatedResponse responds with 201 and data, pointing the Location header at
+// the created resource.
+func CreatedResponse(w http.ResponseWriter, location string, data any) {
+	w.Header().Set("Location", location)
+	write(w, http.StatusCreated, data)
+}
+
+// NoContentResponse responds with 204 and no body, e.g. after a delete.
+func NoContentResponse(w http.ResponseWriter) {
+	w.WriteHeader(http.StatusNoContent)
 }
 
 func ErrorResponse(w http.ResponseWriter, status int, message string) {
-	w.Header().Set("Content-Type", "application/json")
+	write(w, status, errorBody{Error: message})
+}
+
+// ValidationErrorResponse responds with 400 and every invalid field of err,
+// as {"errors":[{"field":...,"message":...}]}.
+func ValidationErrorResponse(w http.ResponseWriter, err *ValidationError) {
+	write(w, http.StatusBadRequest, validationErrorsBody{Errors: err.Errors})
+}
+
+// NotFound responds with a 404 error body, in place of the default plain-text
+// response for unknown routes.
+func NotFound(w http.ResponseWriter, r *http.Request) {
+	ErrorResponse(w, http.StatusNotFound, "resource not found")
+}
+
+// write encodes data as JSON, or as XML when negotiated with WithContentType.
+// Either is indented when requested with WithIndent, and JSON is enveloped
+// when requested with WithEnvelope.
+func write(w http.ResponseWriter, status int, data any) {
+	indent := indented(w)
+
+	if contentType(w) == ContentTypeXML {
+		w.Header().Set("Content-Type", ContentTypeXML)
+		w.WriteHeader(status)
+		io.WriteString(w, xml.Header)
+		enc := xml.NewEncoder(w)
+		if indent {
+			enc.Indent("", "  ")
+		}
+		enc.Encode(data)
+		return
+	}
+
+	w.Header().Set("Content-Type", ContentTypeJSON)
 	w.WriteHeader(status)
-	json.NewEncoder(w).Encode(map[string]string{"error": message})
+	enc := json.NewEncoder(w)
+	if indent {
+		enc.SetIndent("", "  ")
+	}
+	enc.Encode(envelope(w, status, data))
 }
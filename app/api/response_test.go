@@ -1,11 +1,20 @@
 package api
 
 import (
+	"context"
+	"database/sql/driver"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"net"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestOKResponse(t *testing.T) {
@@ -28,6 +37,87 @@ func TestOKResponse(t *testing.T) {
 	})
 }
 
+func TestCreatedResponse(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	CreatedResponse(recorder, "/categories/HATS", map[string]string{"code": "HATS"})
+
+	assert.Equal(t, http.StatusCreated, recorder.Code)
+	assert.Equal(t, "/categories/HATS", recorder.Header().Get("Location"))
+	assert.Equal(t, "application/json", recorder.Header().Get("Content-Type"))
+	assert.JSONEq(t, `{"code":"HATS"}`, recorder.Body.String())
+}
+
+func TestNoContentResponse(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	NoContentResponse(recorder)
+
+	assert.Equal(t, http.StatusNoContent, recorder.Code)
+	assert.Empty(t, recorder.Header().Get("Content-Type"))
+	assert.Empty(t, recorder.Body.String())
+}
+
+func TestHandleServiceError(t *testing.T) {
+	verr := &ValidationError{}
+	verr.Add("code", "code is required")
+
+	tests := []struct {
+		name     string
+		err      error
+		status   int
+		response string
+	}{
+		{"field errors", verr, http.StatusBadRequest, `{"errors":[{"field":"code","message":"code is required"}]}`},
+		{"validation", fmt.Errorf("%w: bad sort", ErrValidation), http.StatusBadRequest, `{"error":"validation failed: bad sort"}`},
+		{"not found", fmt.Errorf("%w: product with code NOPE", ErrNotFound), http.StatusNotFound, `{"error":"resource not found: product with code NOPE"}`},
+		{"limit exceeded", fmt.Errorf("%w: at most 2 variants", ErrLimitExceeded), http.StatusUnprocessableEntity, `{"error":"limit exceeded: at most 2 variants"}`},
+		{"conflict keeps its message", Conflict(errors.New("SKU already exists")), http.StatusConflict, `{"error":"SKU already exists"}`},
+		{"unavailable", fmt.Errorf("querying: %w", driver.ErrBadConn), http.StatusServiceUnavailable, `{"error":"database unavailable","code":"DATABASE_UNAVAILABLE"}`},
+		{"refused connection", &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}, http.StatusServiceUnavailable, `{"error":"database unavailable","code":"DATABASE_UNAVAILABLE"}`},
+		{"timeout", fmt.Errorf("querying: %w", context.DeadlineExceeded), http.StatusGatewayTimeout, `{"error":"request timed out","code":"REQUEST_TIMEOUT"}`},
+		{"anything else", errors.New("boom"), http.StatusInternalServerError, `{"error":"internal server error"}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			HandleServiceError(recorder, tt.err)
+
+			assert.Equal(t, tt.status, recorder.Code)
+			assert.JSONEq(t, tt.response, recorder.Body.String())
+		})
+	}
+
+	t.Run("unavailable with details", func(t *testing.T) {
+		recorder := httptest.NewRecorder()
+		HandleServiceError(WithErrorDetails(recorder), fmt.Errorf("querying: %w", driver.ErrBadConn))
+
+		assert.Equal(t, http.StatusServiceUnavailable, recorder.Code)
+		assert.JSONEq(t, `{"error":"database unavailable","code":"DATABASE_UNAVAILABLE","detail":"querying: driver: bad connection"}`, recorder.Body.String())
+	})
+
+	t.Run("internal error with details", func(t *testing.T) {
+		recorder := httptest.NewRecorder()
+		HandleServiceError(WithErrorDetails(recorder), errors.New(`duplicate key value violates unique constraint "products_code_key"`))
+
+		assert.Equal(t, http.StatusInternalServerError, recorder.Code)
+		assert.JSONEq(t, `{"error":"internal server error","detail":"duplicate key value violates unique constraint \"products_code_key\""}`, recorder.Body.String())
+	})
+
+	t.Run("error messages", func(t *testing.T) {
+		assert.Equal(t, "resource not found: product with code NOPE", ErrorMessage(fmt.Errorf("%w: product with code NOPE", ErrNotFound)))
+		assert.Equal(t, "validation failed", ErrorMessage(verr))
+		assert.Equal(t, "internal server error", ErrorMessage(errors.New("boom")))
+	})
+
+	t.Run("conflict matches both errors", func(t *testing.T) {
+		sentinel := errors.New("SKU already exists")
+		err := Conflict(sentinel)
+
+		assert.ErrorIs(t, err, sentinel)
+		assert.ErrorIs(t, err, ErrConflict)
+	})
+}
+
 func TestErrorResponse(t *testing.T) {
 	t.Run("json response for a given http status code", func(t *testing.T) {
 		recorder := httptest.NewRecorder()
@@ -40,3 +130,149 @@ func TestErrorResponse(t *testing.T) {
 		assert.JSONEq(t, expected, recorder.Body.String(), "Response body does not match expected")
 	})
 }
+
+func TestValidationErrorResponse(t *testing.T) {
+	var verr ValidationError
+	verr.Add("code", "code is required")
+	verr.Add("name", "name is required")
+
+	recorder := httptest.NewRecorder()
+	ValidationErrorResponse(recorder, &verr)
+
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+	assert.Equal(t, "application/json", recorder.Header().Get("Content-Type"))
+	assert.JSONEq(t, `{"errors":[{"field":"code","message":"code is required"},{"field":"name","message":"name is required"}]}`, recorder.Body.String())
+}
+
+func TestNotFound(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /catalog", func(w http.ResponseWriter, r *http.Request) {
+		OKResponse(w, "catalog")
+	})
+	mux.HandleFunc("/", NotFound)
+
+	t.Run("unknown route", func(t *testing.T) {
+		recorder := httptest.NewRecorder()
+		mux.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/catlog", nil))
+
+		assert.Equal(t, http.StatusNotFound, recorder.Code)
+		assert.Equal(t, "application/json", recorder.Header().Get("Content-Type"))
+		assert.JSONEq(t, `{"error":"resource not found"}`, recorder.Body.String())
+	})
+
+	t.Run("known routes are not shadowed", func(t *testing.T) {
+		recorder := httptest.NewRecorder()
+		mux.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/catalog", nil))
+
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		assert.JSONEq(t, `"catalog"`, recorder.Body.String())
+	})
+}
+
+func TestXMLResponse(t *testing.T) {
+	type sampleResponse struct {
+		XMLName xml.Name `json:"-" xml:"sample"`
+		Message string   `json:"message" xml:"message"`
+	}
+
+	t.Run("ok response", func(t *testing.T) {
+		recorder := httptest.NewRecorder()
+		OKResponse(WithContentType(recorder, ContentTypeXML), sampleResponse{Message: "Success"})
+
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		assert.Equal(t, "application/xml", recorder.Header().Get("Content-Type"))
+		assert.Equal(t, xml.Header+"<sample><message>Success</message></sample>", recorder.Body.String())
+	})
+
+	t.Run("validation errors", func(t *testing.T) {
+		var verr ValidationError
+		verr.Add("code", "code is required")
+
+		recorder := httptest.NewRecorder()
+		ValidationErrorResponse(WithContentType(recorder, ContentTypeXML), &verr)
+
+		assert.Equal(t, http.StatusBadRequest, recorder.Code)
+		assert.Equal(t, xml.Header+"<response><errors><error><field>code</field><message>code is required</message></error></errors></response>", recorder.Body.String())
+	})
+
+	t.Run("negotiated below a wrapping writer", func(t *testing.T) {
+		recorder := httptest.NewRecorder()
+		w := &unwrapper{ResponseWriter: WithContentType(recorder, ContentTypeXML)}
+		ErrorResponse(w, http.StatusNotFound, "resource not found")
+
+		assert.Equal(t, "application/xml", recorder.Header().Get("Content-Type"))
+	})
+}
+
+func TestIndentedResponse(t *testing.T) {
+	type sampleResponse struct {
+		XMLName xml.Name `json:"-" xml:"sample"`
+		Message string   `json:"message" xml:"message"`
+	}
+
+	t.Run("json", func(t *testing.T) {
+		recorder := httptest.NewRecorder()
+		OKResponse(WithIndent(recorder), sampleResponse{Message: "Success"})
+
+		assert.Equal(t, "{\n  \"message\": \"Success\"\n}\n", recorder.Body.String())
+	})
+
+	t.Run("xml", func(t *testing.T) {
+		recorder := httptest.NewRecorder()
+		OKResponse(WithIndent(WithContentType(recorder, ContentTypeXML)), sampleResponse{Message: "Success"})
+
+		assert.Equal(t, xml.Header+"<sample>\n  <message>Success</message>\n</sample>", recorder.Body.String())
+	})
+
+	t.Run("compact by default", func(t *testing.T) {
+		recorder := httptest.NewRecorder()
+		OKResponse(recorder, sampleResponse{Message: "Success"})
+
+		assert.Equal(t, "{\"message\":\"Success\"}\n", recorder.Body.String())
+	})
+}
+
+func TestEnvelopedResponse(t *testing.T) {
+	type sampleResponse struct {
+		XMLName xml.Name `json:"-" xml:"sample"`
+		Message string   `json:"message" xml:"message"`
+	}
+
+	t.Run("wraps successful json bodies", func(t *testing.T) {
+		recorder := httptest.NewRecorder()
+		OKResponse(WithEnvelope(recorder, "req-1"), sampleResponse{Message: "Success"})
+
+		var body struct {
+			Data sampleResponse `json:"data"`
+			Meta EnvelopeMeta   `json:"meta"`
+		}
+		require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &body))
+		assert.Equal(t, "Success", body.Data.Message)
+		assert.Equal(t, "req-1", body.Meta.RequestID)
+		assert.WithinDuration(t, time.Now(), body.Meta.Timestamp, time.Minute)
+	})
+
+	t.Run("leaves errors as they are", func(t *testing.T) {
+		recorder := httptest.NewRecorder()
+		ErrorResponse(WithEnvelope(recorder, "req-1"), http.StatusNotFound, "resource not found")
+
+		assert.JSONEq(t, `{"error":"resource not found"}`, recorder.Body.String())
+	})
+
+	t.Run("leaves xml as it is", func(t *testing.T) {
+		recorder := httptest.NewRecorder()
+		OKResponse(WithEnvelope(WithContentType(recorder, ContentTypeXML), "req-1"), sampleResponse{Message: "Success"})
+
+		assert.Equal(t, xml.Header+"<sample><message>Success</message></sample>", recorder.Body.String())
+	})
+}
+
+// unwrapper stands for middleware that wraps the ResponseWriter, like the
+// tracing status recorder.
+type unwrapper struct {
+	http.ResponseWriter
+}
+
+func (u *unwrapper) Unwrap() http.ResponseWriter {
+	return u.ResponseWriter
+}
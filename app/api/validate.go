@@ -0,0 +1,224 @@
+package api
+
+import (
+	"fmt"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/eya20/hiring_test/app/validation"
+)
+
+// FieldError describes why a single request field is invalid.
+type FieldError struct {
+	Field   string `json:"field" xml:"field"`
+	Message string `json:"message" xml:"message"`
+}
+
+// ValidationError lists every invalid field of a request. It wraps
+// ErrValidation so handlers can keep matching on the sentinel.
+//
+// It doubles as an accumulator: Add every failing field, then return Err.
+type ValidationError struct {
+	Errors []FieldError
+}
+
+// Add records that field is invalid.
+func (e *ValidationError) Add(field, message string) {
+	e.Errors = append(e.Errors, FieldError{Field: field, Message: message})
+}
+
+// Err returns e, or nil when no field error was added.
+func (e *ValidationError) Err() error {
+	if len(e.Errors) == 0 {
+		return nil
+	}
+	return e
+}
+
+func (e *ValidationError) Error() string {
+	messages := make([]string, len(e.Errors))
+	for i, f := range e.Errors {
+		messages[i] = f.Message
+	}
+	return ErrValidation.Error() + ": " + strings.Join(messages, "; ")
+}
+
+func (e *ValidationError) Unwrap() error {
+	return ErrValidation
+}
+
+// ValidateStruct checks v against the rules in its `validate` struct tags and
+// returns a *ValidationError listing every failing field, or nil.
+//
+// Supported rules, comma separated:
+//
+//	required    the value must not be the zero value (nil pointers included)
+//	omitempty   skip the remaining rules when the value, or the value it points to, is zero
+//	min=N       minimum length for strings and slices, minimum value for numbers
+//	max=N       maximum length for strings and slices, maximum value for numbers
+//	positive    numbers must be greater than zero
+//	alphanum    strings may only contain ASCII letters and digits
+//	url         strings must be absolute http or https URLs
+//	code        strings must be valid codes, see validation.ValidateCode
+//	name        strings may be at most validation.MaxNameLength characters
+//	description strings may be at most validation.MaxDescriptionLength characters
+//	dive        validate every element of a slice of structs
+//
+// Nil pointers skip every rule but required. Fields are reported by their
+// JSON name. Unknown rules panic, as they are programming errors.
+func ValidateStruct(v any) error {
+	errs := &ValidationError{}
+	validateStruct(reflect.Indirect(reflect.ValueOf(v)), "", errs)
+	return errs.Err()
+}
+
+func validateStruct(v reflect.Value, prefix string, errs *ValidationError) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("validate")
+		if tag == "" || !field.IsExported() {
+			continue
+		}
+
+		name := prefix + jsonName(field)
+		value := v.Field(i)
+		for _, rule := range strings.Split(tag, ",") {
+			if rule == "omitempty" {
+				if v := reflect.Indirect(value); !v.IsValid() || v.IsZero() {
+					break
+				}
+				continue
+			}
+			msg, ok := check(rule, name, value, errs)
+			if !ok {
+				errs.Add(name, msg)
+				break
+			}
+		}
+	}
+}
+
+// check applies a single rule to value. It reports false with a message when
+// the rule fails.
+func check(rule, name string, value reflect.Value, errs *ValidationError) (string, bool) {
+	key, param, _ := strings.Cut(rule, "=")
+
+	if key == "required" {
+		if value.IsZero() {
+			return name + " is required", false
+		}
+		return "", true
+	}
+
+	if value.Kind() == reflect.Pointer {
+		if value.IsNil() {
+			return "", true
+		}
+		value = value.Elem()
+	}
+
+	switch key {
+	case "min", "max":
+		limit, err := strconv.ParseFloat(param, 64)
+		if err != nil {
+			panic(fmt.Sprintf("api: invalid %s rule %q on %s", key, rule, name))
+		}
+		return checkBound(key, name, param, limit, value)
+	case "positive":
+		return checkPositive(name, value)
+	case "alphanum":
+		for _, r := range value.String() {
+			if r > unicode.MaxASCII || !(unicode.IsLetter(r) || unicode.IsDigit(r)) {
+				return name + " must contain only letters and digits", false
+			}
+		}
+		return "", true
+	case "url":
+		if u, err := url.Parse(value.String()); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return name + " must be an absolute http or https URL", false
+		}
+		return "", true
+	case "code":
+		if validation.ValidateCode(value.String()) != nil {
+			return name + " " + validation.CodeFormat, false
+		}
+		return "", true
+	case "name":
+		return checkLength(name, value.String(), validation.MaxNameLength)
+	case "description":
+		return checkLength(name, value.String(), validation.MaxDescriptionLength)
+	case "dive":
+		for i := 0; i < value.Len(); i++ {
+			validateStruct(reflect.Indirect(value.Index(i)), fmt.Sprintf("%s[%d].", name, i), errs)
+		}
+		return "", true
+	}
+	panic(fmt.Sprintf("api: unknown validation rule %q on %s", rule, name))
+}
+
+func checkBound(key, name, param string, limit float64, value reflect.Value) (string, bool) {
+	var (
+		n    float64
+		unit string
+	)
+	switch value.Kind() {
+	case reflect.String:
+		n, unit = float64(len([]rune(value.String()))), " characters"
+	case reflect.Slice, reflect.Map:
+		n, unit = float64(value.Len()), " items"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n = float64(value.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n = float64(value.Uint())
+	case reflect.Float32, reflect.Float64:
+		n = value.Float()
+	default:
+		panic(fmt.Sprintf("api: %s rule not supported on %s (%s)", key, name, value.Kind()))
+	}
+
+	if key == "min" && n < limit {
+		return fmt.Sprintf("%s must be at least %s%s", name, param, unit), false
+	}
+	if key == "max" && n > limit {
+		return fmt.Sprintf("%s must be at most %s%s", name, param, unit), false
+	}
+	return "", true
+}
+
+func checkPositive(name string, value reflect.Value) (string, bool) {
+	var positive bool
+	switch value.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		positive = value.Int() > 0
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		positive = value.Uint() > 0
+	case reflect.Float32, reflect.Float64:
+		positive = value.Float() > 0
+	default:
+		panic(fmt.Sprintf("api: positive rule not supported on %s (%s)", name, value.Kind()))
+	}
+	if !positive {
+		return name + " must be greater than zero", false
+	}
+	return "", true
+}
+
+func checkLength(name, value string, max int) (string, bool) {
+	if err := validation.ValidateLength(name, value, max); err != nil {
+		return err.Error(), false
+	}
+	return "", true
+}
+
+// jsonName returns the name a field is serialized with.
+func jsonName(f reflect.StructField) string {
+	name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+	if name == "" || name == "-" {
+		return f.Name
+	}
+	return name
+}
@@ -0,0 +1,147 @@
+package api
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type validatedItem struct {
+	Name string `json:"name" validate:"required"`
+}
+
+type validatedRequest struct {
+	Code     string          `json:"code" validate:"required,min=3,max=8,alphanum"`
+	Count    int             `json:"count" validate:"min=1,max=10"`
+	Price    *float64        `json:"price" validate:"min=0"`
+	Items    []validatedItem `json:"items" validate:"max=2,dive"`
+	Category *string         `json:"category" validate:"code"`
+	Discount *float64        `json:"discount" validate:"omitempty,positive"`
+	Website  string          `json:"website" validate:"omitempty,url"`
+	Ignored  string
+	internal string
+}
+
+func TestValidateStruct(t *testing.T) {
+	price := func(v float64) *float64 { return &v }
+	code := func(v string) *string { return &v }
+
+	tests := []struct {
+		name   string
+		req    validatedRequest
+		errors []FieldError
+	}{
+		{
+			name: "valid",
+			req:  validatedRequest{Code: "ABC123", Count: 1, Price: price(0), Items: []validatedItem{{Name: "a"}}, Category: code("CLOTHING")},
+		},
+		{
+			name: "nil pointer skips rules",
+			req:  validatedRequest{Code: "ABC", Count: 10},
+		},
+		{
+			name:   "required stops at the first failing rule",
+			req:    validatedRequest{Count: 1},
+			errors: []FieldError{{Field: "code", Message: "code is required"}},
+		},
+		{
+			name: "every failing field is reported",
+			req:  validatedRequest{Code: "AB-", Count: 11, Price: price(-1)},
+			errors: []FieldError{
+				{Field: "code", Message: "code must contain only letters and digits"},
+				{Field: "count", Message: "count must be at most 10"},
+				{Field: "price", Message: "price must be at least 0"},
+			},
+		},
+		{
+			name:   "code format",
+			req:    validatedRequest{Code: "ABC", Count: 1, Category: code("my code!")},
+			errors: []FieldError{{Field: "category", Message: "category must be 3 to 50 characters of A-Z, 0-9, _ or -"}},
+		},
+		{
+			name: "omitempty skips zero values",
+			req:  validatedRequest{Code: "ABC", Count: 1, Discount: price(0)},
+		},
+		{
+			name:   "positive",
+			req:    validatedRequest{Code: "ABC", Count: 1, Discount: price(-0.5)},
+			errors: []FieldError{{Field: "discount", Message: "discount must be greater than zero"}},
+		},
+		{
+			name: "url",
+			req:  validatedRequest{Code: "ABC", Count: 1, Website: "https://example.com/a.png"},
+		},
+		{
+			name:   "relative url",
+			req:    validatedRequest{Code: "ABC", Count: 1, Website: "/a.png"},
+			errors: []FieldError{{Field: "website", Message: "website must be an absolute http or https URL"}},
+		},
+		{
+			name:   "url scheme",
+			req:    validatedRequest{Code: "ABC", Count: 1, Website: "ftp://example.com/a.png"},
+			errors: []FieldError{{Field: "website", Message: "website must be an absolute http or https URL"}},
+		},
+		{
+			name:   "string length",
+			req:    validatedRequest{Code: "ABCDEFGHI", Count: 1},
+			errors: []FieldError{{Field: "code", Message: "code must be at most 8 characters"}},
+		},
+		{
+			name: "dive into slice elements",
+			req:  validatedRequest{Code: "ABC", Count: 1, Items: []validatedItem{{Name: "a"}, {}}},
+			errors: []FieldError{
+				{Field: "items[1].name", Message: "items[1].name is required"},
+			},
+		},
+		{
+			name:   "slice length",
+			req:    validatedRequest{Code: "ABC", Count: 1, Items: []validatedItem{{"a"}, {"b"}, {"c"}}},
+			errors: []FieldError{{Field: "items", Message: "items must be at most 2 items"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateStruct(tt.req)
+
+			if tt.errors == nil {
+				assert.NoError(t, err)
+				return
+			}
+
+			var verr *ValidationError
+			assert.True(t, errors.As(err, &verr))
+			assert.Equal(t, tt.errors, verr.Errors)
+			assert.ErrorIs(t, err, ErrValidation)
+		})
+	}
+}
+
+func TestValidationErrorMessage(t *testing.T) {
+	err := &ValidationError{Errors: []FieldError{
+		{Field: "code", Message: "code is required"},
+		{Field: "name", Message: "name is required"},
+	}}
+
+	assert.EqualError(t, err, "validation failed: code is required; name is required")
+}
+
+func TestValidationErrorAccumulator(t *testing.T) {
+	var verr ValidationError
+	assert.NoError(t, verr.Err())
+
+	verr.Add("code", "code is required")
+	err := verr.Err()
+
+	assert.ErrorIs(t, err, ErrValidation)
+	assert.EqualError(t, err, "validation failed: code is required")
+}
+
+func TestValidateStructUnknownRule(t *testing.T) {
+	assert.Panics(t, func() {
+		_ = ValidateStruct(struct {
+			Code string `validate:"uuid"`
+		}{})
+	})
+}
@@ -0,0 +1,37 @@
+package catalog
+
+import "fmt"
+
+// Config holds the operator-tunable settings of the listing endpoints.
+type Config struct {
+	// DefaultPageSize is the limit used when a request doesn't set one.
+	DefaultPageSize int
+	// MaxPageSize caps the limit a request may ask for.
+	MaxPageSize int
+	// CacheSeconds is the max-age advertised to browsers and CDNs for the
+	// catalog listing; 0 sends no caching headers.
+	CacheSeconds int
+}
+
+// DefaultConfig returns the limits used when nothing is configured.
+func DefaultConfig() Config {
+	return Config{
+		DefaultPageSize: defaultLimit,
+		MaxPageSize:     maxLimit,
+	}
+}
+
+// Validate checks that the page sizes are positive and consistent, and that
+// the cache duration isn't negative.
+func (c Config) Validate() error {
+	if c.DefaultPageSize < minLimit {
+		return fmt.Errorf("default page size must be at least %d, got %d", minLimit, c.DefaultPageSize)
+	}
+	if c.MaxPageSize < c.DefaultPageSize {
+		return fmt.Errorf("max page size %d is smaller than the default page size %d", c.MaxPageSize, c.DefaultPageSize)
+	}
+	if c.CacheSeconds < 0 {
+		return fmt.Errorf("cache seconds must not be negative, got %d", c.CacheSeconds)
+	}
+	return nil
+}
@@ -0,0 +1,64 @@
+package catalog
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/shopspring/decimal"
+)
+
+// BaseCurrency is the currency every exchange rate is expressed against.
+const BaseCurrency = "USD"
+
+// ExchangeRates holds static exchange rates keyed by ISO currency code,
+// expressed as units of that currency per one unit of BaseCurrency.
+type ExchangeRates map[string]decimal.Decimal
+
+// ParseExchangeRates parses a comma separated list of CODE=RATE pairs,
+// e.g. "EUR=0.92,GBP=0.79". The base currency is always supported.
+func ParseExchangeRates(s string) (ExchangeRates, error) {
+	rates := ExchangeRates{BaseCurrency: decimal.NewFromInt(1)}
+	if strings.TrimSpace(s) == "" {
+		return rates, nil
+	}
+
+	for _, pair := range strings.Split(s, ",") {
+		code, value, ok := strings.Cut(strings.TrimSpace(pair), "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid exchange rate %q", pair)
+		}
+
+		rate, err := decimal.NewFromString(value)
+		if err != nil || !rate.IsPositive() {
+			return nil, fmt.Errorf("invalid exchange rate for %s: %q", code, value)
+		}
+		rates[strings.ToUpper(code)] = rate
+	}
+	return rates, nil
+}
+
+// Supports reports whether prices can be converted to and from currency.
+func (r ExchangeRates) Supports(currency string) bool {
+	_, ok := r[currency]
+	return ok
+}
+
+// Convert converts amount from one currency to another through the base
+// currency. The result is not rounded; prices are rounded once, when they
+// are formatted for output.
+func (r ExchangeRates) Convert(amount decimal.Decimal, from, to string) (decimal.Decimal, error) {
+	if from == to {
+		return amount, nil
+	}
+
+	fromRate, ok := r[from]
+	if !ok {
+		return decimal.Decimal{}, fmt.Errorf("unsupported currency %s", from)
+	}
+	toRate, ok := r[to]
+	if !ok {
+		return decimal.Decimal{}, fmt.Errorf("unsupported currency %s", to)
+	}
+
+	return amount.Div(fromRate).Mul(toRate), nil
+}
@@ -0,0 +1,69 @@
+package catalog
+
+import (
+	"testing"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseExchangeRates(t *testing.T) {
+	t.Run("base currency is always supported", func(t *testing.T) {
+		rates, err := ParseExchangeRates("")
+
+		assert.NoError(t, err)
+		assert.True(t, rates.Supports("USD"))
+		assert.False(t, rates.Supports("EUR"))
+	})
+
+	t.Run("parses rates", func(t *testing.T) {
+		rates, err := ParseExchangeRates("eur=0.92, GBP=0.79")
+
+		assert.NoError(t, err)
+		assert.True(t, decimal.RequireFromString("0.92").Equal(rates["EUR"]))
+		assert.True(t, decimal.RequireFromString("0.79").Equal(rates["GBP"]))
+	})
+
+	t.Run("rejects malformed rates", func(t *testing.T) {
+		for _, s := range []string{"EUR", "EUR=abc", "EUR=0", "EUR=-1"} {
+			_, err := ParseExchangeRates(s)
+			assert.Error(t, err, s)
+		}
+	})
+}
+
+func TestExchangeRatesConvert(t *testing.T) {
+	rates, _ := ParseExchangeRates("EUR=0.5,GBP=0.25")
+
+	tests := []struct {
+		amount   string
+		from, to string
+		expected string
+	}{
+		{"10.99", "USD", "USD", "10.99"},
+		{"10.00", "USD", "EUR", "5"},
+		{"10.00", "EUR", "USD", "20"},
+		{"10.00", "EUR", "GBP", "5"},
+		{"10.99", "USD", "GBP", "2.7475"},
+	}
+
+	for _, tt := range tests {
+		converted, err := rates.Convert(decimal.RequireFromString(tt.amount), tt.from, tt.to)
+
+		assert.NoError(t, err)
+		assert.True(t, decimal.RequireFromString(tt.expected).Equal(converted), "%s %s -> %s: got %s", tt.amount, tt.from, tt.to, converted)
+	}
+
+	_, err := rates.Convert(decimal.NewFromInt(1), "USD", "JPY")
+	assert.Error(t, err)
+}
+
+func TestValidateCurrency(t *testing.T) {
+	for _, code := range []string{"USD", "EUR", "JPY", "XCG"} {
+		assert.NoError(t, validateCurrency(code), code)
+	}
+
+	for _, code := range []string{"DOLLAR", "usd", "US", "", "XXX", "XTS"} {
+		assert.Error(t, validateCurrency(code), code)
+	}
+}
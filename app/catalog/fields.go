@@ -0,0 +1,78 @@
+package catalog
+
+import (
+	"encoding/xml"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// productFields maps the names accepted by the fields query param to the
+// matching value of a listed Product. The names are the product JSON keys.
+var productFields = map[string]func(Product) any{
+	"code":     func(p Product) any { return p.Code },
+	"name":     func(p Product) any { return p.Name },
+	"sku":      func(p Product) any { return p.SKU },
+	"price":    func(p Product) any { return p.Price },
+	"currency": func(p Product) any { return p.Currency },
+	"category": func(p Product) any { return p.Category },
+}
+
+// SparseResponse is a product listing restricted to the fields the client asked for.
+type SparseResponse struct {
+	XMLName  xml.Name        `json:"-" xml:"response"`
+	Products []SparseProduct `json:"products" xml:"products>product"`
+	Total    int64           `json:"total" xml:"total"`
+}
+
+// SparseProduct holds the selected fields of a product, keyed by field name.
+type SparseProduct map[string]any
+
+// MarshalXML writes one element per field, in name order, as encoding/xml
+// can't marshal maps.
+func (p SparseProduct) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	if err := e.EncodeToken(start); err != nil {
+		return err
+	}
+	for _, field := range slices.Sorted(maps.Keys(p)) {
+		if err := e.EncodeElement(p[field], xml.StartElement{Name: xml.Name{Local: field}}); err != nil {
+			return err
+		}
+	}
+	return e.EncodeToken(start.End())
+}
+
+// parseFields reads a comma-separated list of product fields. Unknown names
+// are rejected rather than ignored, so typos don't go unnoticed.
+func parseFields(v string) ([]string, error) {
+	var fields []string
+	for _, field := range strings.Split(v, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+		if _, ok := productFields[field]; !ok {
+			return nil, fmt.Errorf("invalid field %q", field)
+		}
+		fields = append(fields, field)
+	}
+	return fields, nil
+}
+
+// SelectFields keeps only the given fields of every product in res.
+func SelectFields(res Response, fields []string) SparseResponse {
+	products := make([]SparseProduct, len(res.Products))
+	for i, p := range res.Products {
+		product := make(SparseProduct, len(fields))
+		for _, field := range fields {
+			product[field] = productFields[field](p)
+		}
+		products[i] = product
+	}
+
+	return SparseResponse{
+		Products: products,
+		Total:    res.Total,
+	}
+}
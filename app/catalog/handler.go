@@ -2,55 +2,716 @@ package catalog
 
 import (
 	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"log"
 	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
 
-	"github.com/eya20/hiring_test/models"
+	"github.com/eya20/hiring_test/app/api"
 )
 
+// userHeader names the user making a request. It is set by the client or
+// the proxy in front of the API, and only recorded for auditing: the routes
+// writing in bulk authenticate with a token, see middleware.WriteAuth.
+const userHeader = "X-User"
+
+// ContentTypeNDJSON is the content type of GET /catalog/stream.
+const ContentTypeNDJSON = "application/x-ndjson"
+
+// streamWriteTimeout bounds the write of each line of GET /catalog/stream,
+// in place of the WriteTimeout of the server, which would cut long exports
+// short.
+const streamWriteTimeout = 30 * time.Second
+
 type Response struct {
-	Products []Product `json:"products"`
+	XMLName  xml.Name  `json:"-" xml:"response"`
+	Products []Product `json:"products" xml:"products>product"`
+	Total    int64     `json:"total" xml:"total"`
 }
 
 type Product struct {
-	Code  string  `json:"code"`
-	Price float64 `json:"price"`
+	Code     string  `json:"code" xml:"code"`
+	Name     string  `json:"name,omitempty" xml:"name,omitempty"`
+	SKU      string  `json:"sku" xml:"sku"`
+	Price    float64 `json:"price" xml:"price"`
+	Currency string  `json:"currency" xml:"currency"`
+	Category string  `json:"category" xml:"category"`
+}
+
+// ProductDetails is a single product with its variants. Category is the
+// category name, kept for existing clients; CategoryCode and CategoryName
+// are omitted for uncategorised products. Variants is never nil, so a product
+// without variants encodes them as [] rather than null.
+type ProductDetails struct {
+	XMLName  xml.Name  `json:"-" xml:"product"`
+	Code     string    `json:"code" xml:"code"`
+	Name     string    `json:"name,omitempty" xml:"name,omitempty"`
+	SKU      string    `json:"sku" xml:"sku"`
+	Price    float64   `json:"price" xml:"price"`
+	Currency string    `json:"currency" xml:"currency"`
+	Category string    `json:"category" xml:"category"`
+	Featured bool      `json:"featured" xml:"featured"`
+	Version  int       `json:"version" xml:"version"`
+	Variants []Variant `json:"variants" xml:"variants>variant"`
+	Shipping *Shipping `json:"shipping,omitempty" xml:"shipping,omitempty"`
+	Images   []string  `json:"images,omitempty" xml:"images>image,omitempty"`
+
+	CategoryCode string `json:"category_code,omitempty" xml:"category_code,omitempty"`
+	CategoryName string `json:"category_name,omitempty" xml:"category_name,omitempty"`
+}
+
+// Shipping is the shipping metadata of a physical product.
+type Shipping struct {
+	WeightGrams   int    `json:"weight_grams" xml:"weight_grams"`
+	LengthMm      int    `json:"length_mm" xml:"length_mm"`
+	WidthMm       int    `json:"width_mm" xml:"width_mm"`
+	HeightMm      int    `json:"height_mm" xml:"height_mm"`
+	ShippingClass string `json:"shipping_class" xml:"shipping_class"`
+}
+
+// ShippingEstimate is the cost of shipping Quantity units of a product.
+// WeightGrams is their total weight.
+type ShippingEstimate struct {
+	XMLName       xml.Name `json:"-" xml:"shipping_estimate"`
+	Code          string   `json:"code" xml:"code"`
+	Quantity      int      `json:"quantity" xml:"quantity"`
+	WeightGrams   int      `json:"weight_grams" xml:"weight_grams"`
+	ShippingClass string   `json:"shipping_class" xml:"shipping_class"`
+	Cost          float64  `json:"cost" xml:"cost"`
+	Currency      string   `json:"currency" xml:"currency"`
+}
+
+type Stats struct {
+	XMLName      xml.Name `json:"-" xml:"stats"`
+	Count        int64    `json:"count" xml:"count"`
+	AveragePrice string   `json:"average_price" xml:"average_price"`
+	Currency     string   `json:"currency" xml:"currency"`
+}
+
+// Count is the number of products matching the filters of a request. It is
+// given as both total, like the listing, and count, like the stats.
+type Count struct {
+	XMLName xml.Name `json:"-" xml:"response"`
+	Total   int64    `json:"total" xml:"total"`
+	Count   int64    `json:"count" xml:"count"`
+}
+
+type CreateProductRequest struct {
+	Code     string                 `json:"code" validate:"required,code"`
+	Name     string                 `json:"name" validate:"omitempty,name"`
+	SKU      string                 `json:"sku"`
+	Price    float64                `json:"price" validate:"positive"`
+	Currency string                 `json:"currency"`
+	Category string                 `json:"category"`
+	Variants []CreateVariantRequest `json:"variants" validate:"dive"`
+}
+
+// UpdateProductRequest is a partial update: only the fields present in the
+// body are changed. An empty sku or category clears it. Version is the
+// version of the product the update is based on, and is always required.
+type UpdateProductRequest struct {
+	Version  *int     `json:"version" validate:"required,min=1"`
+	Name     *string  `json:"name" validate:"omitempty,name"`
+	SKU      *string  `json:"sku"`
+	Price    *float64 `json:"price" validate:"positive"`
+	Currency *string  `json:"currency"`
+	Category *string  `json:"category"`
+	Featured *bool    `json:"featured"`
+}
+
+type CreateVariantRequest struct {
+	Name  string   `json:"name" validate:"required,name"`
+	SKU   string   `json:"sku" validate:"required,code"`
+	Price *float64 `json:"price" validate:"omitempty,positive"`
+	Image string   `json:"image" validate:"omitempty,max=2048,url"`
+}
+
+type UpdateVariantRequest struct {
+	Name  string   `json:"name" validate:"required,name"`
+	Price *float64 `json:"price" validate:"omitempty,positive"`
+	Image string   `json:"image" validate:"omitempty,max=2048,url"`
+}
+
+// ImageRequest adds the image at URL to a product.
+type ImageRequest struct {
+	URL string `json:"url" validate:"required,max=2048,url"`
+}
+
+// PriceHistory lists the price changes of a product, most recent first.
+type PriceHistory struct {
+	XMLName xml.Name      `json:"-" xml:"price_history"`
+	Code    string        `json:"code" xml:"code"`
+	Changes []PriceChange `json:"changes" xml:"changes>change"`
+}
+
+type PriceChange struct {
+	OldPrice  float64   `json:"old_price" xml:"old_price"`
+	NewPrice  float64   `json:"new_price" xml:"new_price"`
+	ChangedAt time.Time `json:"changed_at" xml:"changed_at"`
+	ChangedBy string    `json:"changed_by" xml:"changed_by"`
+}
+
+type FeaturedRequest struct {
+	Featured *bool `json:"featured"`
+}
+
+type CategoryRequest struct {
+	CategoryCode string `json:"category_code" validate:"required,code"`
+}
+
+// PriceAdjustRequest changes the price of every product directly in the
+// category CategoryCode by Percent, e.g. -10 for a 10% discount.
+type PriceAdjustRequest struct {
+	CategoryCode string   `json:"category_code" validate:"required,code"`
+	Percent      *float64 `json:"percent" validate:"required"`
+}
+
+// PriceAdjustment reports how many products a price adjustment repriced.
+type PriceAdjustment struct {
+	XMLName      xml.Name `json:"-" xml:"price_adjustment"`
+	CategoryCode string   `json:"category_code" xml:"category_code"`
+	Percent      float64  `json:"percent" xml:"percent"`
+	Updated      int      `json:"updated" xml:"updated"`
+}
+
+type FeaturedResponse struct {
+	XMLName  xml.Name `json:"-" xml:"product"`
+	Code     string   `json:"code" xml:"code"`
+	Featured bool     `json:"featured" xml:"featured"`
+}
+
+type Variant struct {
+	XMLName         xml.Name `json:"-" xml:"variant"`
+	Name            string   `json:"name" xml:"name"`
+	SKU             string   `json:"sku" xml:"sku"`
+	Price           float64  `json:"price" xml:"price"`
+	SalePrice       *float64 `json:"sale_price" xml:"sale_price"`
+	DiscountPercent *float64 `json:"discount_percent" xml:"discount_percent"`
+	Image           string   `json:"image,omitempty" xml:"image,omitempty"`
+}
+
+// VariantStock is the stock of a single variant.
+type VariantStock struct {
+	XMLName xml.Name `json:"-" xml:"variant_stock"`
+	SKU     string   `json:"sku" xml:"sku"`
+	Stock   int      `json:"stock" xml:"stock"`
 }
 
 type CatalogHandler struct {
-	repo models.ProductsRepositoryInterface
+	service *CatalogService
+	config  Config
 }
 
-func NewCatalogHandler(r models.ProductsRepositoryInterface) *CatalogHandler {
+func NewCatalogHandler(s *CatalogService, cfg Config) *CatalogHandler {
 	return &CatalogHandler{
-		repo: r,
+		service: s,
+		config:  cfg,
+	}
+}
+
+func (h *CatalogHandler) GetCatalog(w http.ResponseWriter, r *http.Request) {
+	params, err := ParseListParams(r, h.config)
+	if err != nil {
+		ParamsErrorResponse(w, err)
+		return
+	}
+
+	if params.Currency != "" && !h.service.SupportsCurrency(params.Currency) {
+		api.ErrorResponse(w, http.StatusBadRequest, "unsupported currency "+params.Currency)
+		return
+	}
+
+	res, err := h.service.GetProductsPaginatedWithFilters(r.Context(), params.Offset, params.Limit, params.FilterParams, params.Sort, params.Currency)
+	if err != nil {
+		api.HandleServiceError(w, err)
+		return
+	}
+
+	h.setCacheHeaders(w)
+	api.SetLinkHeader(w, r, params.Offset, params.Limit, res.Total)
+	WriteListing(w, res, params)
+}
+
+// WriteListing responds with a page of products in the shape params asks
+// for: restricted to params.Fields when set, and without the envelope when
+// params.Bare is set.
+func WriteListing(w http.ResponseWriter, res Response, params ListParams) {
+	if len(params.Fields) > 0 {
+		sparse := SelectFields(res, params.Fields)
+		if params.Bare {
+			writeBare(w, sparse.Products, sparse.Total)
+			return
+		}
+		api.OKResponse(w, sparse)
+		return
+	}
+	if params.Bare {
+		writeBare(w, res.Products, res.Total)
+		return
+	}
+	api.OKResponse(w, res)
+}
+
+// bareList is a listing without its envelope: a JSON array, or a <products>
+// document in XML, which needs a root element.
+type bareList[T any] struct {
+	XMLName  xml.Name `xml:"products"`
+	Products []T      `xml:"product"`
+}
+
+func (l bareList[T]) MarshalJSON() ([]byte, error) {
+	if l.Products == nil {
+		return []byte("[]"), nil
 	}
+	return json.Marshal(l.Products)
+}
+
+// writeBare responds with products without their envelope. The total moves
+// to the X-Total-Count header.
+func writeBare[T any](w http.ResponseWriter, products []T, total int64) {
+	w.Header().Set("X-Total-Count", strconv.FormatInt(total, 10))
+	api.OKResponse(w, bareList[T]{Products: products})
 }
 
-func (h *CatalogHandler) HandleGet(w http.ResponseWriter, r *http.Request) {
-	res, err := h.repo.GetAllProducts()
+// setCacheHeaders lets browsers and CDNs cache a successful listing response
+// for the configured duration.
+func (h *CatalogHandler) setCacheHeaders(w http.ResponseWriter) {
+	if h.config.CacheSeconds <= 0 {
+		return
+	}
+	w.Header().Set("Cache-Control", "public, max-age="+strconv.Itoa(h.config.CacheSeconds))
+	w.Header().Add("Vary", "Accept-Encoding")
+}
+
+// GetCount counts the products matching the filters of the listing, without
+// loading them.
+func (h *CatalogHandler) GetCount(w http.ResponseWriter, r *http.Request) {
+	filters, err := ParseFilterParams(r)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		ParamsErrorResponse(w, err)
 		return
 	}
 
-	// Map response
-	products := make([]Product, len(res))
-	for i, p := range res {
-		products[i] = Product{
-			Code:  p.Code,
-			Price: p.Price.InexactFloat64(),
+	total, err := h.service.CountProducts(r.Context(), filters)
+	if err != nil {
+		api.HandleServiceError(w, err)
+		return
+	}
+
+	api.OKResponse(w, Count{Total: total, Count: total})
+}
+
+// GetStream exports the catalog as JSON Lines, also known as NDJSON
+// (https://jsonlines.org):
+//
+//	{"code":"PROD001","sku":"SKU001","price":10.99,...,"variants":[...]}
+//	{"code":"PROD002","sku":"SKU002","price":12.49,...,"variants":[]}
+//
+// Every line is one product, as returned by GET /catalog/{code}, encoded as
+// a complete JSON object and ended by "\n". Products come in id order. Each
+// line is flushed as soon as it is written, so consumers can process
+// products while the export runs, and the server never holds the whole
+// catalog. The export runs as long as it takes: it is exempt from the
+// request timeout, see router.Streams, and each line gets streamWriteTimeout
+// to be written rather than the whole response the WriteTimeout of the
+// server.
+//
+// Errors before the first line get the usual JSON error response. Once
+// lines were sent the status can't change any more: the stream ends with a
+// last {"error":"..."} line instead, which consumers must check for.
+func (h *CatalogHandler) GetStream(w http.ResponseWriter, r *http.Request) {
+	currency, ok := h.currency(w, r)
+	if !ok {
+		return
+	}
+
+	rc := http.NewResponseController(w)
+	enc := json.NewEncoder(w)
+	started := false
+	start := func() {
+		w.Header().Set("Content-Type", ContentTypeNDJSON)
+		w.WriteHeader(http.StatusOK)
+		started = true
+	}
+
+	err := h.service.StreamProducts(r.Context(), currency, func(p ProductDetails) error {
+		if err := rc.SetWriteDeadline(time.Now().Add(streamWriteTimeout)); err != nil && !errors.Is(err, http.ErrNotSupported) {
+			return err
+		}
+		if !started {
+			start()
+		}
+		if err := enc.Encode(p); err != nil {
+			return err
+		}
+		if err := rc.Flush(); err != nil && !errors.Is(err, http.ErrNotSupported) {
+			return err
+		}
+		return nil
+	})
+	switch {
+	case err == nil && !started:
+		start()
+	case err != nil && !started:
+		api.HandleServiceError(w, err)
+	case err != nil:
+		log.Printf("catalog stream failed: %s", err)
+		enc.Encode(map[string]string{"error": api.ErrorMessage(err)})
+	}
+}
+
+func (h *CatalogHandler) GetFeatured(w http.ResponseWriter, r *http.Request) {
+	currency, ok := h.currency(w, r)
+	if !ok {
+		return
+	}
+
+	res, err := h.service.GetFeaturedProducts(r.Context(), currency)
+	if err != nil {
+		api.HandleServiceError(w, err)
+		return
+	}
+
+	api.OKResponse(w, res)
+}
+
+func (h *CatalogHandler) GetRandom(w http.ResponseWriter, r *http.Request) {
+	count := defaultRandomCount
+	if v := r.URL.Query().Get("count"); v != "" {
+		c, err := strconv.Atoi(v)
+		if err != nil {
+			api.ErrorResponse(w, http.StatusBadRequest, fmt.Sprintf("invalid count %q", v))
+			return
 		}
+		count = min(max(c, minLimit), maxRandomCount)
 	}
 
-	// Return the products as a JSON response
-	w.Header().Set("Content-Type", "application/json")
+	currency, ok := h.currency(w, r)
+	if !ok {
+		return
+	}
+
+	res, err := h.service.GetRandomProducts(r.Context(), count, r.URL.Query().Get("category"), currency)
+	if err != nil {
+		api.HandleServiceError(w, err)
+		return
+	}
+
+	api.OKResponse(w, res)
+}
 
-	response := Response{
+func (h *CatalogHandler) GetStats(w http.ResponseWriter, r *http.Request) {
+	stats, err := h.service.GetStats(r.Context())
+	if err != nil {
+		api.HandleServiceError(w, err)
+		return
+	}
+
+	api.OKResponse(w, stats)
+}
+
+func (h *CatalogHandler) SetFeatured(w http.ResponseWriter, r *http.Request) {
+	var req FeaturedRequest
+	if !api.DecodeBody(w, r, &req) {
+		return
+	}
+	if req.Featured == nil {
+		api.ErrorResponse(w, http.StatusBadRequest, "featured is required")
+		return
+	}
+
+	code := r.PathValue("code")
+	if err := h.service.SetProductFeatured(r.Context(), code, *req.Featured); err != nil {
+		api.HandleServiceError(w, err)
+		return
+	}
+
+	api.OKResponse(w, FeaturedResponse{
+		Code:     code,
+		Featured: *req.Featured,
+	})
+}
+
+// SetCategory moves a product to another category.
+func (h *CatalogHandler) SetCategory(w http.ResponseWriter, r *http.Request) {
+	var req CategoryRequest
+	if !api.DecodeBody(w, r, &req) {
+		return
+	}
+
+	product, err := h.service.SetProductCategory(r.Context(), r.PathValue("code"), req)
+	if err != nil {
+		api.HandleServiceError(w, err)
+		return
+	}
+
+	api.OKResponse(w, product)
+}
+
+// AdjustPrices applies a percentage change to the price of every product in
+// a category.
+func (h *CatalogHandler) AdjustPrices(w http.ResponseWriter, r *http.Request) {
+	var req PriceAdjustRequest
+	if !api.DecodeBody(w, r, &req) {
+		return
+	}
+
+	res, err := h.service.AdjustCategoryPrices(r.Context(), req, r.Header.Get(userHeader))
+	if err != nil {
+		api.HandleServiceError(w, err)
+		return
+	}
+
+	api.OKResponse(w, res)
+}
+
+func (h *CatalogHandler) UpdateProduct(w http.ResponseWriter, r *http.Request) {
+	var req UpdateProductRequest
+	if !api.DecodeBody(w, r, &req) {
+		return
+	}
+
+	product, err := h.service.UpdateProduct(r.Context(), r.PathValue("code"), req, r.Header.Get(userHeader))
+	if err != nil {
+		api.HandleServiceError(w, err)
+		return
+	}
+
+	api.OKResponse(w, product)
+}
+
+// GetPriceHistory lists the price changes of a product, most recent first.
+func (h *CatalogHandler) GetPriceHistory(w http.ResponseWriter, r *http.Request) {
+	history, err := h.service.GetPriceHistory(r.Context(), r.PathValue("code"))
+	if err != nil {
+		api.HandleServiceError(w, err)
+		return
+	}
+
+	api.OKResponse(w, history)
+}
+
+func (h *CatalogHandler) GetProduct(w http.ResponseWriter, r *http.Request) {
+	currency, ok := h.currency(w, r)
+	if !ok {
+		return
+	}
+
+	product, err := h.service.GetProductByCode(r.Context(), r.PathValue("code"), currency)
+	if err != nil {
+		api.HandleServiceError(w, err)
+		return
+	}
+
+	api.OKResponse(w, product)
+}
+
+// GetShipping estimates the cost of shipping ?qty= units of a product, one
+// by default.
+func (h *CatalogHandler) GetShipping(w http.ResponseWriter, r *http.Request) {
+	qty := 1
+	if v := r.URL.Query().Get("qty"); v != "" {
+		q, err := strconv.Atoi(v)
+		if err != nil || q < 1 || q > maxShippingQuantity {
+			api.ErrorResponse(w, http.StatusBadRequest, fmt.Sprintf("invalid qty %q, want 1 to %d", v, maxShippingQuantity))
+			return
+		}
+		qty = q
+	}
+
+	currency, ok := h.currency(w, r)
+	if !ok {
+		return
+	}
+
+	estimate, err := h.service.EstimateShipping(r.Context(), r.PathValue("code"), qty, currency)
+	if err != nil {
+		api.HandleServiceError(w, err)
+		return
+	}
+
+	api.OKResponse(w, estimate)
+}
+
+func (h *CatalogHandler) GetSimilar(w http.ResponseWriter, r *http.Request) {
+	limit, ok := h.similarLimit(w, r)
+	if !ok {
+		return
+	}
+	currency, ok := h.currency(w, r)
+	if !ok {
+		return
+	}
+
+	products, err := h.service.GetSimilarProducts(r.Context(), r.PathValue("code"), limit, currency)
+	if err != nil {
+		api.HandleServiceError(w, err)
+		return
+	}
+
+	api.OKResponse(w, Response{
 		Products: products,
+		Total:    int64(len(products)),
+	})
+}
+
+// GetRelated lists other products of the category of a product, for
+// cross-selling. Unlike GetSimilar it doesn't rank them by price.
+func (h *CatalogHandler) GetRelated(w http.ResponseWriter, r *http.Request) {
+	limit, ok := h.similarLimit(w, r)
+	if !ok {
+		return
+	}
+	currency, ok := h.currency(w, r)
+	if !ok {
+		return
 	}
 
-	if err := json.NewEncoder(w).Encode(response); err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+	products, err := h.service.GetRelatedProducts(r.Context(), r.PathValue("code"), limit, currency)
+	if err != nil {
+		api.HandleServiceError(w, err)
 		return
 	}
+
+	api.OKResponse(w, Response{
+		Products: products,
+		Total:    int64(len(products)),
+	})
+}
+
+// similarLimit reads the optional limit query param of GetSimilar and
+// GetRelated, clamped to the max page size, writing a 400 response and
+// returning false when it isn't a number.
+func (h *CatalogHandler) similarLimit(w http.ResponseWriter, r *http.Request) (int, bool) {
+	v := r.URL.Query().Get("limit")
+	if v == "" {
+		return defaultSimilarLimit, true
+	}
+
+	l, err := strconv.Atoi(v)
+	if err != nil {
+		api.ErrorResponse(w, http.StatusBadRequest, fmt.Sprintf("invalid limit %q", v))
+		return 0, false
+	}
+	return min(max(l, minLimit), h.config.MaxPageSize), true
+}
+
+func (h *CatalogHandler) GetProductBySKU(w http.ResponseWriter, r *http.Request) {
+	currency, ok := h.currency(w, r)
+	if !ok {
+		return
+	}
+
+	product, err := h.service.GetProductBySKU(r.Context(), r.PathValue("sku"), currency)
+	if err != nil {
+		api.HandleServiceError(w, err)
+		return
+	}
+
+	api.OKResponse(w, product)
+}
+
+func (h *CatalogHandler) CreateProduct(w http.ResponseWriter, r *http.Request) {
+	var req CreateProductRequest
+	if !api.DecodeBody(w, r, &req) {
+		return
+	}
+
+	product, err := h.service.CreateProductWithVariants(r.Context(), req)
+	if err != nil {
+		api.HandleServiceError(w, err)
+		return
+	}
+
+	api.CreatedResponse(w, "/catalog/"+url.PathEscape(product.Code), product)
+}
+
+func (h *CatalogHandler) CreateVariant(w http.ResponseWriter, r *http.Request) {
+	var req CreateVariantRequest
+	if !api.DecodeBody(w, r, &req) {
+		return
+	}
+
+	variant, err := h.service.CreateVariant(r.Context(), r.PathValue("code"), req)
+	if err != nil {
+		api.HandleServiceError(w, err)
+		return
+	}
+
+	api.OKResponse(w, variant)
+}
+
+func (h *CatalogHandler) UpdateVariant(w http.ResponseWriter, r *http.Request) {
+	var req UpdateVariantRequest
+	if !api.DecodeBody(w, r, &req) {
+		return
+	}
+
+	variant, err := h.service.UpdateVariant(r.Context(), r.PathValue("code"), r.PathValue("sku"), req)
+	if err != nil {
+		api.HandleServiceError(w, err)
+		return
+	}
+
+	api.OKResponse(w, variant)
+}
+
+// GetVariantStock returns the stock of a variant of a product.
+func (h *CatalogHandler) GetVariantStock(w http.ResponseWriter, r *http.Request) {
+	stock, err := h.service.GetVariantStock(r.Context(), r.PathValue("code"), r.PathValue("sku"))
+	if err != nil {
+		api.HandleServiceError(w, err)
+		return
+	}
+
+	api.OKResponse(w, stock)
+}
+
+// AddImage appends an image URL to a product.
+func (h *CatalogHandler) AddImage(w http.ResponseWriter, r *http.Request) {
+	var req ImageRequest
+	if !api.DecodeBody(w, r, &req) {
+		return
+	}
+
+	product, err := h.service.AddProductImage(r.Context(), r.PathValue("code"), req)
+	if err != nil {
+		api.HandleServiceError(w, err)
+		return
+	}
+
+	api.OKResponse(w, product)
+}
+
+// DeleteImage removes the image at a position, counted from zero, from a
+// product.
+func (h *CatalogHandler) DeleteImage(w http.ResponseWriter, r *http.Request) {
+	index, err := strconv.Atoi(r.PathValue("index"))
+	if err != nil || index < 0 {
+		api.ErrorResponse(w, http.StatusBadRequest, "invalid image index "+strconv.Quote(r.PathValue("index")))
+		return
+	}
+
+	if err := h.service.RemoveProductImage(r.Context(), r.PathValue("code"), index); err != nil {
+		api.HandleServiceError(w, err)
+		return
+	}
+
+	api.NoContentResponse(w)
+}
+
+// currency reads the optional currency query param, writing a 400 response
+// and returning false when prices can't be converted to it.
+func (h *CatalogHandler) currency(w http.ResponseWriter, r *http.Request) (string, bool) {
+	currency := strings.ToUpper(r.URL.Query().Get("currency"))
+	if currency != "" && !h.service.SupportsCurrency(currency) {
+		api.ErrorResponse(w, http.StatusBadRequest, "unsupported currency "+currency)
+		return "", false
+	}
+	return currency, true
 }
@@ -0,0 +1,2319 @@
+package catalog
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/eya20/hiring_test/app/api"
+	"github.com/eya20/hiring_test/app/middleware"
+	"github.com/eya20/hiring_test/models"
+	"github.com/lib/pq"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+type mockProductsRepository struct {
+	products     []models.Product
+	priceChanges []models.PriceChangeEvent
+	err          error
+	// eachDelay slows EachProduct down, before every product.
+	eachDelay time.Duration
+}
+
+func (m *mockProductsRepository) GetAllProducts(ctx context.Context) ([]models.Product, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	return m.products, nil
+}
+
+func (m *mockProductsRepository) EachProduct(ctx context.Context, batchSize int, fn func(models.Product) error) error {
+	if m.err != nil {
+		return m.err
+	}
+	for _, p := range m.products {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(m.eachDelay):
+		}
+		if err := fn(p); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *mockProductsRepository) GetProductByCode(ctx context.Context, code string, product *models.Product) error {
+	if m.err != nil {
+		return m.err
+	}
+	for _, p := range m.products {
+		if p.Code == code {
+			*product = p
+			return nil
+		}
+	}
+	return gorm.ErrRecordNotFound
+}
+
+func (m *mockProductsRepository) GetProductBySKU(ctx context.Context, sku string, product *models.Product) error {
+	if m.err != nil {
+		return m.err
+	}
+	for _, p := range m.products {
+		if p.SKU == sku {
+			*product = p
+			return nil
+		}
+	}
+	for _, p := range m.products {
+		if slices.ContainsFunc(p.Variants, func(v models.Variant) bool { return v.SKU == sku }) {
+			*product = p
+			return nil
+		}
+	}
+	return gorm.ErrRecordNotFound
+}
+
+func (m *mockProductsRepository) GetProducts(ctx context.Context, q models.ProductQuery) ([]models.Product, int64, error) {
+	if m.err != nil {
+		return nil, 0, m.err
+	}
+	products := m.filter(q)
+	total := int64(len(products))
+	if q.Offset >= len(products) {
+		return []models.Product{}, total, nil
+	}
+	return products[q.Offset:min(q.Offset+q.Limit, len(products))], total, nil
+}
+
+func (m *mockProductsRepository) CountProducts(ctx context.Context, q models.ProductQuery) (int64, error) {
+	if m.err != nil {
+		return 0, m.err
+	}
+	return int64(len(m.filter(q))), nil
+}
+
+func (m *mockProductsRepository) GetFeaturedProducts(ctx context.Context) ([]models.Product, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	featured := true
+	products := m.filter(models.ProductQuery{Featured: &featured})
+	slices.SortStableFunc(products, func(a, b models.Product) int {
+		return cmp.Compare(a.SortOrder, b.SortOrder)
+	})
+	return products, nil
+}
+
+func (m *mockProductsRepository) SetProductFeatured(ctx context.Context, code string, featured bool) error {
+	if m.err != nil {
+		return m.err
+	}
+	for i := range m.products {
+		if m.products[i].Code == code {
+			m.products[i].Featured = featured
+			return nil
+		}
+	}
+	return gorm.ErrRecordNotFound
+}
+
+func (m *mockProductsRepository) SetProductCategory(ctx context.Context, code string, categoryID uint) error {
+	if m.err != nil {
+		return m.err
+	}
+	for i := range m.products {
+		if m.products[i].Code == code {
+			m.products[i].CategoryID, m.products[i].Category = &categoryID, models.Category{ID: categoryID}
+			return nil
+		}
+	}
+	return gorm.ErrRecordNotFound
+}
+
+func (m *mockProductsRepository) LockCategoryProducts(ctx context.Context, categoryID uint) ([]models.Product, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	products := []models.Product{}
+	for _, p := range m.products {
+		if p.CategoryID != nil && *p.CategoryID == categoryID {
+			products = append(products, p)
+		}
+	}
+	return products, nil
+}
+
+func (m *mockProductsRepository) GetSimilarProducts(ctx context.Context, code string, limit int) ([]models.Product, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+
+	var product models.Product
+	if err := m.GetProductByCode(ctx, code, &product); err != nil {
+		return nil, err
+	}
+
+	products := []models.Product{}
+	for _, p := range m.products {
+		if p.Category.Code == product.Category.Code && p.Code != code && len(products) < limit {
+			products = append(products, p)
+		}
+	}
+	return products, nil
+}
+
+// GetRandomProducts is deterministic in tests: it returns the first count matches.
+func (m *mockProductsRepository) GetRandomProducts(ctx context.Context, count int, category string) ([]models.Product, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	q := models.ProductQuery{}
+	if category != "" {
+		q.Categories = []string{category}
+	}
+	products := m.filter(q)
+	return products[:min(count, len(products))], nil
+}
+
+func (m *mockProductsRepository) GetPriceTotals(ctx context.Context) ([]models.PriceTotal, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	totals := map[string]*models.PriceTotal{}
+	for _, p := range m.products {
+		if totals[p.Currency] == nil {
+			totals[p.Currency] = &models.PriceTotal{Currency: p.Currency}
+		}
+		totals[p.Currency].Count++
+		totals[p.Currency].Sum = totals[p.Currency].Sum.Add(p.Price)
+	}
+
+	res := []models.PriceTotal{}
+	for _, t := range totals {
+		res = append(res, *t)
+	}
+	return res, nil
+}
+
+func (m *mockProductsRepository) CreateProduct(ctx context.Context, product *models.Product) error {
+	if m.err != nil {
+		return m.err
+	}
+	for _, p := range m.products {
+		if p.Code == product.Code || (product.SKU != "" && p.SKU == product.SKU) {
+			return gorm.ErrDuplicatedKey
+		}
+	}
+	product.ID = uint(len(m.products) + 1)
+	if product.Version == 0 {
+		// The column default.
+		product.Version = 1
+	}
+	m.products = append(m.products, *product)
+	return nil
+}
+
+func (m *mockProductsRepository) CreateVariant(ctx context.Context, variant *models.Variant) error {
+	if m.err != nil {
+		return m.err
+	}
+	for _, p := range m.products {
+		for _, v := range p.Variants {
+			if v.SKU == variant.SKU {
+				return gorm.ErrDuplicatedKey
+			}
+		}
+	}
+	for i := range m.products {
+		if m.products[i].ID == variant.ProductID {
+			m.products[i].Variants = append(m.products[i].Variants, *variant)
+			return nil
+		}
+	}
+	return gorm.ErrRecordNotFound
+}
+
+func (m *mockProductsRepository) CountVariants(ctx context.Context, productID uint) (int64, error) {
+	if m.err != nil {
+		return 0, m.err
+	}
+	for _, p := range m.products {
+		if p.ID == productID {
+			return int64(len(p.Variants)), nil
+		}
+	}
+	return 0, nil
+}
+
+func (m *mockProductsRepository) GetVariantStock(ctx context.Context, code, sku string) (int, error) {
+	if m.err != nil {
+		return 0, m.err
+	}
+	for _, p := range m.products {
+		if p.Code != code {
+			continue
+		}
+		for _, v := range p.Variants {
+			if v.SKU == sku {
+				return v.Stock, nil
+			}
+		}
+	}
+	return 0, gorm.ErrRecordNotFound
+}
+
+func (m *mockProductsRepository) UpdateVariant(ctx context.Context, variant *models.Variant) error {
+	if m.err != nil {
+		return m.err
+	}
+	for i := range m.products {
+		for j := range m.products[i].Variants {
+			if m.products[i].Variants[j].SKU == variant.SKU {
+				m.products[i].Variants[j] = *variant
+				return nil
+			}
+		}
+	}
+	return gorm.ErrRecordNotFound
+}
+
+func (m *mockProductsRepository) UpdateProduct(ctx context.Context, code string, version int, updates map[string]any) error {
+	if m.err != nil {
+		return m.err
+	}
+	for i := range m.products {
+		p := &m.products[i]
+		if p.Code != code {
+			continue
+		}
+		if p.Version != version {
+			return models.ErrVersionConflict
+		}
+		p.Version++
+		for column, value := range updates {
+			switch column {
+			case "name":
+				p.Name = value.(string)
+			case "sku":
+				p.SKU, _ = value.(string)
+			case "price":
+				p.Price = value.(decimal.Decimal)
+			case "currency":
+				p.Currency = value.(string)
+			case "featured":
+				p.Featured = value.(bool)
+			case "images":
+				p.Images = value.(pq.StringArray)
+			case "category_id":
+				p.CategoryID, p.Category = nil, models.Category{}
+				if id, ok := value.(uint); ok {
+					p.CategoryID = &id
+				}
+			default:
+				return fmt.Errorf("unexpected column %s", column)
+			}
+		}
+		return nil
+	}
+	return gorm.ErrRecordNotFound
+}
+
+func (m *mockProductsRepository) CreatePriceChangeEvent(ctx context.Context, event *models.PriceChangeEvent) error {
+	if m.err != nil {
+		return m.err
+	}
+	event.ID = uint(len(m.priceChanges) + 1)
+	m.priceChanges = append(m.priceChanges, *event)
+	return nil
+}
+
+func (m *mockProductsRepository) GetPriceHistory(ctx context.Context, code string) ([]models.PriceChangeEvent, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	events := []models.PriceChangeEvent{}
+	for _, e := range slices.Backward(m.priceChanges) {
+		if e.ProductCode == code {
+			events = append(events, e)
+		}
+	}
+	return events, nil
+}
+
+func (m *mockProductsRepository) filter(q models.ProductQuery) []models.Product {
+	var products []models.Product
+	for _, p := range m.products {
+		if len(q.Categories) > 0 && !slices.ContainsFunc(q.Categories, func(name string) bool { return strings.EqualFold(name, p.Category.Name) }) {
+			continue
+		}
+		if q.PriceLt != nil && p.Price.InexactFloat64() >= *q.PriceLt {
+			continue
+		}
+		if q.PriceGte != nil && p.Price.InexactFloat64() < *q.PriceGte {
+			continue
+		}
+		if q.PriceEq != nil && !p.Price.Equal(*q.PriceEq) {
+			continue
+		}
+		if q.Featured != nil && p.Featured != *q.Featured {
+			continue
+		}
+		if q.InStock && !slices.ContainsFunc(p.Variants, func(v models.Variant) bool { return v.Stock > 0 }) {
+			continue
+		}
+		if q.HasVariants != nil && (len(p.Variants) > 0) != *q.HasVariants {
+			continue
+		}
+		products = append(products, p)
+	}
+	return products
+}
+
+// mockCategoriesRepository only supports the category lookups the catalog needs.
+type mockCategoriesRepository struct {
+	models.CategoriesRepositoryInterface
+	categories []models.Category
+}
+
+func (m *mockCategoriesRepository) GetCategoryByCode(ctx context.Context, code string, category *models.Category) error {
+	for _, c := range m.categories {
+		if c.Code == code {
+			*category = c
+			return nil
+		}
+	}
+	return gorm.ErrRecordNotFound
+}
+
+// mockTransactor snapshots the products and price changes before fn runs and
+// restores them when fn fails, like a rolled back transaction would.
+type mockTransactor struct {
+	products   *mockProductsRepository
+	categories *mockCategoriesRepository
+}
+
+func (m *mockTransactor) WithTransaction(ctx context.Context, fn func(models.TxRepositories) error) error {
+	snapshot, priceChanges := slices.Clone(m.products.products), slices.Clone(m.products.priceChanges)
+	if err := fn(models.TxRepositories{Products: m.products, Categories: m.categories}); err != nil {
+		m.products.products, m.products.priceChanges = snapshot, priceChanges
+		return err
+	}
+	return nil
+}
+
+func newTestHandler(repo *mockProductsRepository) *CatalogHandler {
+	tx := &mockTransactor{
+		products:   repo,
+		categories: &mockCategoriesRepository{categories: []models.Category{{ID: 1, Code: "CLOTHING", Name: "Clothing"}}},
+	}
+	return NewCatalogHandler(NewCatalogService(repo, testRates(), WithTransactor(tx)), DefaultConfig())
+}
+
+func testRates() ExchangeRates {
+	return ExchangeRates{
+		"USD": decimal.NewFromInt(1),
+		"EUR": decimal.RequireFromString("0.5"),
+	}
+}
+
+func testProducts() []models.Product {
+	return []models.Product{
+		{
+			ID:       1,
+			Code:     "PROD001",
+			SKU:      "SKU001",
+			Currency: "USD",
+			Version:  1,
+			Price:    decimal.RequireFromString("10.99"),
+			Category: models.Category{Code: "CLOTHING", Name: "Clothing"},
+			Variants: []models.Variant{
+				{Name: "Variant A", SKU: "SKU001A", Price: decimal.RequireFromString("11.99"), Stock: 5},
+				{Name: "Variant B", SKU: "SKU001B"},
+			},
+		},
+		{
+			ID:        2,
+			Code:      "PROD002",
+			SKU:       "SKU002",
+			Currency:  "USD",
+			Version:   1,
+			Price:     decimal.RequireFromString("12.49"),
+			Category:  models.Category{Code: "SHOES", Name: "Shoes"},
+			Featured:  true,
+			SortOrder: 2,
+		},
+		{
+			ID:        3,
+			Code:      "PROD003",
+			SKU:       "SKU003",
+			Currency:  "USD",
+			Version:   1,
+			Price:     decimal.RequireFromString("8.75"),
+			Category:  models.Category{Code: "ACCESSORIES", Name: "Accessories"},
+			Featured:  true,
+			SortOrder: 1,
+		},
+	}
+}
+
+func TestGetCatalog(t *testing.T) {
+	t.Run("returns all products", func(t *testing.T) {
+		h := newTestHandler(&mockProductsRepository{products: testProducts()})
+
+		recorder := httptest.NewRecorder()
+		h.GetCatalog(recorder, httptest.NewRequest(http.MethodGet, "/catalog", nil))
+
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		assert.JSONEq(t, `{"total":3,"products":[
+			{"code":"PROD001","sku":"SKU001","price":10.99,"currency":"USD","category":"Clothing"},
+			{"code":"PROD002","sku":"SKU002","price":12.49,"currency":"USD","category":"Shoes"},
+			{"code":"PROD003","sku":"SKU003","price":8.75,"currency":"USD","category":"Accessories"}
+		]}`, recorder.Body.String())
+	})
+
+	t.Run("paginates with offset and limit", func(t *testing.T) {
+		h := newTestHandler(&mockProductsRepository{products: testProducts()})
+
+		recorder := httptest.NewRecorder()
+		h.GetCatalog(recorder, httptest.NewRequest(http.MethodGet, "/catalog?offset=1&limit=1", nil))
+
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		assert.JSONEq(t, `{"total":3,"products":[
+			{"code":"PROD002","sku":"SKU002","price":12.49,"currency":"USD","category":"Shoes"}
+		]}`, recorder.Body.String())
+	})
+
+	t.Run("filters by category and price", func(t *testing.T) {
+		h := newTestHandler(&mockProductsRepository{products: testProducts()})
+
+		recorder := httptest.NewRecorder()
+		h.GetCatalog(recorder, httptest.NewRequest(http.MethodGet, "/catalog?category=Clothing&price_lt=11", nil))
+
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		assert.JSONEq(t, `{"total":1,"products":[
+			{"code":"PROD001","sku":"SKU001","price":10.99,"currency":"USD","category":"Clothing"}
+		]}`, recorder.Body.String())
+	})
+
+	t.Run("filters by category whatever its case", func(t *testing.T) {
+		h := newTestHandler(&mockProductsRepository{products: testProducts()})
+
+		for _, category := range []string{"clothing", "CLOTHING", "Clothing"} {
+			recorder := httptest.NewRecorder()
+			h.GetCatalog(recorder, httptest.NewRequest(http.MethodGet, "/catalog?category="+category, nil))
+
+			assert.Equal(t, http.StatusOK, recorder.Code, category)
+			assert.JSONEq(t, `{"total":1,"products":[
+				{"code":"PROD001","sku":"SKU001","price":10.99,"currency":"USD","category":"Clothing"}
+			]}`, recorder.Body.String(), category)
+		}
+	})
+
+	t.Run("filters featured products", func(t *testing.T) {
+		h := newTestHandler(&mockProductsRepository{products: testProducts()})
+
+		recorder := httptest.NewRecorder()
+		h.GetCatalog(recorder, httptest.NewRequest(http.MethodGet, "/catalog?featured=true", nil))
+
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		assert.JSONEq(t, `{"total":2,"products":[
+			{"code":"PROD002","sku":"SKU002","price":12.49,"currency":"USD","category":"Shoes"},
+			{"code":"PROD003","sku":"SKU003","price":8.75,"currency":"USD","category":"Accessories"}
+		]}`, recorder.Body.String())
+	})
+
+	t.Run("converts prices to the requested currency", func(t *testing.T) {
+		h := newTestHandler(&mockProductsRepository{products: testProducts()})
+
+		recorder := httptest.NewRecorder()
+		h.GetCatalog(recorder, httptest.NewRequest(http.MethodGet, "/catalog?limit=1&currency=eur", nil))
+
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		assert.JSONEq(t, `{"total":3,"products":[
+			{"code":"PROD001","sku":"SKU001","price":5.5,"currency":"EUR","category":"Clothing"}
+		]}`, recorder.Body.String())
+	})
+
+	t.Run("filters products in stock", func(t *testing.T) {
+		h := newTestHandler(&mockProductsRepository{products: testProducts()})
+
+		recorder := httptest.NewRecorder()
+		h.GetCatalog(recorder, httptest.NewRequest(http.MethodGet, "/catalog?in_stock=true", nil))
+
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		assert.JSONEq(t, `{"total":1,"products":[
+			{"code":"PROD001","sku":"SKU001","price":10.99,"currency":"USD","category":"Clothing"}
+		]}`, recorder.Body.String())
+
+		recorder = httptest.NewRecorder()
+		h.GetCatalog(recorder, httptest.NewRequest(http.MethodGet, "/catalog?in_stock=false", nil))
+
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		assert.Contains(t, recorder.Body.String(), `"total":3`)
+	})
+
+	t.Run("filters on an exact price", func(t *testing.T) {
+		h := newTestHandler(&mockProductsRepository{products: testProducts()})
+
+		recorder := httptest.NewRecorder()
+		h.GetCatalog(recorder, httptest.NewRequest(http.MethodGet, "/catalog?price_eq=12.49", nil))
+
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		assert.JSONEq(t, `{"total":1,"products":[
+			{"code":"PROD002","sku":"SKU002","price":12.49,"currency":"USD","category":"Shoes"}
+		]}`, recorder.Body.String())
+	})
+
+	t.Run("filters on a price range", func(t *testing.T) {
+		h := newTestHandler(&mockProductsRepository{products: testProducts()})
+
+		recorder := httptest.NewRecorder()
+		h.GetCatalog(recorder, httptest.NewRequest(http.MethodGet, "/catalog?price_gte=10&price_lt=12.49", nil))
+
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		assert.JSONEq(t, `{"total":1,"products":[
+			{"code":"PROD001","sku":"SKU001","price":10.99,"currency":"USD","category":"Clothing"}
+		]}`, recorder.Body.String())
+	})
+
+	t.Run("filters products without variants", func(t *testing.T) {
+		h := newTestHandler(&mockProductsRepository{products: testProducts()})
+
+		recorder := httptest.NewRecorder()
+		h.GetCatalog(recorder, httptest.NewRequest(http.MethodGet, "/catalog?has_variants=false", nil))
+
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		assert.JSONEq(t, `{"total":2,"products":[
+			{"code":"PROD002","sku":"SKU002","price":12.49,"currency":"USD","category":"Shoes"},
+			{"code":"PROD003","sku":"SKU003","price":8.75,"currency":"USD","category":"Accessories"}
+		]}`, recorder.Body.String())
+	})
+
+	t.Run("returns only the requested fields", func(t *testing.T) {
+		h := newTestHandler(&mockProductsRepository{products: testProducts()})
+
+		recorder := httptest.NewRecorder()
+		h.GetCatalog(recorder, httptest.NewRequest(http.MethodGet, "/catalog?limit=2&fields=code,price", nil))
+
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		assert.JSONEq(t, `{"total":3,"products":[
+			{"code":"PROD001","price":10.99},
+			{"code":"PROD002","price":12.49}
+		]}`, recorder.Body.String())
+	})
+
+	t.Run("limit above the max page size is capped", func(t *testing.T) {
+		h := newTestHandler(&mockProductsRepository{products: testProducts()})
+		h.config = Config{DefaultPageSize: 1, MaxPageSize: 2}
+
+		recorder := httptest.NewRecorder()
+		h.GetCatalog(recorder, httptest.NewRequest(http.MethodGet, "/catalog?limit=200&fields=code", nil))
+
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		assert.JSONEq(t, `{"total":3,"products":[{"code":"PROD001"},{"code":"PROD002"}]}`, recorder.Body.String())
+	})
+
+	t.Run("without envelope", func(t *testing.T) {
+		h := newTestHandler(&mockProductsRepository{products: testProducts()})
+
+		recorder := httptest.NewRecorder()
+		h.GetCatalog(recorder, httptest.NewRequest(http.MethodGet, "/catalog?limit=2&envelope=false", nil))
+
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		assert.Equal(t, "3", recorder.Header().Get("X-Total-Count"))
+		assert.JSONEq(t, `[
+			{"code":"PROD001","sku":"SKU001","price":10.99,"currency":"USD","category":"Clothing"},
+			{"code":"PROD002","sku":"SKU002","price":12.49,"currency":"USD","category":"Shoes"}
+		]`, recorder.Body.String())
+
+		recorder = httptest.NewRecorder()
+		h.GetCatalog(recorder, httptest.NewRequest(http.MethodGet, "/catalog?limit=2&fields=code&envelope=false", nil))
+
+		assert.JSONEq(t, `[{"code":"PROD001"},{"code":"PROD002"}]`, recorder.Body.String())
+
+		recorder = httptest.NewRecorder()
+		h.GetCatalog(recorder, httptest.NewRequest(http.MethodGet, "/catalog?category=Hats&envelope=false", nil))
+
+		assert.Equal(t, "0", recorder.Header().Get("X-Total-Count"))
+		assert.JSONEq(t, `[]`, recorder.Body.String())
+
+		recorder = httptest.NewRecorder()
+		h.GetCatalog(recorder, httptest.NewRequest(http.MethodGet, "/catalog?limit=1&envelope=true", nil))
+
+		assert.Empty(t, recorder.Header().Get("X-Total-Count"))
+		assert.Contains(t, recorder.Body.String(), `"total":3`)
+	})
+
+	t.Run("xml", func(t *testing.T) {
+		h := newTestHandler(&mockProductsRepository{products: testProducts()})
+
+		recorder := httptest.NewRecorder()
+		h.GetCatalog(api.WithContentType(recorder, api.ContentTypeXML), httptest.NewRequest(http.MethodGet, "/catalog?limit=1", nil))
+
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		assert.Equal(t, xml.Header+`<response><products><product><code>PROD001</code><sku>SKU001</sku><price>10.99</price><currency>USD</currency><category>Clothing</category></product></products><total>3</total></response>`, recorder.Body.String())
+
+		recorder = httptest.NewRecorder()
+		h.GetCatalog(api.WithContentType(recorder, api.ContentTypeXML), httptest.NewRequest(http.MethodGet, "/catalog?limit=1&fields=price,code", nil))
+
+		assert.Equal(t, xml.Header+`<response><products><product><code>PROD001</code><price>10.99</price></product></products><total>3</total></response>`, recorder.Body.String())
+
+		recorder = httptest.NewRecorder()
+		h.GetCatalog(api.WithContentType(recorder, api.ContentTypeXML), httptest.NewRequest(http.MethodGet, "/catalog?limit=1&envelope=false", nil))
+
+		assert.Equal(t, xml.Header+`<products><product><code>PROD001</code><sku>SKU001</sku><price>10.99</price><currency>USD</currency><category>Clothing</category></product></products>`, recorder.Body.String())
+	})
+
+	t.Run("invalid query params", func(t *testing.T) {
+		h := newTestHandler(&mockProductsRepository{products: testProducts()})
+
+		for _, query := range []string{"offset=-1", "offset=abc", "page=0", "limit=abc", "price_lt=abc", "sort=category", "sort=price,category", "sort=price,", "featured=maybe", "currency=XXX", "fields=code,title", "in_stock=yes", "has_variants=none", "price_gte=abc", "price_gte=20&price_lt=10", "price_eq=abc", "price_eq=10&price_lt=20", "envelope=no"} {
+			recorder := httptest.NewRecorder()
+			h.GetCatalog(recorder, httptest.NewRequest(http.MethodGet, "/catalog?"+query, nil))
+
+			assert.Equal(t, http.StatusBadRequest, recorder.Code, query)
+		}
+	})
+
+	t.Run("price filters", func(t *testing.T) {
+		h := newTestHandler(&mockProductsRepository{products: testProducts()})
+
+		tests := []struct {
+			query    string
+			status   int
+			response string
+		}{
+			{query: "price_lt=abc", status: http.StatusBadRequest, response: `{"error":"price_lt must be a number"}`},
+			{query: "price_gte=abc", status: http.StatusBadRequest, response: `{"error":"price_gte must be a number"}`},
+			{query: "price_lt=NaN", status: http.StatusBadRequest, response: `{"error":"price_lt must be a number"}`},
+			{query: "price_lt=-5", status: http.StatusBadRequest, response: `{"error":"price_lt must not be negative"}`},
+			{query: "price_gte=-0.01", status: http.StatusBadRequest, response: `{"error":"price_gte must not be negative"}`},
+			{query: "price_lt=abc&price_gte=-1", status: http.StatusBadRequest, response: `{"error":"price_lt must be a number; price_gte must not be negative"}`},
+		}
+
+		for _, tt := range tests {
+			recorder := httptest.NewRecorder()
+			h.GetCatalog(recorder, httptest.NewRequest(http.MethodGet, "/catalog?"+tt.query, nil))
+
+			assert.Equal(t, tt.status, recorder.Code, tt.query)
+			assert.JSONEq(t, tt.response, recorder.Body.String(), tt.query)
+		}
+
+		// Empty values don't filter, rather than filtering on zero.
+		for _, query := range []string{"price_lt=", "price_gte=", "price_lt=&price_gte="} {
+			recorder := httptest.NewRecorder()
+			h.GetCatalog(recorder, httptest.NewRequest(http.MethodGet, "/catalog?"+query, nil))
+
+			assert.Equal(t, http.StatusOK, recorder.Code, query)
+			var res Response
+			assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &res), query)
+			assert.Equal(t, int64(len(testProducts())), res.Total, query)
+		}
+	})
+
+	t.Run("repository error", func(t *testing.T) {
+		h := newTestHandler(&mockProductsRepository{err: errors.New("boom")})
+
+		recorder := httptest.NewRecorder()
+		h.GetCatalog(recorder, httptest.NewRequest(http.MethodGet, "/catalog", nil))
+
+		assert.Equal(t, http.StatusInternalServerError, recorder.Code)
+	})
+
+	t.Run("request timeout", func(t *testing.T) {
+		h := newTestHandler(&mockProductsRepository{err: fmt.Errorf("querying products: %w", context.DeadlineExceeded)})
+
+		recorder := httptest.NewRecorder()
+		h.GetCatalog(recorder, httptest.NewRequest(http.MethodGet, "/catalog", nil))
+
+		assert.Equal(t, http.StatusGatewayTimeout, recorder.Code)
+		assert.JSONEq(t, `{"error":"request timed out","code":"REQUEST_TIMEOUT"}`, recorder.Body.String())
+	})
+}
+
+func TestGetCatalogLinkHeader(t *testing.T) {
+	t.Run("links the pages", func(t *testing.T) {
+		h := newTestHandler(&mockProductsRepository{products: testProducts()})
+
+		recorder := httptest.NewRecorder()
+		h.GetCatalog(recorder, httptest.NewRequest(http.MethodGet, "/catalog?page=2&limit=1&currency=EUR", nil))
+
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		assert.Equal(t, `</catalog?currency=EUR&limit=1&offset=0>; rel="first", `+
+			`</catalog?currency=EUR&limit=1&offset=0>; rel="prev", `+
+			`</catalog?currency=EUR&limit=1&offset=2>; rel="next", `+
+			`</catalog?currency=EUR&limit=1&offset=2>; rel="last"`, recorder.Header().Get("Link"))
+	})
+
+	t.Run("not on errors", func(t *testing.T) {
+		h := newTestHandler(&mockProductsRepository{err: errors.New("boom")})
+
+		recorder := httptest.NewRecorder()
+		h.GetCatalog(recorder, httptest.NewRequest(http.MethodGet, "/catalog", nil))
+
+		assert.Equal(t, http.StatusInternalServerError, recorder.Code)
+		assert.Empty(t, recorder.Header().Get("Link"))
+	})
+}
+
+func TestGetCatalogCacheHeaders(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.CacheSeconds = 60
+
+	tests := []struct {
+		name         string
+		repo         *mockProductsRepository
+		query        string
+		status       int
+		cacheControl string
+		vary         string
+	}{
+		{name: "success", repo: &mockProductsRepository{products: testProducts()}, status: http.StatusOK, cacheControl: "public, max-age=60", vary: "Accept-Encoding"},
+		{name: "invalid query", repo: &mockProductsRepository{products: testProducts()}, query: "?limit=abc", status: http.StatusBadRequest},
+		{name: "repository error", repo: &mockProductsRepository{err: errors.New("boom")}, status: http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := NewCatalogHandler(NewCatalogService(tt.repo, testRates()), cfg)
+
+			recorder := httptest.NewRecorder()
+			h.GetCatalog(recorder, httptest.NewRequest(http.MethodGet, "/catalog"+tt.query, nil))
+
+			assert.Equal(t, tt.status, recorder.Code)
+			assert.Equal(t, tt.cacheControl, recorder.Header().Get("Cache-Control"))
+			assert.Equal(t, tt.vary, recorder.Header().Get("Vary"))
+		})
+	}
+
+	t.Run("disabled by default", func(t *testing.T) {
+		h := newTestHandler(&mockProductsRepository{products: testProducts()})
+
+		recorder := httptest.NewRecorder()
+		h.GetCatalog(recorder, httptest.NewRequest(http.MethodGet, "/catalog", nil))
+
+		assert.Empty(t, recorder.Header().Get("Cache-Control"))
+	})
+}
+
+func TestGetProductBySKU(t *testing.T) {
+	t.Run("returns product details with inherited variant prices", func(t *testing.T) {
+		h := newTestHandler(&mockProductsRepository{products: testProducts()})
+
+		req := httptest.NewRequest(http.MethodGet, "/catalog/by-sku/SKU001", nil)
+		req.SetPathValue("sku", "SKU001")
+		recorder := httptest.NewRecorder()
+		h.GetProductBySKU(recorder, req)
+
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		assert.JSONEq(t, `{"code":"PROD001","sku":"SKU001","price":10.99,"currency":"USD","category":"Clothing","category_code":"CLOTHING","category_name":"Clothing","featured":false,"version":1,"variants":[
+			{"name":"Variant A","sku":"SKU001A","price":11.99,"sale_price":null,"discount_percent":null},
+			{"name":"Variant B","sku":"SKU001B","price":10.99,"sale_price":null,"discount_percent":null}
+		]}`, recorder.Body.String())
+	})
+
+	t.Run("converts variant prices to the requested currency", func(t *testing.T) {
+		h := newTestHandler(&mockProductsRepository{products: testProducts()})
+
+		req := httptest.NewRequest(http.MethodGet, "/catalog/by-sku/SKU001?currency=EUR", nil)
+		req.SetPathValue("sku", "SKU001")
+		recorder := httptest.NewRecorder()
+		h.GetProductBySKU(recorder, req)
+
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		assert.JSONEq(t, `{"code":"PROD001","sku":"SKU001","price":5.5,"currency":"EUR","category":"Clothing","category_code":"CLOTHING","category_name":"Clothing","featured":false,"version":1,"variants":[
+			{"name":"Variant A","sku":"SKU001A","price":6,"sale_price":null,"discount_percent":null},
+			{"name":"Variant B","sku":"SKU001B","price":5.5,"sale_price":null,"discount_percent":null}
+		]}`, recorder.Body.String())
+	})
+
+	t.Run("variant sku returns the owning product", func(t *testing.T) {
+		h := newTestHandler(&mockProductsRepository{products: testProducts()})
+
+		req := httptest.NewRequest(http.MethodGet, "/catalog/by-sku/SKU001B", nil)
+		req.SetPathValue("sku", "SKU001B")
+		recorder := httptest.NewRecorder()
+		h.GetProductBySKU(recorder, req)
+
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		assert.Contains(t, recorder.Body.String(), `"code":"PROD001"`)
+	})
+
+	t.Run("unknown sku", func(t *testing.T) {
+		h := newTestHandler(&mockProductsRepository{products: testProducts()})
+
+		req := httptest.NewRequest(http.MethodGet, "/catalog/by-sku/NOPE", nil)
+		req.SetPathValue("sku", "NOPE")
+		recorder := httptest.NewRecorder()
+		h.GetProductBySKU(recorder, req)
+
+		assert.Equal(t, http.StatusNotFound, recorder.Code)
+	})
+
+	t.Run("repository error", func(t *testing.T) {
+		h := newTestHandler(&mockProductsRepository{err: errors.New("boom")})
+
+		req := httptest.NewRequest(http.MethodGet, "/catalog/by-sku/SKU001", nil)
+		req.SetPathValue("sku", "SKU001")
+		recorder := httptest.NewRecorder()
+		h.GetProductBySKU(recorder, req)
+
+		assert.Equal(t, http.StatusInternalServerError, recorder.Code)
+	})
+}
+
+func TestGetFeatured(t *testing.T) {
+	t.Run("returns featured products by sort order", func(t *testing.T) {
+		h := newTestHandler(&mockProductsRepository{products: testProducts()})
+
+		recorder := httptest.NewRecorder()
+		h.GetFeatured(recorder, httptest.NewRequest(http.MethodGet, "/catalog/featured", nil))
+
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		assert.JSONEq(t, `{"total":2,"products":[
+			{"code":"PROD003","sku":"SKU003","price":8.75,"currency":"USD","category":"Accessories"},
+			{"code":"PROD002","sku":"SKU002","price":12.49,"currency":"USD","category":"Shoes"}
+		]}`, recorder.Body.String())
+	})
+}
+
+func TestSetFeatured(t *testing.T) {
+	tests := []struct {
+		name     string
+		code     string
+		body     string
+		expected int
+		response string
+	}{
+		{"features a product", "PROD001", `{"featured":true}`, http.StatusOK, `{"code":"PROD001","featured":true}`},
+		{"unfeatures a product", "PROD002", `{"featured":false}`, http.StatusOK, `{"code":"PROD002","featured":false}`},
+		{"unknown product", "NOPE", `{"featured":true}`, http.StatusNotFound, ""},
+		{"missing flag", "PROD001", `{}`, http.StatusBadRequest, ""},
+		{"malformed body", "PROD001", `{`, http.StatusBadRequest, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &mockProductsRepository{products: testProducts()}
+			h := newTestHandler(repo)
+
+			req := httptest.NewRequest(http.MethodPatch, "/catalog/"+tt.code+"/featured", strings.NewReader(tt.body))
+			req.SetPathValue("code", tt.code)
+			recorder := httptest.NewRecorder()
+			h.SetFeatured(recorder, req)
+
+			assert.Equal(t, tt.expected, recorder.Code)
+			if tt.response != "" {
+				assert.JSONEq(t, tt.response, recorder.Body.String())
+			}
+		})
+	}
+}
+
+func TestGetProduct(t *testing.T) {
+	t.Run("returns product details", func(t *testing.T) {
+		h := newTestHandler(&mockProductsRepository{products: testProducts()})
+
+		req := httptest.NewRequest(http.MethodGet, "/catalog/PROD002", nil)
+		req.SetPathValue("code", "PROD002")
+		recorder := httptest.NewRecorder()
+		h.GetProduct(recorder, req)
+
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		assert.JSONEq(t, `{"code":"PROD002","sku":"SKU002","price":12.49,"currency":"USD","category":"Shoes","category_code":"SHOES","category_name":"Shoes","featured":true,"version":1,"variants":[]}`, recorder.Body.String())
+	})
+
+	t.Run("unknown product", func(t *testing.T) {
+		h := newTestHandler(&mockProductsRepository{products: testProducts()})
+
+		req := httptest.NewRequest(http.MethodGet, "/catalog/NOPE", nil)
+		req.SetPathValue("code", "NOPE")
+		recorder := httptest.NewRecorder()
+		h.GetProduct(recorder, req)
+
+		assert.Equal(t, http.StatusNotFound, recorder.Code)
+	})
+}
+
+func TestHead(t *testing.T) {
+	h := newTestHandler(&mockProductsRepository{products: testProducts()})
+
+	// GET patterns also match HEAD, and the server drops the body of HEAD
+	// responses, so the catalog endpoints need no HEAD-specific routes.
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /catalog", h.GetCatalog)
+	mux.HandleFunc("GET /catalog/{code}", h.GetProduct)
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	tests := []struct {
+		path   string
+		status int
+	}{
+		{path: "/catalog", status: http.StatusOK},
+		{path: "/catalog/PROD001", status: http.StatusOK},
+		{path: "/catalog/NOPE", status: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			res, err := http.Head(srv.URL + tt.path)
+			if !assert.NoError(t, err) {
+				return
+			}
+			defer res.Body.Close()
+			body, _ := io.ReadAll(res.Body)
+
+			assert.Equal(t, tt.status, res.StatusCode)
+			assert.Equal(t, "application/json", res.Header.Get("Content-Type"))
+			assert.Empty(t, body)
+		})
+	}
+}
+
+func TestGetSimilar(t *testing.T) {
+	similarProducts := func() []models.Product {
+		clothing := models.Category{Code: "CLOTHING", Name: "Clothing"}
+		return append(testProducts(),
+			models.Product{Code: "PROD004", Price: decimal.RequireFromString("15"), Currency: "USD", Category: clothing},
+			models.Product{Code: "PROD007", Price: decimal.RequireFromString("18.2"), Currency: "USD", Category: clothing},
+		)
+	}
+
+	t.Run("never includes the queried product", func(t *testing.T) {
+		h := newTestHandler(&mockProductsRepository{products: similarProducts()})
+
+		req := httptest.NewRequest(http.MethodGet, "/catalog/PROD001/similar", nil)
+		req.SetPathValue("code", "PROD001")
+		recorder := httptest.NewRecorder()
+		h.GetSimilar(recorder, req)
+
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		assert.JSONEq(t, `{"total":2,"products":[
+			{"code":"PROD004","sku":"","price":15,"currency":"USD","category":"Clothing"},
+			{"code":"PROD007","sku":"","price":18.2,"currency":"USD","category":"Clothing"}
+		]}`, recorder.Body.String())
+		assert.NotContains(t, recorder.Body.String(), "PROD001")
+	})
+
+	t.Run("respects the limit", func(t *testing.T) {
+		h := newTestHandler(&mockProductsRepository{products: similarProducts()})
+
+		req := httptest.NewRequest(http.MethodGet, "/catalog/PROD004/similar?limit=1", nil)
+		req.SetPathValue("code", "PROD004")
+		recorder := httptest.NewRecorder()
+		h.GetSimilar(recorder, req)
+
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		assert.JSONEq(t, `{"total":1,"products":[
+			{"code":"PROD001","sku":"SKU001","price":10.99,"currency":"USD","category":"Clothing"}
+		]}`, recorder.Body.String())
+	})
+
+	t.Run("converts prices to the requested currency", func(t *testing.T) {
+		h := newTestHandler(&mockProductsRepository{products: similarProducts()})
+
+		req := httptest.NewRequest(http.MethodGet, "/catalog/PROD004/similar?limit=1&currency=eur", nil)
+		req.SetPathValue("code", "PROD004")
+		recorder := httptest.NewRecorder()
+		h.GetSimilar(recorder, req)
+
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		assert.JSONEq(t, `{"total":1,"products":[
+			{"code":"PROD001","sku":"SKU001","price":5.5,"currency":"EUR","category":"Clothing"}
+		]}`, recorder.Body.String())
+	})
+
+	t.Run("unsupported currency", func(t *testing.T) {
+		h := newTestHandler(&mockProductsRepository{products: similarProducts()})
+
+		req := httptest.NewRequest(http.MethodGet, "/catalog/PROD004/similar?currency=XXX", nil)
+		req.SetPathValue("code", "PROD004")
+		recorder := httptest.NewRecorder()
+		h.GetSimilar(recorder, req)
+
+		assert.Equal(t, http.StatusBadRequest, recorder.Code)
+		assert.JSONEq(t, `{"error":"unsupported currency XXX"}`, recorder.Body.String())
+	})
+
+	t.Run("empty when alone in its category", func(t *testing.T) {
+		h := newTestHandler(&mockProductsRepository{products: similarProducts()})
+
+		req := httptest.NewRequest(http.MethodGet, "/catalog/PROD002/similar", nil)
+		req.SetPathValue("code", "PROD002")
+		recorder := httptest.NewRecorder()
+		h.GetSimilar(recorder, req)
+
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		assert.JSONEq(t, `{"total":0,"products":[]}`, recorder.Body.String())
+	})
+
+	t.Run("unknown product", func(t *testing.T) {
+		h := newTestHandler(&mockProductsRepository{products: similarProducts()})
+
+		req := httptest.NewRequest(http.MethodGet, "/catalog/NOPE/similar", nil)
+		req.SetPathValue("code", "NOPE")
+		recorder := httptest.NewRecorder()
+		h.GetSimilar(recorder, req)
+
+		assert.Equal(t, http.StatusNotFound, recorder.Code)
+	})
+}
+
+func TestGetRelated(t *testing.T) {
+	relatedProducts := func() []models.Product {
+		clothing := models.Category{Code: "CLOTHING", Name: "Clothing"}
+		return append(testProducts(),
+			models.Product{Code: "PROD004", Price: decimal.RequireFromString("15"), Currency: "USD", Category: clothing},
+			models.Product{Code: "PROD007", Price: decimal.RequireFromString("10.5"), Currency: "USD", Category: clothing},
+			models.Product{Code: "PROD008", Price: decimal.RequireFromString("10"), Currency: "USD"},
+		)
+	}
+	related := func(code, query string) *httptest.ResponseRecorder {
+		h := newTestHandler(&mockProductsRepository{products: relatedProducts()})
+		req := httptest.NewRequest(http.MethodGet, "/catalog/"+code+"/related"+query, nil)
+		req.SetPathValue("code", code)
+		recorder := httptest.NewRecorder()
+		h.GetRelated(recorder, req)
+		return recorder
+	}
+
+	t.Run("lists the category in catalog order", func(t *testing.T) {
+		recorder := related("PROD001", "")
+
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		assert.JSONEq(t, `{"total":2,"products":[
+			{"code":"PROD004","sku":"","price":15,"currency":"USD","category":"Clothing"},
+			{"code":"PROD007","sku":"","price":10.5,"currency":"USD","category":"Clothing"}
+		]}`, recorder.Body.String())
+	})
+
+	t.Run("respects the limit without counting the product", func(t *testing.T) {
+		recorder := related("PROD001", "?limit=1")
+
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		assert.JSONEq(t, `{"total":1,"products":[
+			{"code":"PROD004","sku":"","price":15,"currency":"USD","category":"Clothing"}
+		]}`, recorder.Body.String())
+	})
+
+	t.Run("empty when alone in its category", func(t *testing.T) {
+		recorder := related("PROD002", "")
+
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		assert.JSONEq(t, `{"total":0,"products":[]}`, recorder.Body.String())
+	})
+
+	t.Run("converts prices to the requested currency", func(t *testing.T) {
+		recorder := related("PROD001", "?limit=1&currency=eur")
+
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		assert.JSONEq(t, `{"total":1,"products":[
+			{"code":"PROD004","sku":"","price":7.5,"currency":"EUR","category":"Clothing"}
+		]}`, recorder.Body.String())
+	})
+
+	t.Run("unsupported currency", func(t *testing.T) {
+		assert.Equal(t, http.StatusBadRequest, related("PROD001", "?currency=XXX").Code)
+	})
+
+	t.Run("empty without a category", func(t *testing.T) {
+		recorder := related("PROD008", "")
+
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		assert.JSONEq(t, `{"total":0,"products":[]}`, recorder.Body.String())
+	})
+
+	t.Run("invalid limit", func(t *testing.T) {
+		assert.Equal(t, http.StatusBadRequest, related("PROD001", "?limit=abc").Code)
+	})
+
+	t.Run("unknown product", func(t *testing.T) {
+		assert.Equal(t, http.StatusNotFound, related("NOPE", "").Code)
+	})
+}
+
+func TestCreateVariant(t *testing.T) {
+	tests := []struct {
+		name     string
+		code     string
+		body     string
+		status   int
+		response string
+	}{
+		{
+			name:     "price override",
+			code:     "PROD001",
+			body:     `{"name":"Variant C","sku":"SKU001C","price":13.5}`,
+			status:   http.StatusOK,
+			response: `{"name":"Variant C","sku":"SKU001C","price":13.5,"sale_price":null,"discount_percent":null}`,
+		},
+		{
+			name:     "nil price inherits the product price",
+			code:     "PROD001",
+			body:     `{"name":"Variant C","sku":"SKU001C"}`,
+			status:   http.StatusOK,
+			response: `{"name":"Variant C","sku":"SKU001C","price":10.99,"sale_price":null,"discount_percent":null}`,
+		},
+		{
+			name:     "zero price inherits the product price",
+			code:     "PROD001",
+			body:     `{"name":"Variant C","sku":"SKU001C","price":0}`,
+			status:   http.StatusOK,
+			response: `{"name":"Variant C","sku":"SKU001C","price":10.99,"sale_price":null,"discount_percent":null}`,
+		},
+		{
+			name:     "image",
+			code:     "PROD001",
+			body:     `{"name":"Variant C","sku":"SKU001C","image":"https://cdn.example.com/c.png"}`,
+			status:   http.StatusOK,
+			response: `{"name":"Variant C","sku":"SKU001C","price":10.99,"sale_price":null,"discount_percent":null,"image":"https://cdn.example.com/c.png"}`,
+		},
+		{
+			name:     "relative image url",
+			code:     "PROD001",
+			body:     `{"name":"Variant C","sku":"SKU001C","image":"c.png"}`,
+			status:   http.StatusBadRequest,
+			response: `{"errors":[{"field":"image","message":"image must be an absolute http or https URL"}]}`,
+		},
+		{
+			name:     "negative price",
+			code:     "PROD001",
+			body:     `{"name":"Variant C","sku":"SKU001C","price":-1}`,
+			status:   http.StatusBadRequest,
+			response: `{"errors":[{"field":"price","message":"price must be greater than zero"}]}`,
+		},
+		{
+			name:     "invalid sku",
+			code:     "PROD001",
+			body:     `{"name":"Variant C","sku":"sku-001c"}`,
+			status:   http.StatusBadRequest,
+			response: `{"errors":[{"field":"sku","message":"sku must be 3 to 50 characters of A-Z, 0-9, _ or -"}]}`,
+		},
+		{
+			name:     "name too long",
+			code:     "PROD001",
+			body:     `{"name":"` + strings.Repeat("a", 201) + `","sku":"SKU001C"}`,
+			status:   http.StatusBadRequest,
+			response: `{"errors":[{"field":"name","message":"name exceeds maximum length of 200 characters"}]}`,
+		},
+		{
+			name:   "missing sku",
+			code:   "PROD001",
+			body:   `{"name":"Variant C"}`,
+			status: http.StatusBadRequest,
+		},
+		{
+			name:   "malformed body",
+			code:   "PROD001",
+			body:   `{`,
+			status: http.StatusBadRequest,
+		},
+		{
+			name:   "unknown product",
+			code:   "NOPE",
+			body:   `{"name":"Variant C","sku":"SKU001C"}`,
+			status: http.StatusNotFound,
+		},
+		{
+			name:     "duplicate sku",
+			code:     "PROD002",
+			body:     `{"name":"Variant A","sku":"SKU001A"}`,
+			status:   http.StatusConflict,
+			response: `{"error":"SKU already exists"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := newTestHandler(&mockProductsRepository{products: testProducts()})
+
+			req := httptest.NewRequest(http.MethodPost, "/catalog/"+tt.code+"/variants", strings.NewReader(tt.body))
+			req.SetPathValue("code", tt.code)
+			recorder := httptest.NewRecorder()
+			h.CreateVariant(recorder, req)
+
+			assert.Equal(t, tt.status, recorder.Code)
+			if tt.response != "" {
+				assert.JSONEq(t, tt.response, recorder.Body.String())
+			}
+		})
+	}
+}
+
+func TestCreateVariantMaxVariants(t *testing.T) {
+	repo := &mockProductsRepository{products: testProducts()}
+	tx := &mockTransactor{products: repo, categories: &mockCategoriesRepository{}}
+	h := NewCatalogHandler(NewCatalogService(repo, testRates(), WithTransactor(tx), WithMaxVariants(2)), DefaultConfig())
+
+	t.Run("adding a variant beyond the limit", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/catalog/PROD001/variants", strings.NewReader(`{"name":"Variant C","sku":"SKU001C"}`))
+		req.SetPathValue("code", "PROD001")
+		recorder := httptest.NewRecorder()
+		h.CreateVariant(recorder, req)
+
+		assert.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+		assert.JSONEq(t, `{"error":"limit exceeded: product PROD001 already has the maximum of 2 variants"}`, recorder.Body.String())
+	})
+
+	t.Run("adding a variant below the limit", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/catalog/PROD003/variants", strings.NewReader(`{"name":"Variant A","sku":"SKU003A"}`))
+		req.SetPathValue("code", "PROD003")
+		recorder := httptest.NewRecorder()
+		h.CreateVariant(recorder, req)
+
+		assert.Equal(t, http.StatusOK, recorder.Code)
+	})
+
+	t.Run("creating a product with too many variants", func(t *testing.T) {
+		body := `{"code":"PROD009","price":20,"variants":[{"name":"A","sku":"SKU009A"},{"name":"B","sku":"SKU009B"},{"name":"C","sku":"SKU009C"}]}`
+		req := httptest.NewRequest(http.MethodPost, "/catalog", strings.NewReader(body))
+		recorder := httptest.NewRecorder()
+		h.CreateProduct(recorder, req)
+
+		assert.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+		assert.JSONEq(t, `{"error":"limit exceeded: a product can have at most 2 variants"}`, recorder.Body.String())
+	})
+}
+
+func TestUpdateVariant(t *testing.T) {
+	tests := []struct {
+		name     string
+		sku      string
+		body     string
+		status   int
+		response string
+	}{
+		{
+			name:     "price override",
+			sku:      "SKU001B",
+			body:     `{"name":"Variant B","price":9.99}`,
+			status:   http.StatusOK,
+			response: `{"name":"Variant B","sku":"SKU001B","price":9.99,"sale_price":null,"discount_percent":null}`,
+		},
+		{
+			name:     "zero price inherits the product price",
+			sku:      "SKU001B",
+			body:     `{"name":"Variant B","price":0}`,
+			status:   http.StatusOK,
+			response: `{"name":"Variant B","sku":"SKU001B","price":10.99,"sale_price":null,"discount_percent":null}`,
+		},
+		{
+			name:     "clearing the price inherits the product price",
+			sku:      "SKU001A",
+			body:     `{"name":"Variant A"}`,
+			status:   http.StatusOK,
+			response: `{"name":"Variant A","sku":"SKU001A","price":10.99,"sale_price":null,"discount_percent":null}`,
+		},
+		{
+			name:     "image",
+			sku:      "SKU001A",
+			body:     `{"name":"Variant A","image":"https://cdn.example.com/a.png"}`,
+			status:   http.StatusOK,
+			response: `{"name":"Variant A","sku":"SKU001A","price":10.99,"sale_price":null,"discount_percent":null,"image":"https://cdn.example.com/a.png"}`,
+		},
+		{
+			name:   "negative price",
+			sku:    "SKU001A",
+			body:   `{"name":"Variant A","price":-0.01}`,
+			status: http.StatusBadRequest,
+		},
+		{
+			name:   "unknown variant",
+			sku:    "NOPE",
+			body:   `{"name":"Variant A"}`,
+			status: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := newTestHandler(&mockProductsRepository{products: testProducts()})
+
+			req := httptest.NewRequest(http.MethodPut, "/catalog/PROD001/variants/"+tt.sku, strings.NewReader(tt.body))
+			req.SetPathValue("code", "PROD001")
+			req.SetPathValue("sku", tt.sku)
+			recorder := httptest.NewRecorder()
+			h.UpdateVariant(recorder, req)
+
+			assert.Equal(t, tt.status, recorder.Code)
+			if tt.response != "" {
+				assert.JSONEq(t, tt.response, recorder.Body.String())
+			}
+		})
+	}
+}
+
+func TestGetVariantStock(t *testing.T) {
+	tests := []struct {
+		name     string
+		code     string
+		sku      string
+		status   int
+		response string
+	}{
+		{name: "stock of the variant", code: "PROD001", sku: "SKU001A", status: http.StatusOK, response: `{"sku":"SKU001A","stock":5}`},
+		{name: "variant out of stock", code: "PROD001", sku: "SKU001B", status: http.StatusOK, response: `{"sku":"SKU001B","stock":0}`},
+		{name: "unknown sku", code: "PROD001", sku: "SKU404", status: http.StatusNotFound, response: `{"error":"resource not found: variant SKU404 of product PROD001"}`},
+		{name: "sku of another product", code: "PROD002", sku: "SKU001A", status: http.StatusNotFound, response: `{"error":"resource not found: variant SKU001A of product PROD002"}`},
+		{name: "unknown product", code: "PROD404", sku: "SKU001A", status: http.StatusNotFound, response: `{"error":"resource not found: variant SKU001A of product PROD404"}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := newTestHandler(&mockProductsRepository{products: testProducts()})
+
+			req := httptest.NewRequest(http.MethodGet, "/catalog/"+tt.code+"/variants/"+tt.sku+"/stock", nil)
+			req.SetPathValue("code", tt.code)
+			req.SetPathValue("sku", tt.sku)
+			recorder := httptest.NewRecorder()
+			h.GetVariantStock(recorder, req)
+
+			assert.Equal(t, tt.status, recorder.Code)
+			assert.JSONEq(t, tt.response, recorder.Body.String())
+		})
+	}
+}
+
+func TestGetRandom(t *testing.T) {
+	tests := []struct {
+		name   string
+		query  string
+		status int
+		codes  []string
+	}{
+		{name: "default count", query: "", status: http.StatusOK, codes: []string{"PROD001", "PROD002", "PROD003"}},
+		{name: "count", query: "?count=2", status: http.StatusOK, codes: []string{"PROD001", "PROD002"}},
+		{name: "count below minimum", query: "?count=0", status: http.StatusOK, codes: []string{"PROD001"}},
+		{name: "within a category", query: "?category=Shoes", status: http.StatusOK, codes: []string{"PROD002"}},
+		{name: "category in another case", query: "?category=sHOES", status: http.StatusOK, codes: []string{"PROD002"}},
+		{name: "invalid count", query: "?count=abc", status: http.StatusBadRequest},
+		{name: "unsupported currency", query: "?currency=JPY", status: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := newTestHandler(&mockProductsRepository{products: testProducts()})
+
+			recorder := httptest.NewRecorder()
+			h.GetRandom(recorder, httptest.NewRequest(http.MethodGet, "/catalog/random"+tt.query, nil))
+
+			assert.Equal(t, tt.status, recorder.Code)
+			if tt.status != http.StatusOK {
+				return
+			}
+
+			var res Response
+			assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &res))
+			codes := make([]string, len(res.Products))
+			for i, p := range res.Products {
+				codes[i] = p.Code
+			}
+			assert.Equal(t, tt.codes, codes)
+		})
+	}
+}
+
+func TestGetRandomCapsCount(t *testing.T) {
+	var products []models.Product
+	for i := range maxRandomCount + 5 {
+		products = append(products, models.Product{Code: fmt.Sprintf("P%02d", i), Currency: "USD"})
+	}
+	h := newTestHandler(&mockProductsRepository{products: products})
+
+	recorder := httptest.NewRecorder()
+	h.GetRandom(recorder, httptest.NewRequest(http.MethodGet, "/catalog/random?count=1000", nil))
+
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	var res Response
+	assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &res))
+	assert.Len(t, res.Products, maxRandomCount)
+}
+
+func TestCreateProduct(t *testing.T) {
+	tests := []struct {
+		name     string
+		body     string
+		status   int
+		response string
+		created  bool
+	}{
+		{
+			name:   "product with variants",
+			body:   `{"code":"PROD009","sku":"SKU009","price":20,"category":"CLOTHING","variants":[{"name":"Variant A","sku":"SKU009A"},{"name":"Variant B","sku":"SKU009B","price":25}]}`,
+			status: http.StatusCreated,
+			response: `{"code":"PROD009","sku":"SKU009","price":20,"currency":"USD","category":"Clothing","category_code":"CLOTHING","category_name":"Clothing","featured":false,"version":1,"variants":[
+				{"name":"Variant A","sku":"SKU009A","price":20,"sale_price":null,"discount_percent":null},
+				{"name":"Variant B","sku":"SKU009B","price":25,"sale_price":null,"discount_percent":null}
+			]}`,
+			created: true,
+		},
+		{
+			name:     "product without variants",
+			body:     `{"code":"PROD009","price":20,"currency":"eur"}`,
+			status:   http.StatusCreated,
+			response: `{"code":"PROD009","sku":"","price":20,"currency":"EUR","category":"","featured":false,"version":1,"variants":[]}`,
+			created:  true,
+		},
+		{
+			name:     "product with a name",
+			body:     `{"code":"PROD009","name":"Linen shirt","price":20}`,
+			status:   http.StatusCreated,
+			response: `{"code":"PROD009","name":"Linen shirt","sku":"","price":20,"currency":"USD","category":"","featured":false,"version":1,"variants":[]}`,
+			created:  true,
+		},
+		{
+			name:     "unknown field",
+			body:     `{"code":"PROD009","price":20,"namme":"Hat"}`,
+			status:   http.StatusBadRequest,
+			response: `{"error":"unknown field: namme"}`,
+		},
+		{
+			name:   "missing code",
+			body:   `{"price":20}`,
+			status: http.StatusBadRequest,
+		},
+		{
+			name:     "invalid code",
+			body:     `{"code":"prod 009","price":20}`,
+			status:   http.StatusBadRequest,
+			response: `{"errors":[{"field":"code","message":"code must be 3 to 50 characters of A-Z, 0-9, _ or -"}]}`,
+		},
+		{
+			name:     "zero price",
+			body:     `{"code":"PROD009","price":0}`,
+			status:   http.StatusBadRequest,
+			response: `{"errors":[{"field":"price","message":"price must be greater than zero"}]}`,
+		},
+		{
+			name:     "unknown category",
+			body:     `{"code":"PROD009","price":20,"category":"HATS"}`,
+			status:   http.StatusBadRequest,
+			response: `{"errors":[{"field":"category","message":"unknown category HATS"}]}`,
+		},
+		{
+			name:     "currency not in ISO 4217",
+			body:     `{"code":"PROD009","price":20,"currency":"dollar"}`,
+			status:   http.StatusBadRequest,
+			response: `{"errors":[{"field":"currency","message":"DOLLAR is not an ISO 4217 currency code"}]}`,
+		},
+		{
+			name:     "unsupported currency",
+			body:     `{"code":"PROD009","price":20,"currency":"JPY"}`,
+			status:   http.StatusBadRequest,
+			response: `{"errors":[{"field":"currency","message":"unsupported currency JPY"}]}`,
+		},
+		{
+			name:     "invalid fields",
+			body:     `{"price":-1,"variants":[{"name":"Variant A","sku":"SKU009A"},{"sku":"SKU009B","price":-1}]}`,
+			status:   http.StatusBadRequest,
+			response: `{"errors":[{"field":"code","message":"code is required"},{"field":"price","message":"price must be greater than zero"},{"field":"variants[1].name","message":"variants[1].name is required"},{"field":"variants[1].price","message":"variants[1].price must be greater than zero"}]}`,
+		},
+		{
+			name:     "failing variant rolls back the product",
+			body:     `{"code":"PROD009","price":20,"variants":[{"name":"Variant A","sku":"SKU009A"},{"name":"Variant B","sku":"SKU001A"}]}`,
+			status:   http.StatusConflict,
+			response: `{"error":"SKU already exists"}`,
+		},
+		{
+			name:     "duplicate code",
+			body:     `{"code":"PROD001","price":20}`,
+			status:   http.StatusConflict,
+			response: `{"error":"product PROD001 already exists"}`,
+		},
+		{
+			name:     "duplicate SKU",
+			body:     `{"code":"PROD009","sku":"SKU001","price":20}`,
+			status:   http.StatusConflict,
+			response: `{"error":"product PROD009 or SKU SKU001 already exists"}`,
+		},
+		{
+			name:   "malformed body",
+			body:   `{`,
+			status: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &mockProductsRepository{products: testProducts()}
+			h := newTestHandler(repo)
+
+			recorder := httptest.NewRecorder()
+			h.CreateProduct(recorder, httptest.NewRequest(http.MethodPost, "/catalog", strings.NewReader(tt.body)))
+
+			assert.Equal(t, tt.status, recorder.Code)
+			if tt.response != "" {
+				assert.JSONEq(t, tt.response, recorder.Body.String())
+			}
+
+			if tt.created {
+				assert.Equal(t, "/catalog/PROD009", recorder.Header().Get("Location"))
+			} else {
+				assert.Empty(t, recorder.Header().Get("Location"))
+			}
+
+			var product models.Product
+			err := repo.GetProductByCode(context.Background(), "PROD009", &product)
+			assert.Equal(t, tt.created, err == nil)
+		})
+	}
+}
+
+func TestUpdateProduct(t *testing.T) {
+	tests := []struct {
+		name     string
+		code     string
+		body     string
+		status   int
+		response string
+	}{
+		{
+			name:     "price only",
+			code:     "PROD002",
+			body:     `{"version":1,"price":15}`,
+			status:   http.StatusOK,
+			response: `{"code":"PROD002","sku":"SKU002","price":15,"currency":"USD","category":"Shoes","category_code":"SHOES","category_name":"Shoes","featured":true,"version":2,"variants":[]}`,
+		},
+		{
+			name:     "unknown field",
+			code:     "PROD002",
+			body:     `{"version":1,"title":"Sneakers","price":15}`,
+			status:   http.StatusBadRequest,
+			response: `{"error":"unknown field: title"}`,
+		},
+		{
+			name:     "name",
+			code:     "PROD002",
+			body:     `{"version":1,"name":"Canvas sneakers"}`,
+			status:   http.StatusOK,
+			response: `{"code":"PROD002","name":"Canvas sneakers","sku":"SKU002","price":12.49,"currency":"USD","category":"Shoes","category_code":"SHOES","category_name":"Shoes","featured":true,"version":2,"variants":[]}`,
+		},
+		{
+			name:     "only the version changes without fields",
+			code:     "PROD002",
+			body:     `{"version":1}`,
+			status:   http.StatusOK,
+			response: `{"code":"PROD002","sku":"SKU002","price":12.49,"currency":"USD","category":"Shoes","category_code":"SHOES","category_name":"Shoes","featured":true,"version":2,"variants":[]}`,
+		},
+		{
+			name:     "clear sku and change currency",
+			code:     "PROD002",
+			body:     `{"version":1,"sku":"","currency":"eur","featured":false}`,
+			status:   http.StatusOK,
+			response: `{"code":"PROD002","sku":"","price":12.49,"currency":"EUR","category":"Shoes","featured":false,"version":2,"variants":[],"category_code":"SHOES","category_name":"Shoes"}`,
+		},
+		{
+			name:     "zero price",
+			code:     "PROD002",
+			body:     `{"version":1,"price":0}`,
+			status:   http.StatusBadRequest,
+			response: `{"errors":[{"field":"price","message":"price must be greater than zero"}]}`,
+		},
+		{
+			name:     "currency not in ISO 4217",
+			code:     "PROD002",
+			body:     `{"version":1,"currency":"DOLLAR"}`,
+			status:   http.StatusBadRequest,
+			response: `{"errors":[{"field":"currency","message":"DOLLAR is not an ISO 4217 currency code"}]}`,
+		},
+		{
+			name:     "unsupported currency",
+			code:     "PROD002",
+			body:     `{"version":1,"currency":"JPY"}`,
+			status:   http.StatusBadRequest,
+			response: `{"errors":[{"field":"currency","message":"unsupported currency JPY"}]}`,
+		},
+		{
+			name:     "unknown category",
+			code:     "PROD002",
+			body:     `{"version":1,"category":"HATS"}`,
+			status:   http.StatusBadRequest,
+			response: `{"errors":[{"field":"category","message":"unknown category HATS"}]}`,
+		},
+		{
+			name:   "unknown product",
+			code:   "NOPE",
+			body:   `{"version":1,"price":15}`,
+			status: http.StatusNotFound,
+		},
+		{
+			name:     "missing version",
+			code:     "PROD002",
+			body:     `{"price":15}`,
+			status:   http.StatusBadRequest,
+			response: `{"errors":[{"field":"version","message":"version is required"}]}`,
+		},
+		{
+			name:     "stale version",
+			code:     "PROD002",
+			body:     `{"version":2,"price":15}`,
+			status:   http.StatusConflict,
+			response: `{"error":"version conflict, reload the resource and retry"}`,
+		},
+		{
+			name:   "malformed body",
+			code:   "PROD002",
+			body:   `{`,
+			status: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := newTestHandler(&mockProductsRepository{products: testProducts()})
+
+			req := httptest.NewRequest(http.MethodPatch, "/catalog/"+tt.code, strings.NewReader(tt.body))
+			req.SetPathValue("code", tt.code)
+			recorder := httptest.NewRecorder()
+			h.UpdateProduct(recorder, req)
+
+			assert.Equal(t, tt.status, recorder.Code)
+			if tt.response != "" {
+				assert.JSONEq(t, tt.response, recorder.Body.String())
+			}
+		})
+	}
+}
+
+func TestGetPriceHistory(t *testing.T) {
+	repo := &mockProductsRepository{products: testProducts()}
+	h := newTestHandler(repo)
+
+	update := func(body, user string) {
+		req := httptest.NewRequest(http.MethodPatch, "/catalog/PROD002", strings.NewReader(body))
+		req.SetPathValue("code", "PROD002")
+		req.Header.Set("X-User", user)
+		recorder := httptest.NewRecorder()
+		h.UpdateProduct(recorder, req)
+		assert.Equal(t, http.StatusOK, recorder.Code)
+	}
+	update(`{"version":1,"price":15}`, "alice")
+	update(`{"version":2,"price":15}`, "bob")
+	update(`{"version":3,"currency":"EUR"}`, "bob")
+	update(`{"version":4,"price":9.5}`, "")
+
+	t.Run("lists price changes, most recent first", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/catalog/PROD002/price-history", nil)
+		req.SetPathValue("code", "PROD002")
+		recorder := httptest.NewRecorder()
+		h.GetPriceHistory(recorder, req)
+
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		var history PriceHistory
+		assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &history))
+		assert.Equal(t, "PROD002", history.Code)
+		if assert.Len(t, history.Changes, 2) {
+			assert.Equal(t, 15.0, history.Changes[0].OldPrice)
+			assert.Equal(t, 9.5, history.Changes[0].NewPrice)
+			assert.Equal(t, "", history.Changes[0].ChangedBy)
+			assert.Equal(t, 12.49, history.Changes[1].OldPrice)
+			assert.Equal(t, 15.0, history.Changes[1].NewPrice)
+			assert.Equal(t, "alice", history.Changes[1].ChangedBy)
+			assert.WithinDuration(t, time.Now(), history.Changes[1].ChangedAt, time.Minute)
+		}
+	})
+
+	t.Run("product without price changes", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/catalog/PROD001/price-history", nil)
+		req.SetPathValue("code", "PROD001")
+		recorder := httptest.NewRecorder()
+		h.GetPriceHistory(recorder, req)
+
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		assert.JSONEq(t, `{"code":"PROD001","changes":[]}`, recorder.Body.String())
+	})
+
+	t.Run("unknown product", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/catalog/NOPE/price-history", nil)
+		req.SetPathValue("code", "NOPE")
+		recorder := httptest.NewRecorder()
+		h.GetPriceHistory(recorder, req)
+
+		assert.Equal(t, http.StatusNotFound, recorder.Code)
+	})
+}
+
+func TestAddImage(t *testing.T) {
+	tests := []struct {
+		name     string
+		code     string
+		body     string
+		status   int
+		response string
+		images   []string
+	}{
+		{
+			name:     "appends the image",
+			code:     "PROD002",
+			body:     `{"url":"https://cdn.example.com/2b.png"}`,
+			status:   http.StatusOK,
+			response: `{"code":"PROD002","sku":"SKU002","price":12.49,"currency":"USD","category":"Shoes","featured":true,"version":2,"variants":[],"images":["https://cdn.example.com/2a.png","https://cdn.example.com/2b.png"],"category_code":"SHOES","category_name":"Shoes"}`,
+			images:   []string{"https://cdn.example.com/2a.png", "https://cdn.example.com/2b.png"},
+		},
+		{
+			name:     "relative url",
+			code:     "PROD002",
+			body:     `{"url":"/2b.png"}`,
+			status:   http.StatusBadRequest,
+			response: `{"errors":[{"field":"url","message":"url must be an absolute http or https URL"}]}`,
+			images:   []string{"https://cdn.example.com/2a.png"},
+		},
+		{
+			name:     "missing url",
+			code:     "PROD002",
+			body:     `{}`,
+			status:   http.StatusBadRequest,
+			response: `{"errors":[{"field":"url","message":"url is required"}]}`,
+			images:   []string{"https://cdn.example.com/2a.png"},
+		},
+		{
+			name:   "malformed body",
+			code:   "PROD002",
+			body:   `{`,
+			status: http.StatusBadRequest,
+			images: []string{"https://cdn.example.com/2a.png"},
+		},
+		{
+			name:     "unknown product",
+			code:     "NOPE",
+			body:     `{"url":"https://cdn.example.com/2b.png"}`,
+			status:   http.StatusNotFound,
+			response: `{"error":"resource not found: product with code NOPE"}`,
+			images:   []string{"https://cdn.example.com/2a.png"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &mockProductsRepository{products: testProducts()}
+			repo.products[1].Images = pq.StringArray{"https://cdn.example.com/2a.png"}
+			h := newTestHandler(repo)
+
+			req := httptest.NewRequest(http.MethodPost, "/catalog/"+tt.code+"/images", strings.NewReader(tt.body))
+			req.SetPathValue("code", tt.code)
+			recorder := httptest.NewRecorder()
+			h.AddImage(recorder, req)
+
+			assert.Equal(t, tt.status, recorder.Code)
+			if tt.response != "" {
+				assert.JSONEq(t, tt.response, recorder.Body.String())
+			}
+			assert.Equal(t, pq.StringArray(tt.images), repo.products[1].Images)
+		})
+	}
+}
+
+func TestDeleteImage(t *testing.T) {
+	tests := []struct {
+		name     string
+		code     string
+		index    string
+		status   int
+		response string
+		images   []string
+	}{
+		{
+			name:   "removes the image",
+			code:   "PROD002",
+			index:  "0",
+			status: http.StatusNoContent,
+			images: []string{"https://cdn.example.com/2b.png", "https://cdn.example.com/2c.png"},
+		},
+		{
+			name:   "later images move up",
+			code:   "PROD002",
+			index:  "1",
+			status: http.StatusNoContent,
+			images: []string{"https://cdn.example.com/2a.png", "https://cdn.example.com/2c.png"},
+		},
+		{
+			name:     "index out of range",
+			code:     "PROD002",
+			index:    "3",
+			status:   http.StatusNotFound,
+			response: `{"error":"resource not found: image 3 of product PROD002"}`,
+			images:   []string{"https://cdn.example.com/2a.png", "https://cdn.example.com/2b.png", "https://cdn.example.com/2c.png"},
+		},
+		{
+			name:     "negative index",
+			code:     "PROD002",
+			index:    "-1",
+			status:   http.StatusBadRequest,
+			response: `{"error":"invalid image index \"-1\""}`,
+			images:   []string{"https://cdn.example.com/2a.png", "https://cdn.example.com/2b.png", "https://cdn.example.com/2c.png"},
+		},
+		{
+			name:     "unknown product",
+			code:     "NOPE",
+			index:    "0",
+			status:   http.StatusNotFound,
+			response: `{"error":"resource not found: product with code NOPE"}`,
+			images:   []string{"https://cdn.example.com/2a.png", "https://cdn.example.com/2b.png", "https://cdn.example.com/2c.png"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &mockProductsRepository{products: testProducts()}
+			repo.products[1].Images = pq.StringArray{"https://cdn.example.com/2a.png", "https://cdn.example.com/2b.png", "https://cdn.example.com/2c.png"}
+			h := newTestHandler(repo)
+
+			req := httptest.NewRequest(http.MethodDelete, "/catalog/"+tt.code+"/images/"+tt.index, nil)
+			req.SetPathValue("code", tt.code)
+			req.SetPathValue("index", tt.index)
+			recorder := httptest.NewRecorder()
+			h.DeleteImage(recorder, req)
+
+			assert.Equal(t, tt.status, recorder.Code)
+			if tt.response != "" {
+				assert.JSONEq(t, tt.response, recorder.Body.String())
+			}
+			assert.Equal(t, pq.StringArray(tt.images), repo.products[1].Images)
+		})
+	}
+}
+
+func TestSetCategory(t *testing.T) {
+	tests := []struct {
+		name     string
+		code     string
+		body     string
+		status   int
+		response string
+	}{
+		{
+			name:     "moves the product",
+			code:     "PROD002",
+			body:     `{"category_code":"CLOTHING"}`,
+			status:   http.StatusOK,
+			response: `{"code":"PROD002","sku":"SKU002","price":12.49,"currency":"USD","category":"Clothing","featured":true,"version":1,"variants":[],"category_code":"CLOTHING","category_name":"Clothing"}`,
+		},
+		{
+			name:     "unknown product",
+			code:     "NOPE",
+			body:     `{"category_code":"CLOTHING"}`,
+			status:   http.StatusNotFound,
+			response: `{"error":"resource not found: product with code NOPE"}`,
+		},
+		{
+			name:     "unknown category",
+			code:     "PROD002",
+			body:     `{"category_code":"HATS"}`,
+			status:   http.StatusNotFound,
+			response: `{"error":"resource not found: category with code HATS"}`,
+		},
+		{
+			name:     "missing category code",
+			code:     "PROD002",
+			body:     `{}`,
+			status:   http.StatusBadRequest,
+			response: `{"errors":[{"field":"category_code","message":"category_code is required"}]}`,
+		},
+		{
+			name:   "malformed body",
+			code:   "PROD002",
+			body:   `{`,
+			status: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &mockProductsRepository{products: testProducts()}
+			h := newTestHandler(repo)
+
+			req := httptest.NewRequest(http.MethodPatch, "/catalog/"+tt.code+"/category", strings.NewReader(tt.body))
+			req.SetPathValue("code", tt.code)
+			recorder := httptest.NewRecorder()
+			h.SetCategory(recorder, req)
+
+			assert.Equal(t, tt.status, recorder.Code)
+			if tt.response != "" {
+				assert.JSONEq(t, tt.response, recorder.Body.String())
+			}
+
+			var product models.Product
+			if repo.GetProductByCode(context.Background(), "PROD002", &product) == nil {
+				assert.Equal(t, tt.status == http.StatusOK, product.CategoryID != nil)
+			}
+		})
+	}
+}
+
+func TestAdjustPrices(t *testing.T) {
+	clothing := uint(1)
+	products := func(cheapest string) []models.Product {
+		return []models.Product{
+			{Code: "PROD001", Price: decimal.RequireFromString("10.00"), Currency: "USD", CategoryID: &clothing, Version: 1},
+			{Code: "PROD002", Price: decimal.RequireFromString(cheapest), Currency: "USD", CategoryID: &clothing, Version: 1},
+			{Code: "PROD003", Price: decimal.RequireFromString("30.00"), Currency: "USD", Version: 1},
+		}
+	}
+
+	tests := []struct {
+		name     string
+		cheapest string
+		body     string
+		status   int
+		response string
+		prices   []string
+	}{
+		{
+			name:     "reprices the category",
+			cheapest: "20.00",
+			body:     `{"category_code":"CLOTHING","percent":-10}`,
+			status:   http.StatusOK,
+			response: `{"category_code":"CLOTHING","percent":-10,"updated":2}`,
+			prices:   []string{"9", "18", "30"},
+		},
+		{
+			name:     "rounds to cents and skips unchanged prices",
+			cheapest: "0.01",
+			body:     `{"category_code":"CLOTHING","percent":12.5}`,
+			status:   http.StatusOK,
+			response: `{"category_code":"CLOTHING","percent":12.5,"updated":1}`,
+			prices:   []string{"11.25", "0.01", "30"},
+		},
+		{
+			name:     "no price may drop to zero",
+			cheapest: "0.01",
+			body:     `{"category_code":"CLOTHING","percent":-60}`,
+			status:   http.StatusBadRequest,
+			response: `{"errors":[{"field":"percent","message":"would reduce the price of PROD002 to 0.00"}]}`,
+			prices:   []string{"10", "0.01", "30"},
+		},
+		{
+			name:     "percent must be above -100",
+			cheapest: "20.00",
+			body:     `{"category_code":"CLOTHING","percent":-100}`,
+			status:   http.StatusBadRequest,
+			response: `{"errors":[{"field":"percent","message":"percent must be greater than -100"}]}`,
+			prices:   []string{"10", "20", "30"},
+		},
+		{
+			name:     "unknown category",
+			cheapest: "20.00",
+			body:     `{"category_code":"HATS","percent":10}`,
+			status:   http.StatusNotFound,
+			response: `{"error":"resource not found: category with code HATS"}`,
+			prices:   []string{"10", "20", "30"},
+		},
+		{
+			name:     "missing fields",
+			cheapest: "20.00",
+			body:     `{}`,
+			status:   http.StatusBadRequest,
+			response: `{"errors":[{"field":"category_code","message":"category_code is required"},{"field":"percent","message":"percent is required"}]}`,
+			prices:   []string{"10", "20", "30"},
+		},
+		{
+			name:     "malformed body",
+			cheapest: "20.00",
+			body:     `{`,
+			status:   http.StatusBadRequest,
+			response: `{"error":"invalid request body"}`,
+			prices:   []string{"10", "20", "30"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &mockProductsRepository{products: products(tt.cheapest)}
+			h := newTestHandler(repo)
+
+			req := httptest.NewRequest(http.MethodPost, "/catalog/price-adjust", strings.NewReader(tt.body))
+			req.Header.Set("X-User", "merchandiser")
+			recorder := httptest.NewRecorder()
+			h.AdjustPrices(recorder, req)
+
+			assert.Equal(t, tt.status, recorder.Code)
+			assert.JSONEq(t, tt.response, recorder.Body.String())
+
+			prices := make([]string, len(repo.products))
+			for i, p := range repo.products {
+				prices[i] = p.Price.String()
+			}
+			assert.Equal(t, tt.prices, prices)
+
+			if tt.status == http.StatusOK {
+				for _, e := range repo.priceChanges {
+					assert.Equal(t, "merchandiser", e.ChangedBy)
+				}
+			} else {
+				assert.Empty(t, repo.priceChanges)
+			}
+		})
+	}
+}
+
+// flatShipping charges Cost per unit, whatever the product.
+type flatShipping struct {
+	Cost decimal.Decimal
+}
+
+func (c flatShipping) Estimate(ctx context.Context, product models.Product, quantity int) (decimal.Decimal, error) {
+	return c.Cost.Mul(decimal.NewFromInt(int64(quantity))), nil
+}
+
+func TestGetShipping(t *testing.T) {
+	products := []models.Product{
+		{Code: "PROD001", Price: decimal.RequireFromString("10"), Currency: "USD", WeightGrams: 250, LengthMm: 300, WidthMm: 200, HeightMm: 50, ShippingClass: "fragile"},
+	}
+
+	tests := []struct {
+		name     string
+		code     string
+		query    string
+		status   int
+		response string
+	}{
+		{
+			name:     "one unit by default",
+			code:     "PROD001",
+			status:   http.StatusOK,
+			response: `{"code":"PROD001","quantity":1,"weight_grams":250,"shipping_class":"fragile","cost":2.5,"currency":"USD"}`,
+		},
+		{
+			name:     "quantity and currency",
+			code:     "PROD001",
+			query:    "?qty=3&currency=eur",
+			status:   http.StatusOK,
+			response: `{"code":"PROD001","quantity":3,"weight_grams":750,"shipping_class":"fragile","cost":3.75,"currency":"EUR"}`,
+		},
+		{
+			name:     "invalid quantity",
+			code:     "PROD001",
+			query:    "?qty=0",
+			status:   http.StatusBadRequest,
+			response: `{"error":"invalid qty \"0\", want 1 to 1000"}`,
+		},
+		{
+			name:     "unsupported currency",
+			code:     "PROD001",
+			query:    "?currency=JPY",
+			status:   http.StatusBadRequest,
+			response: `{"error":"unsupported currency JPY"}`,
+		},
+		{
+			name:     "unknown product",
+			code:     "NOPE",
+			status:   http.StatusNotFound,
+			response: `{"error":"resource not found: product with code NOPE"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := NewCatalogService(&mockProductsRepository{products: products}, testRates(),
+				WithShippingCalculator(flatShipping{Cost: decimal.RequireFromString("2.50")}))
+			h := NewCatalogHandler(service, DefaultConfig())
+
+			req := httptest.NewRequest(http.MethodGet, "/catalog/"+tt.code+"/shipping"+tt.query, nil)
+			req.SetPathValue("code", tt.code)
+			recorder := httptest.NewRecorder()
+			h.GetShipping(recorder, req)
+
+			assert.Equal(t, tt.status, recorder.Code)
+			assert.JSONEq(t, tt.response, recorder.Body.String())
+		})
+	}
+
+	t.Run("product details include the shipping metadata", func(t *testing.T) {
+		h := newTestHandler(&mockProductsRepository{products: products})
+
+		req := httptest.NewRequest(http.MethodGet, "/catalog/PROD001", nil)
+		req.SetPathValue("code", "PROD001")
+		recorder := httptest.NewRecorder()
+		h.GetProduct(recorder, req)
+
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		assert.JSONEq(t, `{"code":"PROD001","sku":"","price":10,"currency":"USD","category":"","featured":false,"version":0,"variants":[],
+			"shipping":{"weight_grams":250,"length_mm":300,"width_mm":200,"height_mm":50,"shipping_class":"fragile"}}`, recorder.Body.String())
+	})
+}
+
+func TestGetCount(t *testing.T) {
+	tests := []struct {
+		name     string
+		query    string
+		err      error
+		status   int
+		response string
+	}{
+		{name: "every product", query: "", status: http.StatusOK, response: `{"total":3,"count":3}`},
+		{name: "within a category", query: "?category=Shoes", status: http.StatusOK, response: `{"total":1,"count":1}`},
+		{name: "within a price range", query: "?price_gte=9&price_lt=12", status: http.StatusOK, response: `{"total":1,"count":1}`},
+		{name: "combined filters", query: "?category=Clothing&price_lt=50", status: http.StatusOK, response: `{"total":1,"count":1}`},
+		{name: "ignores pagination", query: "?limit=1&offset=2", status: http.StatusOK, response: `{"total":3,"count":3}`},
+		{name: "invalid filter", query: "?price_lt=abc", status: http.StatusBadRequest, response: `{"error":"price_lt must be a number"}`},
+		{name: "repository error", query: "", err: errors.New("boom"), status: http.StatusInternalServerError, response: `{"error":"internal server error"}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := newTestHandler(&mockProductsRepository{products: testProducts(), err: tt.err})
+
+			recorder := httptest.NewRecorder()
+			h.GetCount(recorder, httptest.NewRequest(http.MethodGet, "/catalog/count"+tt.query, nil))
+
+			assert.Equal(t, tt.status, recorder.Code)
+			assert.JSONEq(t, tt.response, recorder.Body.String())
+		})
+	}
+}
+
+func TestGetStreamOutlastsTimeouts(t *testing.T) {
+	// Each product takes longer than the request timeout, and the whole
+	// export longer than the write timeout of the server.
+	h := newTestHandler(&mockProductsRepository{products: testProducts(), eachDelay: 30 * time.Millisecond})
+	srv := httptest.NewUnstartedServer(middleware.Timeout(10*time.Millisecond, "/catalog/stream")(http.HandlerFunc(h.GetStream)))
+	srv.Config.WriteTimeout = 50 * time.Millisecond
+	srv.Start()
+	defer srv.Close()
+
+	res, err := http.Get(srv.URL + "/catalog/stream")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer res.Body.Close()
+	body, err := io.ReadAll(res.Body)
+
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	lines := strings.Split(strings.TrimSuffix(string(body), "\n"), "\n")
+	if assert.Len(t, lines, 3) {
+		for i, code := range []string{"PROD001", "PROD002", "PROD003"} {
+			assert.Contains(t, lines[i], `"code":"`+code+`"`)
+		}
+	}
+}
+
+func TestGetStream(t *testing.T) {
+	stream := func(h *CatalogHandler, query string) *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		h.GetStream(recorder, httptest.NewRequest(http.MethodGet, "/catalog/stream"+query, nil))
+		return recorder
+	}
+
+	t.Run("one product per line", func(t *testing.T) {
+		recorder := stream(newTestHandler(&mockProductsRepository{products: testProducts()}), "")
+
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		assert.Equal(t, ContentTypeNDJSON, recorder.Header().Get("Content-Type"))
+		assert.True(t, recorder.Flushed)
+
+		lines := strings.Split(recorder.Body.String(), "\n")
+		assert.Len(t, lines, 4)
+		assert.Equal(t, "", lines[3], "the last line ends with a newline")
+		assert.JSONEq(t, `{"code":"PROD002","sku":"SKU002","price":12.49,"currency":"USD","category":"Shoes","category_code":"SHOES","category_name":"Shoes","featured":true,"version":1,"variants":[]}`, lines[1])
+		for i, code := range []string{"PROD001", "PROD002", "PROD003"} {
+			assert.Contains(t, lines[i], `"code":"`+code+`"`)
+		}
+	})
+
+	t.Run("converts prices", func(t *testing.T) {
+		recorder := stream(newTestHandler(&mockProductsRepository{products: testProducts()}), "?currency=eur")
+
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		assert.Contains(t, recorder.Body.String(), `"currency":"EUR"`)
+		assert.NotContains(t, recorder.Body.String(), `"currency":"USD"`)
+	})
+
+	t.Run("empty catalog", func(t *testing.T) {
+		recorder := stream(newTestHandler(&mockProductsRepository{}), "")
+
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		assert.Equal(t, ContentTypeNDJSON, recorder.Header().Get("Content-Type"))
+		assert.Empty(t, recorder.Body.String())
+	})
+
+	t.Run("unsupported currency", func(t *testing.T) {
+		recorder := stream(newTestHandler(&mockProductsRepository{products: testProducts()}), "?currency=JPY")
+
+		assert.Equal(t, http.StatusBadRequest, recorder.Code)
+		assert.JSONEq(t, `{"error":"unsupported currency JPY"}`, recorder.Body.String())
+	})
+
+	t.Run("error before the first line", func(t *testing.T) {
+		recorder := stream(newTestHandler(&mockProductsRepository{err: errors.New("boom")}), "")
+
+		assert.Equal(t, http.StatusInternalServerError, recorder.Code)
+		assert.JSONEq(t, `{"error":"internal server error"}`, recorder.Body.String())
+	})
+
+	t.Run("error after the first line", func(t *testing.T) {
+		products := testProducts()
+		products[1].Currency = "JPY"
+		recorder := stream(newTestHandler(&mockProductsRepository{products: products}), "?currency=EUR")
+
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		lines := strings.Split(strings.TrimSuffix(recorder.Body.String(), "\n"), "\n")
+		assert.Len(t, lines, 2)
+		assert.Contains(t, lines[0], `"code":"PROD001"`)
+		assert.JSONEq(t, `{"error":"internal server error"}`, lines[1])
+	})
+}
+
+func TestGetStats(t *testing.T) {
+	tests := []struct {
+		name     string
+		products []models.Product
+		response string
+	}{
+		{
+			name:     "empty catalog",
+			products: nil,
+			response: `{"count":0,"average_price":"0.00","currency":"USD"}`,
+		},
+		{
+			name:     "average price",
+			products: testProducts(),
+			response: `{"count":3,"average_price":"10.74","currency":"USD"}`,
+		},
+		{
+			name: "converts to the base currency",
+			products: []models.Product{
+				{Code: "PROD001", Price: decimal.RequireFromString("10"), Currency: "USD"},
+				{Code: "PROD002", Price: decimal.RequireFromString("10"), Currency: "EUR"},
+			},
+			response: `{"count":2,"average_price":"15.00","currency":"USD"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := newTestHandler(&mockProductsRepository{products: tt.products})
+
+			recorder := httptest.NewRecorder()
+			h.GetStats(recorder, httptest.NewRequest(http.MethodGet, "/catalog/stats", nil))
+
+			assert.Equal(t, http.StatusOK, recorder.Code)
+			assert.JSONEq(t, tt.response, recorder.Body.String())
+		})
+	}
+
+	t.Run("repository error", func(t *testing.T) {
+		h := newTestHandler(&mockProductsRepository{err: errors.New("boom")})
+
+		recorder := httptest.NewRecorder()
+		h.GetStats(recorder, httptest.NewRequest(http.MethodGet, "/catalog/stats", nil))
+
+		assert.Equal(t, http.StatusInternalServerError, recorder.Code)
+	})
+}
@@ -0,0 +1,79 @@
+package catalog
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/eya20/hiring_test/app/api"
+	"github.com/eya20/hiring_test/app/tracing"
+	"github.com/eya20/hiring_test/app/webhooks"
+	"github.com/eya20/hiring_test/models"
+	"github.com/lib/pq"
+	"gorm.io/gorm"
+)
+
+// AddProductImage appends req.URL to the images of the product identified by
+// code.
+func (s *CatalogService) AddProductImage(ctx context.Context, code string, req ImageRequest) (ProductDetails, error) {
+	ctx, span := tracing.Start(ctx, "CatalogService.AddProductImage")
+	defer span.End()
+
+	if err := api.ValidateStruct(req); err != nil {
+		return ProductDetails{}, err
+	}
+
+	return s.updateImages(ctx, code, func(images []string) ([]string, error) {
+		return append(images, req.URL), nil
+	})
+}
+
+// RemoveProductImage removes the image at index, counted from zero, from the
+// images of the product identified by code. The images after it move up.
+func (s *CatalogService) RemoveProductImage(ctx context.Context, code string, index int) error {
+	ctx, span := tracing.Start(ctx, "CatalogService.RemoveProductImage")
+	defer span.End()
+
+	_, err := s.updateImages(ctx, code, func(images []string) ([]string, error) {
+		if index < 0 || index >= len(images) {
+			return nil, fmt.Errorf("%w: image %d of product %s", api.ErrNotFound, index, code)
+		}
+		return append(images[:index:index], images[index+1:]...), nil
+	})
+	return err
+}
+
+// updateImages replaces the images of the product identified by code with
+// the result of fn, in a transaction and at the version they were read at.
+func (s *CatalogService) updateImages(ctx context.Context, code string, fn func([]string) ([]string, error)) (ProductDetails, error) {
+	var product models.Product
+	err := s.withTransaction(ctx, func(repos models.TxRepositories) error {
+		if err := repos.Products.GetProductByCode(ctx, code, &product); err != nil {
+			return err
+		}
+
+		images, err := fn(product.Images)
+		if err != nil {
+			return err
+		}
+
+		if err := repos.Products.UpdateProduct(ctx, code, product.Version, map[string]any{"images": pq.StringArray(images)}); err != nil {
+			return err
+		}
+		product.Images = images
+		product.Version++
+		return nil
+	})
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ProductDetails{}, fmt.Errorf("%w: product with code %s", api.ErrNotFound, code)
+		}
+		if errors.Is(err, models.ErrVersionConflict) {
+			return ProductDetails{}, api.Conflict(err)
+		}
+		return ProductDetails{}, err
+	}
+
+	s.events.Publish(webhooks.EventProductUpdated, product.Code)
+	return s.toProductDetails(product, "")
+}
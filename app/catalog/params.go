@@ -0,0 +1,221 @@
+package catalog
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/eya20/hiring_test/app/api"
+	"github.com/eya20/hiring_test/models"
+	"github.com/shopspring/decimal"
+)
+
+// Defaults for Config, used when no page sizes are configured.
+const (
+	defaultLimit = 20
+	minLimit     = 1
+	maxLimit     = 100
+
+	defaultSimilarLimit = 5
+
+	defaultRandomCount = 5
+	maxRandomCount     = 20
+
+	maxShippingQuantity = 1000
+)
+
+// ListParams holds the pagination, sorting and filtering options
+// accepted by the product listing endpoints.
+type ListParams struct {
+	FilterParams
+
+	Offset   int
+	Limit    int
+	Sort     []models.SortOrder
+	Currency string
+	Fields   []string
+	// Bare drops the {"products":[...],"total":N} envelope, see
+	// ParseListParams.
+	Bare bool
+}
+
+// FilterParams holds the product filters of the listing endpoints. Zero
+// values don't filter.
+type FilterParams struct {
+	// Categories keeps products in any of the named categories.
+	Categories []string
+	// PriceLt and PriceGte bound the product price to [PriceGte, PriceLt).
+	PriceLt  *float64
+	PriceGte *float64
+	// PriceEq keeps products priced exactly at it, compared as decimals.
+	PriceEq  *decimal.Decimal
+	Featured *bool
+	// InStock keeps products with at least one variant in stock.
+	InStock bool
+	// HasVariants keeps products with (true) or without (false) variants.
+	HasVariants *bool
+}
+
+// ParseListParams reads the listing options from the request query string.
+// Pagination is parsed by api.ParsePaginationParams: the limit is clamped to
+// [1, cfg.MaxPageSize], defaulting to cfg.DefaultPageSize, and page can be
+// used instead of offset. Filters are parsed by ParseFilterParams.
+// An empty fields list means every product field is returned. Listings are
+// enveloped unless envelope=false, which sets Bare.
+func ParseListParams(r *http.Request, cfg Config) (ListParams, error) {
+	page, err := api.ParsePaginationParams(r, api.PaginationParams{
+		Limit:    cfg.DefaultPageSize,
+		MaxLimit: cfg.MaxPageSize,
+	})
+	if err != nil {
+		return ListParams{}, err
+	}
+
+	filters, err := ParseFilterParams(r)
+	if err != nil {
+		return ListParams{}, err
+	}
+
+	q := r.URL.Query()
+	params := ListParams{
+		FilterParams: filters,
+		Offset:       page.Offset,
+		Limit:        page.Limit,
+		Currency:     strings.ToUpper(q.Get("currency")),
+	}
+
+	params.Sort, err = models.ParseProductSort(page.Sort)
+	if err != nil {
+		return ListParams{}, err
+	}
+
+	if v := q.Get("envelope"); v != "" {
+		envelope, err := strconv.ParseBool(v)
+		if err != nil {
+			return ListParams{}, fmt.Errorf("invalid envelope %q", v)
+		}
+		params.Bare = !envelope
+	}
+
+	if v := q.Get("fields"); v != "" {
+		fields, err := parseFields(v)
+		if err != nil {
+			return ListParams{}, err
+		}
+		params.Fields = fields
+	}
+
+	return params, nil
+}
+
+// ParseFilterParams reads the product filters from the request query string:
+// category (repeatable), price_lt, price_gte, price_eq, featured, in_stock
+// and has_variants. Malformed values, and price_eq combined with a price
+// range, are reported together as a *api.ValidationError.
+func ParseFilterParams(r *http.Request) (FilterParams, error) {
+	q := r.URL.Query()
+	verr := &api.ValidationError{}
+
+	var filters FilterParams
+	for _, category := range q["category"] {
+		if category != "" {
+			filters.Categories = append(filters.Categories, category)
+		}
+	}
+
+	filters.PriceLt = parsePriceParam(q, "price_lt", verr)
+	filters.PriceGte = parsePriceParam(q, "price_gte", verr)
+	if filters.PriceLt != nil && filters.PriceGte != nil && *filters.PriceGte >= *filters.PriceLt {
+		verr.Add("price_gte", "price_gte must be less than price_lt")
+	}
+	filters.PriceEq = parseExactPriceParam(q, "price_eq", verr)
+	if filters.PriceEq != nil && (filters.PriceLt != nil || filters.PriceGte != nil) {
+		verr.Add("price_eq", "price_eq can't be combined with price_lt or price_gte")
+	}
+
+	filters.Featured = parseBoolParam(q, "featured", verr)
+	if inStock := parseBoolParam(q, "in_stock", verr); inStock != nil {
+		filters.InStock = *inStock
+	}
+	filters.HasVariants = parseBoolParam(q, "has_variants", verr)
+
+	if err := verr.Err(); err != nil {
+		return FilterParams{}, err
+	}
+	return filters, nil
+}
+
+// ParamsErrorResponse responds 400 to an error of ParseListParams or
+// ParseFilterParams. Invalid filters are reported by their messages alone,
+// e.g. {"error":"price_lt must be a number"}, joined by "; " when there are
+// several.
+func ParamsErrorResponse(w http.ResponseWriter, err error) {
+	message := err.Error()
+	var verr *api.ValidationError
+	if errors.As(err, &verr) {
+		messages := make([]string, len(verr.Errors))
+		for i, f := range verr.Errors {
+			messages[i] = f.Message
+		}
+		message = strings.Join(messages, "; ")
+	}
+	api.ErrorResponse(w, http.StatusBadRequest, message)
+}
+
+// parsePriceParam returns the named query param as a price, or nil when it
+// is absent or empty. A value that isn't a finite number, which includes NaN
+// and Inf, or is negative is added to verr rather than filtering on zero.
+func parsePriceParam(q url.Values, name string, verr *api.ValidationError) *float64 {
+	v := q.Get(name)
+	if v == "" {
+		return nil
+	}
+	f, err := strconv.ParseFloat(v, 64)
+	if err != nil || math.IsNaN(f) || math.IsInf(f, 0) {
+		verr.Add(name, name+" must be a number")
+		return nil
+	}
+	if f < 0 {
+		verr.Add(name, name+" must not be negative")
+		return nil
+	}
+	return &f
+}
+
+// parseExactPriceParam is parsePriceParam for a price compared exactly, as
+// a decimal.
+func parseExactPriceParam(q url.Values, name string, verr *api.ValidationError) *decimal.Decimal {
+	v := q.Get(name)
+	if v == "" {
+		return nil
+	}
+	d, err := decimal.NewFromString(v)
+	if err != nil {
+		verr.Add(name, name+" must be a number")
+		return nil
+	}
+	if d.IsNegative() {
+		verr.Add(name, name+" must not be negative")
+		return nil
+	}
+	return &d
+}
+
+// parseBoolParam returns the named query param as a bool, or nil when it is
+// absent. A malformed value is added to verr.
+func parseBoolParam(q url.Values, name string, verr *api.ValidationError) *bool {
+	v := q.Get(name)
+	if v == "" {
+		return nil
+	}
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		verr.Add(name, fmt.Sprintf("invalid %s %q", name, v))
+		return nil
+	}
+	return &b
+}
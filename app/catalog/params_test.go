@@ -0,0 +1,217 @@
+package catalog
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/eya20/hiring_test/app/api"
+	"github.com/eya20/hiring_test/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseListParams(t *testing.T) {
+	t.Run("defaults", func(t *testing.T) {
+		params, err := ParseListParams(httptest.NewRequest(http.MethodGet, "/catalog", nil), DefaultConfig())
+
+		assert.NoError(t, err)
+		assert.Equal(t, ListParams{Offset: 0, Limit: 20}, params)
+	})
+
+	t.Run("limit is clamped", func(t *testing.T) {
+		tests := map[string]int{"0": 1, "-5": 1, "1": 1, "100": 100, "101": 100}
+		for limit, expected := range tests {
+			params, err := ParseListParams(httptest.NewRequest(http.MethodGet, "/catalog?limit="+limit, nil), DefaultConfig())
+
+			assert.NoError(t, err)
+			assert.Equal(t, expected, params.Limit, limit)
+		}
+	})
+
+	t.Run("several sort keys", func(t *testing.T) {
+		params, err := ParseListParams(httptest.NewRequest(http.MethodGet, "/catalog?sort=price,-code", nil), DefaultConfig())
+
+		assert.NoError(t, err)
+		assert.Equal(t, []models.SortOrder{{Field: "price", Dir: models.SortAsc}, {Field: "code", Dir: models.SortDesc}}, params.Sort)
+	})
+
+	t.Run("unknown sort key", func(t *testing.T) {
+		_, err := ParseListParams(httptest.NewRequest(http.MethodGet, "/catalog?sort=price,category", nil), DefaultConfig())
+
+		assert.EqualError(t, err, `invalid sort "category"`)
+	})
+
+	t.Run("name and creation date sort keys", func(t *testing.T) {
+		params, err := ParseListParams(httptest.NewRequest(http.MethodGet, "/catalog?sort=name,-created_at", nil), DefaultConfig())
+
+		assert.NoError(t, err)
+		assert.Equal(t, []models.SortOrder{{Field: "name", Dir: models.SortAsc}, {Field: "created_at", Dir: models.SortDesc}}, params.Sort)
+	})
+
+	t.Run("all params", func(t *testing.T) {
+		params, err := ParseListParams(httptest.NewRequest(http.MethodGet, "/catalog?offset=5&limit=20&sort=-price&category=Shoes&price_lt=9.5", nil), DefaultConfig())
+
+		assert.NoError(t, err)
+		assert.Equal(t, 5, params.Offset)
+		assert.Equal(t, 20, params.Limit)
+		assert.Equal(t, []models.SortOrder{{Field: "price", Dir: models.SortDesc}}, params.Sort)
+		assert.Equal(t, []string{"Shoes"}, params.Categories)
+		assert.Equal(t, 9.5, *params.PriceLt)
+	})
+
+	t.Run("page", func(t *testing.T) {
+		params, err := ParseListParams(httptest.NewRequest(http.MethodGet, "/catalog?page=3&limit=20", nil), DefaultConfig())
+
+		assert.NoError(t, err)
+		assert.Equal(t, 40, params.Offset)
+		assert.Equal(t, 20, params.Limit)
+	})
+
+	t.Run("fields", func(t *testing.T) {
+		params, err := ParseListParams(httptest.NewRequest(http.MethodGet, "/catalog?fields=code,+price,", nil), DefaultConfig())
+
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"code", "price"}, params.Fields)
+	})
+
+	t.Run("envelope", func(t *testing.T) {
+		tests := map[string]bool{"": false, "?envelope=true": false, "?envelope=false": true, "?envelope=0": true}
+		for query, bare := range tests {
+			params, err := ParseListParams(httptest.NewRequest(http.MethodGet, "/catalog"+query, nil), DefaultConfig())
+
+			assert.NoError(t, err)
+			assert.Equal(t, bare, params.Bare, query)
+		}
+
+		_, err := ParseListParams(httptest.NewRequest(http.MethodGet, "/catalog?envelope=no", nil), DefaultConfig())
+		assert.EqualError(t, err, `invalid envelope "no"`)
+	})
+
+	t.Run("unknown field", func(t *testing.T) {
+		_, err := ParseListParams(httptest.NewRequest(http.MethodGet, "/catalog?fields=code,colour", nil), DefaultConfig())
+
+		assert.EqualError(t, err, `invalid field "colour"`)
+	})
+	t.Run("configured page sizes", func(t *testing.T) {
+		cfg := Config{DefaultPageSize: 25, MaxPageSize: 50}
+
+		params, err := ParseListParams(httptest.NewRequest(http.MethodGet, "/catalog", nil), cfg)
+		assert.NoError(t, err)
+		assert.Equal(t, 25, params.Limit)
+
+		params, err = ParseListParams(httptest.NewRequest(http.MethodGet, "/catalog?limit=80", nil), cfg)
+		assert.NoError(t, err)
+		assert.Equal(t, 50, params.Limit)
+	})
+}
+
+func TestParseFilterParams(t *testing.T) {
+	t.Run("no filters", func(t *testing.T) {
+		filters, err := ParseFilterParams(httptest.NewRequest(http.MethodGet, "/catalog", nil))
+
+		assert.NoError(t, err)
+		assert.Equal(t, FilterParams{}, filters)
+	})
+
+	t.Run("all filters", func(t *testing.T) {
+		filters, err := ParseFilterParams(httptest.NewRequest(http.MethodGet, "/catalog?category=Shoes&category=&category=Clothing&price_gte=5&price_lt=9.5&featured=true&in_stock=1&has_variants=false", nil))
+
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"Shoes", "Clothing"}, filters.Categories)
+		assert.Equal(t, 5.0, *filters.PriceGte)
+		assert.Equal(t, 9.5, *filters.PriceLt)
+		assert.True(t, *filters.Featured)
+		assert.True(t, filters.InStock)
+		assert.False(t, *filters.HasVariants)
+	})
+
+	t.Run("reports every invalid filter", func(t *testing.T) {
+		_, err := ParseFilterParams(httptest.NewRequest(http.MethodGet, "/catalog?price_lt=abc&featured=maybe&has_variants=none", nil))
+
+		var verr *api.ValidationError
+		assert.ErrorAs(t, err, &verr)
+		assert.Equal(t, []api.FieldError{
+			{Field: "price_lt", Message: "price_lt must be a number"},
+			{Field: "featured", Message: `invalid featured "maybe"`},
+			{Field: "has_variants", Message: `invalid has_variants "none"`},
+		}, verr.Errors)
+	})
+
+	t.Run("empty price range", func(t *testing.T) {
+		_, err := ParseFilterParams(httptest.NewRequest(http.MethodGet, "/catalog?price_gte=10&price_lt=10", nil))
+
+		assert.ErrorContains(t, err, "price_gte must be less than price_lt")
+	})
+
+	t.Run("exact price", func(t *testing.T) {
+		filters, err := ParseFilterParams(httptest.NewRequest(http.MethodGet, "/catalog?price_eq=12.490", nil))
+
+		assert.NoError(t, err)
+		assert.Equal(t, "12.49", filters.PriceEq.String())
+	})
+
+	t.Run("exact price with a price range", func(t *testing.T) {
+		for _, query := range []string{"price_eq=10&price_lt=20", "price_eq=10&price_gte=5"} {
+			_, err := ParseFilterParams(httptest.NewRequest(http.MethodGet, "/catalog?"+query, nil))
+
+			var verr *api.ValidationError
+			assert.ErrorAs(t, err, &verr, query)
+			assert.Equal(t, []api.FieldError{
+				{Field: "price_eq", Message: "price_eq can't be combined with price_lt or price_gte"},
+			}, verr.Errors, query)
+		}
+	})
+
+	t.Run("malformed exact price", func(t *testing.T) {
+		_, err := ParseFilterParams(httptest.NewRequest(http.MethodGet, "/catalog?price_eq=1e", nil))
+
+		assert.ErrorContains(t, err, "price_eq must be a number")
+	})
+
+	t.Run("invalid prices", func(t *testing.T) {
+		tests := []struct {
+			query   string
+			field   string
+			message string
+		}{
+			{query: "price_lt=abc", field: "price_lt", message: "price_lt must be a number"},
+			{query: "price_lt=NaN", field: "price_lt", message: "price_lt must be a number"},
+			{query: "price_gte=Inf", field: "price_gte", message: "price_gte must be a number"},
+			{query: "price_gte=1,5", field: "price_gte", message: "price_gte must be a number"},
+			{query: "price_lt=-1", field: "price_lt", message: "price_lt must not be negative"},
+			{query: "price_gte=-0.5", field: "price_gte", message: "price_gte must not be negative"},
+			{query: "price_eq=-2", field: "price_eq", message: "price_eq must not be negative"},
+		}
+
+		for _, tt := range tests {
+			_, err := ParseFilterParams(httptest.NewRequest(http.MethodGet, "/catalog?"+tt.query, nil))
+
+			var verr *api.ValidationError
+			if assert.ErrorAs(t, err, &verr, tt.query) {
+				assert.Equal(t, []api.FieldError{{Field: tt.field, Message: tt.message}}, verr.Errors, tt.query)
+			}
+		}
+	})
+
+	t.Run("empty prices are no filter", func(t *testing.T) {
+		filters, err := ParseFilterParams(httptest.NewRequest(http.MethodGet, "/catalog?price_lt=&price_gte=&price_eq=", nil))
+
+		assert.NoError(t, err)
+		assert.Equal(t, FilterParams{}, filters)
+	})
+
+	t.Run("zero price", func(t *testing.T) {
+		filters, err := ParseFilterParams(httptest.NewRequest(http.MethodGet, "/catalog?price_gte=0", nil))
+
+		assert.NoError(t, err)
+		assert.Equal(t, 0.0, *filters.PriceGte)
+	})
+}
+
+func TestConfigValidate(t *testing.T) {
+	assert.NoError(t, DefaultConfig().Validate())
+	assert.NoError(t, Config{DefaultPageSize: 20, MaxPageSize: 20}.Validate())
+	assert.Error(t, Config{DefaultPageSize: 0, MaxPageSize: 20}.Validate())
+	assert.EqualError(t, Config{DefaultPageSize: 50, MaxPageSize: 20}.Validate(), "max page size 20 is smaller than the default page size 50")
+	assert.EqualError(t, Config{DefaultPageSize: 10, MaxPageSize: 20, CacheSeconds: -1}.Validate(), "cache seconds must not be negative, got -1")
+}
@@ -0,0 +1,370 @@
+package catalog
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/eya20/hiring_test/app/api"
+	"github.com/eya20/hiring_test/app/tracing"
+	"github.com/eya20/hiring_test/app/webhooks"
+	"github.com/eya20/hiring_test/models"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+// CreateProductWithVariants creates a product and all of its variants in one
+// transaction: either everything is stored or, on any failure, nothing is.
+func (s *CatalogService) CreateProductWithVariants(ctx context.Context, req CreateProductRequest) (ProductDetails, error) {
+	ctx, span := tracing.Start(ctx, "CatalogService.CreateProductWithVariants")
+	defer span.End()
+
+	if err := api.ValidateStruct(req); err != nil {
+		return ProductDetails{}, err
+	}
+	if s.maxVariants > 0 && len(req.Variants) > s.maxVariants {
+		return ProductDetails{}, fmt.Errorf("%w: a product can have at most %d variants", api.ErrLimitExceeded, s.maxVariants)
+	}
+
+	currency := strings.ToUpper(req.Currency)
+	if currency == "" {
+		currency = BaseCurrency
+	}
+	if err := s.validateProductCurrency(currency); err != nil {
+		return ProductDetails{}, err
+	}
+
+	product := models.Product{
+		Code:     req.Code,
+		Name:     req.Name,
+		SKU:      req.SKU,
+		Price:    decimal.NewFromFloat(req.Price),
+		Currency: currency,
+	}
+
+	err := s.withTransaction(ctx, func(repos models.TxRepositories) error {
+		if req.Category != "" {
+			if err := repos.Categories.GetCategoryByCode(ctx, req.Category, &product.Category); err != nil {
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					verr := &api.ValidationError{}
+					verr.Add("category", "unknown category "+req.Category)
+					return verr
+				}
+				return err
+			}
+			product.CategoryID = &product.Category.ID
+		}
+
+		if err := repos.Products.CreateProduct(ctx, &product); err != nil {
+			return productConflict(err, product)
+		}
+
+		for _, v := range req.Variants {
+			variant := models.Variant{
+				ProductID: product.ID,
+				Name:      v.Name,
+				SKU:       v.SKU,
+				Image:     v.Image,
+			}
+			s.setVariantPrice(&variant, product, v.Price)
+			if err := repos.Products.CreateVariant(ctx, &variant); err != nil {
+				return skuConflict(err)
+			}
+			product.Variants = append(product.Variants, variant)
+		}
+		return nil
+	})
+	if err != nil {
+		return ProductDetails{}, err
+	}
+
+	s.events.Publish(webhooks.EventProductCreated, product.Code)
+	return s.toProductDetails(product, "")
+}
+
+// productConflict maps a unique violation inserting product to a conflict:
+// its code or its SKU is taken.
+func productConflict(err error, product models.Product) error {
+	if !models.IsDuplicateKey(err) {
+		return err
+	}
+	if product.SKU == "" {
+		return api.Conflict(fmt.Errorf("product %s already exists", product.Code))
+	}
+	return api.Conflict(fmt.Errorf("product %s or SKU %s already exists", product.Code, product.SKU))
+}
+
+// UpdateProduct applies the fields set in req to the product identified by
+// code and returns the updated product. Fields missing from req keep their
+// current value.
+//
+// The update only applies to the product at req.Version, and increments its
+// version; a product updated since is a models.ErrVersionConflict. A price
+// change is recorded as a PriceChangeEvent made by changedBy, in the same
+// transaction as the update.
+func (s *CatalogService) UpdateProduct(ctx context.Context, code string, req UpdateProductRequest, changedBy string) (ProductDetails, error) {
+	ctx, span := tracing.Start(ctx, "CatalogService.UpdateProduct")
+	defer span.End()
+
+	if err := api.ValidateStruct(req); err != nil {
+		return ProductDetails{}, err
+	}
+
+	updates := map[string]any{}
+	if req.Name != nil {
+		updates["name"] = *req.Name
+	}
+	if req.SKU != nil {
+		updates["sku"] = nullIfEmpty(*req.SKU)
+	}
+	if req.Price != nil {
+		updates["price"] = decimal.NewFromFloat(*req.Price)
+	}
+	if req.Currency != nil {
+		currency := strings.ToUpper(*req.Currency)
+		if err := s.validateProductCurrency(currency); err != nil {
+			return ProductDetails{}, err
+		}
+		updates["currency"] = currency
+	}
+	if req.Featured != nil {
+		updates["featured"] = *req.Featured
+	}
+
+	var product models.Product
+	err := s.withTransaction(ctx, func(repos models.TxRepositories) error {
+		var before models.Product
+		if req.Price != nil {
+			if err := repos.Products.GetProductByCode(ctx, code, &before); err != nil {
+				return err
+			}
+		}
+
+		if req.Category != nil {
+			updates["category_id"] = nil
+			if *req.Category != "" {
+				var category models.Category
+				if err := repos.Categories.GetCategoryByCode(ctx, *req.Category, &category); err != nil {
+					if errors.Is(err, gorm.ErrRecordNotFound) {
+						verr := &api.ValidationError{}
+						verr.Add("category", "unknown category "+*req.Category)
+						return verr
+					}
+					return err
+				}
+				updates["category_id"] = category.ID
+			}
+		}
+
+		if err := repos.Products.UpdateProduct(ctx, code, *req.Version, updates); err != nil {
+			return skuConflict(err)
+		}
+		if err := repos.Products.GetProductByCode(ctx, code, &product); err != nil {
+			return err
+		}
+
+		if req.Price != nil && !before.Price.Equal(product.Price) {
+			return repos.Products.CreatePriceChangeEvent(ctx, &models.PriceChangeEvent{
+				ProductCode: product.Code,
+				OldPrice:    before.Price,
+				NewPrice:    product.Price,
+				ChangedAt:   time.Now().UTC(),
+				ChangedBy:   changedBy,
+			})
+		}
+		return nil
+	})
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ProductDetails{}, fmt.Errorf("%w: product with code %s", api.ErrNotFound, code)
+		}
+		if errors.Is(err, models.ErrVersionConflict) {
+			return ProductDetails{}, api.Conflict(err)
+		}
+		return ProductDetails{}, err
+	}
+
+	s.events.Publish(webhooks.EventProductUpdated, product.Code)
+	return s.toProductDetails(product, "")
+}
+
+// SetProductCategory moves the product identified by code to the category
+// req.CategoryCode. Unknown product and category codes are both not found
+// errors.
+func (s *CatalogService) SetProductCategory(ctx context.Context, code string, req CategoryRequest) (ProductDetails, error) {
+	ctx, span := tracing.Start(ctx, "CatalogService.SetProductCategory")
+	defer span.End()
+
+	if err := api.ValidateStruct(req); err != nil {
+		return ProductDetails{}, err
+	}
+
+	var product models.Product
+	err := s.withTransaction(ctx, func(repos models.TxRepositories) error {
+		if err := repos.Products.GetProductByCode(ctx, code, &product); err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return fmt.Errorf("%w: product with code %s", api.ErrNotFound, code)
+			}
+			return err
+		}
+
+		var category models.Category
+		if err := repos.Categories.GetCategoryByCode(ctx, req.CategoryCode, &category); err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return fmt.Errorf("%w: category with code %s", api.ErrNotFound, req.CategoryCode)
+			}
+			return err
+		}
+
+		if err := repos.Products.SetProductCategory(ctx, product.Code, category.ID); err != nil {
+			return err
+		}
+		product.CategoryID, product.Category = &category.ID, category
+		return nil
+	})
+	if err != nil {
+		return ProductDetails{}, err
+	}
+
+	s.events.Publish(webhooks.EventProductUpdated, product.Code)
+	return s.toProductDetails(product, "")
+}
+
+// AdjustCategoryPrices multiplies the price of every product directly in the
+// category req.CategoryCode by 1 + req.Percent/100, rounded to cents, and
+// records each change as done by changedBy. Either every price changes or,
+// when one would drop to zero or below, none does.
+func (s *CatalogService) AdjustCategoryPrices(ctx context.Context, req PriceAdjustRequest, changedBy string) (PriceAdjustment, error) {
+	ctx, span := tracing.Start(ctx, "CatalogService.AdjustCategoryPrices")
+	defer span.End()
+
+	if err := api.ValidateStruct(req); err != nil {
+		return PriceAdjustment{}, err
+	}
+	percent := decimal.NewFromFloat(*req.Percent)
+	if percent.LessThanOrEqual(decimal.NewFromInt(-100)) {
+		verr := &api.ValidationError{}
+		verr.Add("percent", "percent must be greater than -100")
+		return PriceAdjustment{}, verr
+	}
+	factor := decimal.NewFromInt(1).Add(percent.Div(decimal.NewFromInt(100)))
+
+	var updated []string
+	err := s.withTransaction(ctx, func(repos models.TxRepositories) error {
+		var category models.Category
+		if err := repos.Categories.GetCategoryByCode(ctx, req.CategoryCode, &category); err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return fmt.Errorf("%w: category with code %s", api.ErrNotFound, req.CategoryCode)
+			}
+			return err
+		}
+
+		products, err := repos.Products.LockCategoryProducts(ctx, category.ID)
+		if err != nil {
+			return err
+		}
+
+		now := time.Now().UTC()
+		for _, p := range products {
+			price := p.Price.Mul(factor).Round(2)
+			if !price.IsPositive() {
+				verr := &api.ValidationError{}
+				verr.Add("percent", fmt.Sprintf("would reduce the price of %s to %s", p.Code, price.StringFixed(2)))
+				return verr
+			}
+			if price.Equal(p.Price) {
+				continue
+			}
+
+			if err := repos.Products.UpdateProduct(ctx, p.Code, p.Version, map[string]any{"price": price}); err != nil {
+				return err
+			}
+			err := repos.Products.CreatePriceChangeEvent(ctx, &models.PriceChangeEvent{
+				ProductCode: p.Code,
+				OldPrice:    p.Price,
+				NewPrice:    price,
+				ChangedAt:   now,
+				ChangedBy:   changedBy,
+			})
+			if err != nil {
+				return err
+			}
+			updated = append(updated, p.Code)
+		}
+		return nil
+	})
+	if err != nil {
+		return PriceAdjustment{}, err
+	}
+
+	for _, code := range updated {
+		s.events.Publish(webhooks.EventProductUpdated, code)
+	}
+	return PriceAdjustment{
+		CategoryCode: req.CategoryCode,
+		Percent:      *req.Percent,
+		Updated:      len(updated),
+	}, nil
+}
+
+// GetPriceHistory returns the price changes of the product identified by
+// code, most recent first.
+func (s *CatalogService) GetPriceHistory(ctx context.Context, code string) (PriceHistory, error) {
+	ctx, span := tracing.Start(ctx, "CatalogService.GetPriceHistory")
+	defer span.End()
+
+	product, err := s.getProduct(ctx, code)
+	if err != nil {
+		return PriceHistory{}, err
+	}
+
+	events, err := s.repo.GetPriceHistory(ctx, product.Code)
+	if err != nil {
+		return PriceHistory{}, err
+	}
+
+	changes := make([]PriceChange, len(events))
+	for i, e := range events {
+		changes[i] = PriceChange{
+			OldPrice:  s.rounding.Float(e.OldPrice),
+			NewPrice:  s.rounding.Float(e.NewPrice),
+			ChangedAt: e.ChangedAt,
+			ChangedBy: e.ChangedBy,
+		}
+	}
+
+	return PriceHistory{
+		Code:    product.Code,
+		Changes: changes,
+	}, nil
+}
+
+// nullIfEmpty maps an empty string to a NULL column value.
+func nullIfEmpty(s string) any {
+	if s == "" {
+		return nil
+	}
+	return s
+}
+
+// withTransaction runs fn through the configured transactor.
+func (s *CatalogService) withTransaction(ctx context.Context, fn func(models.TxRepositories) error) error {
+	if s.tx == nil {
+		return errors.New("catalog: no transactor configured")
+	}
+	return s.tx.WithTransaction(ctx, fn)
+}
+
+// validateProductCurrency returns a validation error on the currency field
+// unless currency is an ISO 4217 code prices can be converted from.
+func (s *CatalogService) validateProductCurrency(currency string) error {
+	verr := &api.ValidationError{}
+	if err := validateCurrency(currency); err != nil {
+		verr.Add("currency", err.Error())
+	} else if !s.SupportsCurrency(currency) {
+		verr.Add("currency", "unsupported currency "+currency)
+	}
+	return verr.Err()
+}
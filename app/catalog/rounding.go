@@ -0,0 +1,67 @@
+package catalog
+
+import (
+	"fmt"
+
+	"github.com/shopspring/decimal"
+)
+
+// RoundingMode selects how displayed prices are rounded.
+type RoundingMode string
+
+const (
+	// RoundHalfUp rounds halves away from zero: 1.005 becomes 1.01.
+	RoundHalfUp RoundingMode = "half_up"
+	// RoundHalfEven rounds halves to the nearest even digit: 1.005 becomes 1.00.
+	RoundHalfEven RoundingMode = "half_even"
+	// RoundFloor rounds towards negative infinity: 1.009 becomes 1.00.
+	RoundFloor RoundingMode = "floor"
+)
+
+// Rounding is applied to every price before it is returned by the API.
+type Rounding struct {
+	Mode RoundingMode
+	// Places is the number of decimal places kept.
+	Places int32
+}
+
+// DefaultRounding rounds half up to cents.
+func DefaultRounding() Rounding {
+	return Rounding{Mode: RoundHalfUp, Places: 2}
+}
+
+// ParseRounding builds a Rounding from its configured mode and places. An
+// empty mode falls back to RoundHalfUp.
+func ParseRounding(mode string, places int) (Rounding, error) {
+	r := Rounding{Mode: RoundingMode(mode), Places: int32(places)}
+	if r.Mode == "" {
+		r.Mode = RoundHalfUp
+	}
+
+	switch r.Mode {
+	case RoundHalfUp, RoundHalfEven, RoundFloor:
+	default:
+		return Rounding{}, fmt.Errorf("unknown rounding mode %q, want %s, %s or %s", mode, RoundHalfUp, RoundHalfEven, RoundFloor)
+	}
+	if places < 0 || places > 8 {
+		return Rounding{}, fmt.Errorf("rounding places must be between 0 and 8, got %d", places)
+	}
+	return r, nil
+}
+
+// Apply rounds d according to r.
+func (r Rounding) Apply(d decimal.Decimal) decimal.Decimal {
+	switch r.Mode {
+	case RoundHalfEven:
+		return d.RoundBank(r.Places)
+	case RoundFloor:
+		return d.RoundFloor(r.Places)
+	default:
+		return d.Round(r.Places)
+	}
+}
+
+// Float rounds d according to r and returns it as a float64 for output.
+func (r Rounding) Float(d decimal.Decimal) float64 {
+	return r.Apply(d).InexactFloat64()
+}
@@ -0,0 +1,47 @@
+package catalog
+
+import (
+	"testing"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseRounding(t *testing.T) {
+	t.Run("defaults to half up", func(t *testing.T) {
+		r, err := ParseRounding("", 2)
+
+		assert.NoError(t, err)
+		assert.Equal(t, Rounding{Mode: RoundHalfUp, Places: 2}, r)
+	})
+
+	t.Run("rejects invalid settings", func(t *testing.T) {
+		_, err := ParseRounding("ceil", 2)
+		assert.Error(t, err)
+
+		_, err = ParseRounding("floor", -1)
+		assert.Error(t, err)
+	})
+}
+
+func TestRoundingApply(t *testing.T) {
+	tests := []struct {
+		mode     RoundingMode
+		places   int32
+		amount   string
+		expected string
+	}{
+		{RoundHalfUp, 2, "1.005", "1.01"},
+		{RoundHalfUp, 2, "-1.005", "-1.01"},
+		{RoundHalfEven, 2, "1.005", "1.00"},
+		{RoundHalfEven, 2, "1.015", "1.02"},
+		{RoundFloor, 2, "1.009", "1.00"},
+		{RoundFloor, 2, "-1.001", "-1.01"},
+		{RoundHalfUp, 0, "2.5", "3"},
+	}
+
+	for _, tt := range tests {
+		got := Rounding{Mode: tt.mode, Places: tt.places}.Apply(decimal.RequireFromString(tt.amount))
+		assert.True(t, decimal.RequireFromString(tt.expected).Equal(got), "%s %d %s: got %s", tt.mode, tt.places, tt.amount, got)
+	}
+}
@@ -0,0 +1,465 @@
+package catalog
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/eya20/hiring_test/app/api"
+	"github.com/eya20/hiring_test/app/tracing"
+	"github.com/eya20/hiring_test/app/webhooks"
+	"github.com/eya20/hiring_test/models"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+type CatalogService struct {
+	repo  models.ProductsRepositoryInterface
+	rates ExchangeRates
+
+	// maxPriceDeviation is the percentage a variant price may differ from its
+	// product price before a warning is logged. Zero disables the check.
+	maxPriceDeviation decimal.Decimal
+
+	// tx runs multi-step writes atomically. Writes that need it fail
+	// when it isn't configured.
+	tx models.TransactorInterface
+
+	// events is notified of every successful product write.
+	events webhooks.Publisher
+
+	// rounding is applied to every price returned.
+	rounding Rounding
+
+	// shipping prices shipping estimates.
+	shipping ShippingCalculator
+
+	// maxVariants caps the number of variants of a product. Zero means no
+	// limit.
+	maxVariants int
+}
+
+// DefaultMaxVariants is the number of variants a product may have unless
+// configured with WithMaxVariants.
+const DefaultMaxVariants = 500
+
+// Option configures optional CatalogService behaviour.
+type Option func(*CatalogService)
+
+// WithPriceDeviationWarning logs a warning when a written variant price deviates
+// from its product price by more than percent.
+func WithPriceDeviationWarning(percent float64) Option {
+	return func(s *CatalogService) {
+		s.maxPriceDeviation = decimal.NewFromFloat(percent)
+	}
+}
+
+// WithTransactor makes multi-step writes such as CreateProductWithVariants
+// run in a single database transaction.
+func WithTransactor(tx models.TransactorInterface) Option {
+	return func(s *CatalogService) {
+		s.tx = tx
+	}
+}
+
+// WithPublisher notifies p of every product created or updated.
+func WithPublisher(p webhooks.Publisher) Option {
+	return func(s *CatalogService) {
+		s.events = p
+	}
+}
+
+// WithPriceRounding sets how prices are rounded for output, half up to
+// cents by default.
+func WithPriceRounding(r Rounding) Option {
+	return func(s *CatalogService) {
+		s.rounding = r
+	}
+}
+
+// WithShippingCalculator prices shipping estimates with c. Without it,
+// shipping is free.
+func WithShippingCalculator(c ShippingCalculator) Option {
+	return func(s *CatalogService) {
+		s.shipping = c
+	}
+}
+
+// WithMaxVariants caps the number of variants of a product at n. Adding
+// variants beyond it fails with api.ErrLimitExceeded. Zero disables the limit.
+func WithMaxVariants(n int) Option {
+	return func(s *CatalogService) {
+		s.maxVariants = n
+	}
+}
+
+func NewCatalogService(r models.ProductsRepositoryInterface, rates ExchangeRates, opts ...Option) *CatalogService {
+	s := &CatalogService{
+		repo:        r,
+		rates:       rates,
+		events:      webhooks.Discard,
+		rounding:    DefaultRounding(),
+		shipping:    WeightBasedCalculator{},
+		maxVariants: DefaultMaxVariants,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// SupportsCurrency reports whether prices can be displayed in currency.
+func (s *CatalogService) SupportsCurrency(currency string) bool {
+	return s.rates.Supports(currency)
+}
+
+// GetProductsPaginatedWithFilters returns a page of products matching filters.
+// Prices are converted to currency, or kept in each product's own currency when empty.
+func (s *CatalogService) GetProductsPaginatedWithFilters(ctx context.Context, offset, limit int, filters FilterParams, sort []models.SortOrder, currency string) (Response, error) {
+	ctx, span := tracing.Start(ctx, "CatalogService.GetProductsPaginatedWithFilters")
+	defer span.End()
+
+	q := productQuery(filters)
+	q.Offset, q.Limit, q.Sort = offset, limit, sort
+
+	res, total, err := s.repo.GetProducts(ctx, q)
+	if err != nil {
+		return Response{}, err
+	}
+
+	products, err := s.toProducts(res, currency)
+	if err != nil {
+		return Response{}, err
+	}
+
+	return Response{
+		Products: products,
+		Total:    total,
+	}, nil
+}
+
+// CountProducts returns the number of products matching filters, without
+// loading them.
+func (s *CatalogService) CountProducts(ctx context.Context, filters FilterParams) (int64, error) {
+	ctx, span := tracing.Start(ctx, "CatalogService.CountProducts")
+	defer span.End()
+
+	return s.repo.CountProducts(ctx, productQuery(filters))
+}
+
+// streamBatchSize is the number of products StreamProducts loads at a time.
+const streamBatchSize = 100
+
+// StreamProducts calls fn with every product and its variants, in id order,
+// with prices converted to currency, or kept in each product's own currency
+// when empty. The products are loaded in batches, so the whole catalog is
+// never held in memory.
+func (s *CatalogService) StreamProducts(ctx context.Context, currency string, fn func(ProductDetails) error) error {
+	ctx, span := tracing.Start(ctx, "CatalogService.StreamProducts")
+	defer span.End()
+
+	return s.repo.EachProduct(ctx, streamBatchSize, func(p models.Product) error {
+		details, err := s.toProductDetails(p, currency)
+		if err != nil {
+			return err
+		}
+		return fn(details)
+	})
+}
+
+// productQuery returns the query selecting the products matching filters.
+func productQuery(filters FilterParams) models.ProductQuery {
+	q := models.NewProductQuery()
+	q.Categories = filters.Categories
+	q.PriceLt, q.PriceGte = filters.PriceLt, filters.PriceGte
+	q.PriceEq = filters.PriceEq
+	q.Featured = filters.Featured
+	q.InStock = filters.InStock
+	q.HasVariants = filters.HasVariants
+	return q
+}
+
+func (s *CatalogService) GetFeaturedProducts(ctx context.Context, currency string) (Response, error) {
+	ctx, span := tracing.Start(ctx, "CatalogService.GetFeaturedProducts")
+	defer span.End()
+
+	res, err := s.repo.GetFeaturedProducts(ctx)
+	if err != nil {
+		return Response{}, err
+	}
+
+	products, err := s.toProducts(res, currency)
+	if err != nil {
+		return Response{}, err
+	}
+
+	return Response{
+		Products: products,
+		Total:    int64(len(products)),
+	}, nil
+}
+
+func (s *CatalogService) SetProductFeatured(ctx context.Context, code string, featured bool) error {
+	ctx, span := tracing.Start(ctx, "CatalogService.SetProductFeatured")
+	defer span.End()
+
+	if err := s.repo.SetProductFeatured(ctx, code, featured); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return fmt.Errorf("%w: product with code %s", api.ErrNotFound, code)
+		}
+		return err
+	}
+
+	s.events.Publish(webhooks.EventProductUpdated, code)
+	return nil
+}
+
+func (s *CatalogService) GetProductByCode(ctx context.Context, code, currency string) (ProductDetails, error) {
+	ctx, span := tracing.Start(ctx, "CatalogService.GetProductByCode")
+	defer span.End()
+
+	var product models.Product
+	if err := s.repo.GetProductByCode(ctx, code, &product); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ProductDetails{}, fmt.Errorf("%w: product with code %s", api.ErrNotFound, code)
+		}
+		return ProductDetails{}, err
+	}
+	return s.toProductDetails(product, currency)
+}
+
+// GetSimilarProducts returns up to limit products from the same category as code,
+// never including the product itself, priced in currency when set.
+func (s *CatalogService) GetSimilarProducts(ctx context.Context, code string, limit int, currency string) ([]Product, error) {
+	ctx, span := tracing.Start(ctx, "CatalogService.GetSimilarProducts")
+	defer span.End()
+
+	res, err := s.repo.GetSimilarProducts(ctx, code, limit)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("%w: product with code %s", api.ErrNotFound, code)
+		}
+		return nil, err
+	}
+	return s.toProducts(res, currency)
+}
+
+// GetRelatedProducts returns up to limit other products from the category of
+// the product identified by code, in id order, as filtering the catalog by
+// that category does, priced in currency when set. A product without a
+// category has none.
+func (s *CatalogService) GetRelatedProducts(ctx context.Context, code string, limit int, currency string) ([]Product, error) {
+	ctx, span := tracing.Start(ctx, "CatalogService.GetRelatedProducts")
+	defer span.End()
+
+	var product models.Product
+	if err := s.repo.GetProductByCode(ctx, code, &product); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("%w: product with code %s", api.ErrNotFound, code)
+		}
+		return nil, err
+	}
+	if product.Category.Name == "" {
+		return []Product{}, nil
+	}
+
+	// Fetch one more, in case the product itself is among them.
+	q := models.NewProductQuery()
+	q.Categories = []string{product.Category.Name}
+	q.Limit = limit + 1
+	res, _, err := s.repo.GetProducts(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+	res = slices.DeleteFunc(res, func(p models.Product) bool { return p.Code == product.Code })
+	return s.toProducts(res[:min(len(res), limit)], currency)
+}
+
+// GetRandomProducts returns up to count random products, optionally within a category.
+func (s *CatalogService) GetRandomProducts(ctx context.Context, count int, category, currency string) (Response, error) {
+	ctx, span := tracing.Start(ctx, "CatalogService.GetRandomProducts")
+	defer span.End()
+
+	res, err := s.repo.GetRandomProducts(ctx, count, category)
+	if err != nil {
+		return Response{}, err
+	}
+
+	products, err := s.toProducts(res, currency)
+	if err != nil {
+		return Response{}, err
+	}
+
+	return Response{
+		Products: products,
+		Total:    int64(len(products)),
+	}, nil
+}
+
+// GetStats returns the number of products and their average price in the base
+// currency. An empty catalog has a zero count and a "0.00" average.
+func (s *CatalogService) GetStats(ctx context.Context) (Stats, error) {
+	ctx, span := tracing.Start(ctx, "CatalogService.GetStats")
+	defer span.End()
+
+	totals, err := s.repo.GetPriceTotals(ctx)
+	if err != nil {
+		return Stats{}, err
+	}
+
+	var count int64
+	sum := decimal.Zero
+	for _, t := range totals {
+		converted, err := s.rates.Convert(t.Sum, t.Currency, BaseCurrency)
+		if err != nil {
+			return Stats{}, err
+		}
+		count += t.Count
+		sum = sum.Add(converted)
+	}
+
+	average := decimal.Zero
+	if count > 0 {
+		average = sum.Div(decimal.NewFromInt(count))
+	}
+
+	return Stats{
+		Count:        count,
+		AveragePrice: average.StringFixed(2),
+		Currency:     BaseCurrency,
+	}, nil
+}
+
+func (s *CatalogService) GetProductBySKU(ctx context.Context, sku, currency string) (ProductDetails, error) {
+	ctx, span := tracing.Start(ctx, "CatalogService.GetProductBySKU")
+	defer span.End()
+
+	var product models.Product
+	if err := s.repo.GetProductBySKU(ctx, sku, &product); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ProductDetails{}, fmt.Errorf("%w: product with sku %s", api.ErrNotFound, sku)
+		}
+		return ProductDetails{}, err
+	}
+	return s.toProductDetails(product, currency)
+}
+
+func (s *CatalogService) toProducts(res []models.Product, currency string) ([]Product, error) {
+	products := make([]Product, len(res))
+	for i, p := range res {
+		product, err := s.toProduct(p, currency)
+		if err != nil {
+			return nil, err
+		}
+		products[i] = product
+	}
+	return products, nil
+}
+
+func (s *CatalogService) toProduct(p models.Product, currency string) (Product, error) {
+	currency = displayCurrency(p, currency)
+	price, err := s.rates.Convert(p.Price, p.Currency, currency)
+	if err != nil {
+		return Product{}, err
+	}
+
+	return Product{
+		Code:     p.Code,
+		Name:     p.Name,
+		SKU:      p.SKU,
+		Price:    s.rounding.Float(price),
+		Currency: currency,
+		Category: p.Category.Name,
+	}, nil
+}
+
+func (s *CatalogService) toProductDetails(p models.Product, currency string) (ProductDetails, error) {
+	currency = displayCurrency(p, currency)
+	price, err := s.rates.Convert(p.Price, p.Currency, currency)
+	if err != nil {
+		return ProductDetails{}, err
+	}
+
+	// Not nil even without variants, see ProductDetails.
+	variants := make([]Variant, len(p.Variants))
+	for i, v := range p.Variants {
+		variant, err := s.toVariant(v, p, currency)
+		if err != nil {
+			return ProductDetails{}, err
+		}
+		variants[i] = variant
+	}
+
+	return ProductDetails{
+		Code:     p.Code,
+		Name:     p.Name,
+		SKU:      p.SKU,
+		Price:    s.rounding.Float(price),
+		Currency: currency,
+		Category: p.Category.Name,
+		Featured: p.Featured,
+		Version:  p.Version,
+		Variants: variants,
+		Shipping: toShipping(p),
+		Images:   p.Images,
+
+		CategoryCode: p.Category.Code,
+		CategoryName: p.Category.Name,
+	}, nil
+}
+
+func (s *CatalogService) toVariant(v models.Variant, p models.Product, currency string) (Variant, error) {
+	price, err := s.rates.Convert(variantPrice(v, p), p.Currency, currency)
+	if err != nil {
+		return Variant{}, err
+	}
+
+	variant := Variant{
+		Name:  v.Name,
+		SKU:   v.SKU,
+		Price: s.rounding.Float(price),
+		Image: v.Image,
+	}
+
+	if v.SalePrice != nil {
+		salePrice, err := s.rates.Convert(*v.SalePrice, p.Currency, currency)
+		if err != nil {
+			return Variant{}, err
+		}
+
+		sale := s.rounding.Float(salePrice)
+		discount := discountPercent(variantPrice(v, p), *v.SalePrice).InexactFloat64()
+		variant.SalePrice = &sale
+		variant.DiscountPercent = &discount
+	}
+
+	return variant, nil
+}
+
+// discountPercent returns how much cheaper salePrice is than price, as a percentage
+// rounded to two decimals.
+func discountPercent(price, salePrice decimal.Decimal) decimal.Decimal {
+	if price.IsZero() {
+		return decimal.Zero
+	}
+	return price.Sub(salePrice).Mul(decimal.NewFromInt(100)).Div(price).Round(2)
+}
+
+// displayCurrency returns the currency prices are shown in: the requested
+// one if any, the product's own currency otherwise.
+func displayCurrency(p models.Product, currency string) string {
+	if currency == "" {
+		return p.Currency
+	}
+	return currency
+}
+
+// variantPrice returns the variant's own price, falling back to the
+// product price when the variant doesn't define one.
+func variantPrice(v models.Variant, p models.Product) decimal.Decimal {
+	if v.Price.IsZero() {
+		return p.Price
+	}
+	return v.Price
+}
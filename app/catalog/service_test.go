@@ -0,0 +1,208 @@
+package catalog
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/eya20/hiring_test/models"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiscountPercent(t *testing.T) {
+	tests := []struct {
+		price, salePrice string
+		expected         string
+	}{
+		{"100", "75", "25"},
+		{"11.99", "9.99", "16.68"},
+		{"10", "10", "0"},
+		{"0", "0", "0"},
+	}
+
+	for _, tt := range tests {
+		got := discountPercent(decimal.RequireFromString(tt.price), decimal.RequireFromString(tt.salePrice))
+		assert.True(t, decimal.RequireFromString(tt.expected).Equal(got), "%s -> %s: got %s", tt.price, tt.salePrice, got)
+	}
+}
+
+func TestCatalogService_GetProductByCode_SalePrice(t *testing.T) {
+	salePrice := decimal.RequireFromString("9.99")
+	repo := &mockProductsRepository{products: []models.Product{
+		{
+			Code:     "PROD001",
+			Price:    decimal.RequireFromString("10.99"),
+			Currency: "USD",
+			Variants: []models.Variant{
+				{Name: "Variant A", SKU: "SKU001A", Price: decimal.RequireFromString("11.99"), SalePrice: &salePrice},
+				{Name: "Variant B", SKU: "SKU001B", SalePrice: &salePrice},
+				{Name: "Variant C", SKU: "SKU001C"},
+			},
+		},
+	}}
+	service := NewCatalogService(repo, testRates())
+
+	product, err := service.GetProductByCode(context.Background(), "PROD001", "")
+	assert.NoError(t, err)
+
+	t.Run("computes discount against the variant price", func(t *testing.T) {
+		assert.Equal(t, 9.99, *product.Variants[0].SalePrice)
+		assert.Equal(t, 16.68, *product.Variants[0].DiscountPercent)
+	})
+
+	t.Run("computes discount against the inherited product price", func(t *testing.T) {
+		assert.Equal(t, 9.99, *product.Variants[1].SalePrice)
+		assert.Equal(t, 9.1, *product.Variants[1].DiscountPercent)
+	})
+
+	t.Run("nil sale price means no sale", func(t *testing.T) {
+		assert.Nil(t, product.Variants[2].SalePrice)
+		assert.Nil(t, product.Variants[2].DiscountPercent)
+	})
+}
+
+func TestCatalogService_ProductWithoutVariants(t *testing.T) {
+	repo := &mockProductsRepository{products: []models.Product{
+		{Code: "PROD001", Price: decimal.RequireFromString("10.99"), Currency: "USD", Variants: nil},
+	}}
+
+	product, err := NewCatalogService(repo, testRates()).GetProductByCode(context.Background(), "PROD001", "")
+	assert.NoError(t, err)
+	assert.NotNil(t, product.Variants)
+
+	body, err := json.Marshal(product)
+	assert.NoError(t, err)
+	assert.Contains(t, string(body), `"variants":[]`)
+}
+
+func TestCatalogService_PriceRounding(t *testing.T) {
+	repo := &mockProductsRepository{products: []models.Product{
+		{Code: "PROD001", Price: decimal.RequireFromString("10.99"), Currency: "USD"},
+	}}
+	ctx := context.Background()
+
+	t.Run("half up by default", func(t *testing.T) {
+		product, err := NewCatalogService(repo, testRates()).GetProductByCode(ctx, "PROD001", "EUR")
+
+		assert.NoError(t, err)
+		assert.Equal(t, 5.5, product.Price)
+	})
+
+	t.Run("configured mode and places", func(t *testing.T) {
+		service := NewCatalogService(repo, testRates(), WithPriceRounding(Rounding{Mode: RoundFloor, Places: 1}))
+		product, err := service.GetProductByCode(ctx, "PROD001", "EUR")
+
+		assert.NoError(t, err)
+		assert.Equal(t, 5.4, product.Price)
+	})
+}
+
+// recordingPublisher records every published event as "event code".
+type recordingPublisher struct {
+	events []string
+}
+
+func (p *recordingPublisher) Publish(event, code string) {
+	p.events = append(p.events, event+" "+code)
+}
+
+func TestCatalogService_PublishesEvents(t *testing.T) {
+	ctx := context.Background()
+	repo := &mockProductsRepository{products: testProducts()}
+	events := &recordingPublisher{}
+	tx := &mockTransactor{products: repo, categories: &mockCategoriesRepository{}}
+	service := NewCatalogService(repo, testRates(), WithTransactor(tx), WithPublisher(events))
+
+	_, err := service.CreateProductWithVariants(ctx, CreateProductRequest{Code: "PROD009", Price: 20})
+	assert.NoError(t, err)
+	version := 1
+	_, err = service.UpdateProduct(ctx, "PROD001", UpdateProductRequest{Version: &version}, "")
+	assert.NoError(t, err)
+	assert.NoError(t, service.SetProductFeatured(ctx, "PROD002", false))
+	_, err = service.CreateVariant(ctx, "PROD003", CreateVariantRequest{Name: "Variant A", SKU: "SKU003A"})
+	assert.NoError(t, err)
+	_, err = service.UpdateVariant(ctx, "PROD001", "SKU001A", UpdateVariantRequest{Name: "Variant A"})
+	assert.NoError(t, err)
+
+	// Failed writes publish nothing.
+	assert.Error(t, service.SetProductFeatured(ctx, "NOPE", true))
+	_, err = service.UpdateProduct(ctx, "NOPE", UpdateProductRequest{Version: &version}, "")
+	assert.Error(t, err)
+
+	assert.Equal(t, []string{
+		"product.created PROD009",
+		"product.updated PROD001",
+		"product.updated PROD002",
+		"product.updated PROD003",
+		"product.updated PROD001",
+	}, events.events)
+}
+
+// benchmarkSizes are the catalog sizes the listing benchmarks run against.
+var benchmarkSizes = []int{100, 1000, 10000}
+
+// benchmarkProducts builds n products spread over three categories, every
+// other one featured, each with two variants.
+func benchmarkProducts(n int) []models.Product {
+	categories := []models.Category{
+		{Code: "CLOTHING", Name: "Clothing"},
+		{Code: "SHOES", Name: "Shoes"},
+		{Code: "ACCESSORIES", Name: "Accessories"},
+	}
+
+	products := make([]models.Product, n)
+	for i := range products {
+		code := fmt.Sprintf("PROD%05d", i)
+		products[i] = models.Product{
+			ID:       uint(i + 1),
+			Code:     code,
+			SKU:      "SKU" + code[4:],
+			Price:    decimal.New(int64(100+i%5000), -2),
+			Currency: "USD",
+			Featured: i%2 == 0,
+			Category: categories[i%len(categories)],
+			Variants: []models.Variant{
+				{Name: "Variant A", SKU: "SKU" + code[4:] + "A", Stock: i % 3},
+				{Name: "Variant B", SKU: "SKU" + code[4:] + "B", Price: decimal.New(int64(200+i%5000), -2)},
+			},
+		}
+	}
+	return products
+}
+
+func BenchmarkCatalogService_GetProductsPaginated(b *testing.B) {
+	for _, size := range benchmarkSizes {
+		b.Run(fmt.Sprintf("products=%d", size), func(b *testing.B) {
+			service := NewCatalogService(&mockProductsRepository{products: benchmarkProducts(size)}, testRates())
+			ctx := context.Background()
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if _, err := service.GetProductsPaginatedWithFilters(ctx, size/2, maxLimit, FilterParams{}, nil, ""); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkCatalogService_GetProductsPaginatedWithFilters(b *testing.B) {
+	priceLt := 30.0
+	featured := true
+
+	for _, size := range benchmarkSizes {
+		b.Run(fmt.Sprintf("products=%d", size), func(b *testing.B) {
+			service := NewCatalogService(&mockProductsRepository{products: benchmarkProducts(size)}, testRates())
+			ctx := context.Background()
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if _, err := service.GetProductsPaginatedWithFilters(ctx, 0, maxLimit, FilterParams{Categories: []string{"Shoes"}, PriceLt: &priceLt, Featured: &featured, InStock: true}, nil, "EUR"); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,118 @@
+package catalog
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/eya20/hiring_test/app/api"
+	"github.com/eya20/hiring_test/app/tracing"
+	"github.com/eya20/hiring_test/models"
+	"github.com/shopspring/decimal"
+)
+
+// ShippingCalculator prices the shipping of quantity units of a product, in
+// BaseCurrency. It is the extension point for carrier specific rates.
+type ShippingCalculator interface {
+	Estimate(ctx context.Context, product models.Product, quantity int) (decimal.Decimal, error)
+}
+
+// WeightBasedCalculator charges BaseFee per shipment, PerKg for every started
+// kilogram of the total weight, and the surcharge of the product's shipping
+// class per unit. Products without weight or class ship for free. The zero
+// value ships everything for free.
+type WeightBasedCalculator struct {
+	BaseFee    decimal.Decimal
+	PerKg      decimal.Decimal
+	Surcharges map[string]decimal.Decimal
+}
+
+func (c WeightBasedCalculator) Estimate(ctx context.Context, product models.Product, quantity int) (decimal.Decimal, error) {
+	if product.WeightGrams == 0 && product.ShippingClass == "" {
+		return decimal.Zero, nil
+	}
+
+	grams := int64(product.WeightGrams) * int64(quantity)
+	kilograms := decimal.NewFromInt((grams + 999) / 1000)
+	units := decimal.NewFromInt(int64(quantity))
+
+	cost := c.BaseFee.Add(c.PerKg.Mul(kilograms))
+	if surcharge, ok := c.Surcharges[product.ShippingClass]; ok {
+		cost = cost.Add(surcharge.Mul(units))
+	}
+	return cost, nil
+}
+
+// ParseShippingSurcharges parses a comma separated list of CLASS=AMOUNT
+// pairs, e.g. "oversized=15,fragile=3.50". Amounts are in BaseCurrency.
+func ParseShippingSurcharges(s string) (map[string]decimal.Decimal, error) {
+	surcharges := map[string]decimal.Decimal{}
+	if strings.TrimSpace(s) == "" {
+		return surcharges, nil
+	}
+
+	for _, pair := range strings.Split(s, ",") {
+		class, value, ok := strings.Cut(strings.TrimSpace(pair), "=")
+		if !ok || class == "" {
+			return nil, fmt.Errorf("invalid shipping surcharge %q", pair)
+		}
+
+		amount, err := decimal.NewFromString(value)
+		if err != nil || amount.IsNegative() {
+			return nil, fmt.Errorf("invalid shipping surcharge for %s: %q", class, value)
+		}
+		surcharges[class] = amount
+	}
+	return surcharges, nil
+}
+
+// EstimateShipping prices the shipping of quantity units of the product
+// identified by code, in currency, or in BaseCurrency when empty.
+func (s *CatalogService) EstimateShipping(ctx context.Context, code string, quantity int, currency string) (ShippingEstimate, error) {
+	ctx, span := tracing.Start(ctx, "CatalogService.EstimateShipping")
+	defer span.End()
+
+	if quantity < 1 {
+		return ShippingEstimate{}, fmt.Errorf("%w: quantity must be at least 1", api.ErrValidation)
+	}
+	if currency == "" {
+		currency = BaseCurrency
+	}
+
+	product, err := s.getProduct(ctx, code)
+	if err != nil {
+		return ShippingEstimate{}, err
+	}
+
+	cost, err := s.shipping.Estimate(ctx, product, quantity)
+	if err != nil {
+		return ShippingEstimate{}, err
+	}
+	cost, err = s.rates.Convert(cost, BaseCurrency, currency)
+	if err != nil {
+		return ShippingEstimate{}, err
+	}
+
+	return ShippingEstimate{
+		Code:          product.Code,
+		Quantity:      quantity,
+		WeightGrams:   product.WeightGrams * quantity,
+		ShippingClass: product.ShippingClass,
+		Cost:          s.rounding.Float(cost),
+		Currency:      currency,
+	}, nil
+}
+
+// toShipping returns the shipping metadata of p, nil when it has none.
+func toShipping(p models.Product) *Shipping {
+	if p.WeightGrams == 0 && p.LengthMm == 0 && p.WidthMm == 0 && p.HeightMm == 0 && p.ShippingClass == "" {
+		return nil
+	}
+	return &Shipping{
+		WeightGrams:   p.WeightGrams,
+		LengthMm:      p.LengthMm,
+		WidthMm:       p.WidthMm,
+		HeightMm:      p.HeightMm,
+		ShippingClass: p.ShippingClass,
+	}
+}
@@ -0,0 +1,51 @@
+package catalog
+
+import (
+	"context"
+	"testing"
+
+	"github.com/eya20/hiring_test/models"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWeightBasedCalculator(t *testing.T) {
+	calculator := WeightBasedCalculator{
+		BaseFee:    decimal.RequireFromString("5"),
+		PerKg:      decimal.RequireFromString("1.50"),
+		Surcharges: map[string]decimal.Decimal{"oversized": decimal.RequireFromString("10")},
+	}
+
+	tests := []struct {
+		name     string
+		product  models.Product
+		quantity int
+		expected string
+	}{
+		{"nothing to ship", models.Product{}, 3, "0"},
+		{"started kilograms are charged", models.Product{WeightGrams: 1200}, 1, "8"},
+		{"weight adds up over the quantity", models.Product{WeightGrams: 400}, 5, "8"},
+		{"class surcharge per unit", models.Product{WeightGrams: 1000, ShippingClass: "oversized"}, 2, "28"},
+		{"unknown class", models.Product{WeightGrams: 1000, ShippingClass: "fragile"}, 1, "6.5"},
+	}
+
+	for _, tt := range tests {
+		cost, err := calculator.Estimate(context.Background(), tt.product, tt.quantity)
+
+		assert.NoError(t, err)
+		assert.True(t, decimal.RequireFromString(tt.expected).Equal(cost), "%s: got %s", tt.name, cost)
+	}
+}
+
+func TestParseShippingSurcharges(t *testing.T) {
+	surcharges, err := ParseShippingSurcharges("oversized=15, fragile=3.50")
+
+	assert.NoError(t, err)
+	assert.Len(t, surcharges, 2)
+	assert.True(t, decimal.RequireFromString("3.5").Equal(surcharges["fragile"]))
+
+	for _, s := range []string{"oversized", "=15", "fragile=abc", "fragile=-1"} {
+		_, err := ParseShippingSurcharges(s)
+		assert.Error(t, err, s)
+	}
+}
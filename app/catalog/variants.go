@@ -0,0 +1,152 @@
+package catalog
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/eya20/hiring_test/app/api"
+	"github.com/eya20/hiring_test/app/tracing"
+	"github.com/eya20/hiring_test/app/webhooks"
+	"github.com/eya20/hiring_test/models"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+// ErrSKUExists is returned when a variant or product is given a SKU already in use.
+var ErrSKUExists = errors.New("SKU already exists")
+
+// CreateVariant adds a variant to the product identified by code.
+func (s *CatalogService) CreateVariant(ctx context.Context, code string, req CreateVariantRequest) (Variant, error) {
+	ctx, span := tracing.Start(ctx, "CatalogService.CreateVariant")
+	defer span.End()
+
+	if err := api.ValidateStruct(req); err != nil {
+		return Variant{}, err
+	}
+
+	product, err := s.getProduct(ctx, code)
+	if err != nil {
+		return Variant{}, err
+	}
+
+	if s.maxVariants > 0 {
+		count, err := s.repo.CountVariants(ctx, product.ID)
+		if err != nil {
+			return Variant{}, err
+		}
+		if count >= int64(s.maxVariants) {
+			return Variant{}, fmt.Errorf("%w: product %s already has the maximum of %d variants", api.ErrLimitExceeded, code, s.maxVariants)
+		}
+	}
+
+	variant := models.Variant{
+		ProductID: product.ID,
+		Name:      req.Name,
+		SKU:       req.SKU,
+		Image:     req.Image,
+	}
+	s.setVariantPrice(&variant, product, req.Price)
+
+	if err := s.repo.CreateVariant(ctx, &variant); err != nil {
+		return Variant{}, skuConflict(err)
+	}
+
+	s.events.Publish(webhooks.EventProductUpdated, product.Code)
+	return s.toVariant(variant, product, product.Currency)
+}
+
+// UpdateVariant replaces the name, price and image of the variant sku of product code.
+func (s *CatalogService) UpdateVariant(ctx context.Context, code, sku string, req UpdateVariantRequest) (Variant, error) {
+	ctx, span := tracing.Start(ctx, "CatalogService.UpdateVariant")
+	defer span.End()
+
+	if err := api.ValidateStruct(req); err != nil {
+		return Variant{}, err
+	}
+
+	product, err := s.getProduct(ctx, code)
+	if err != nil {
+		return Variant{}, err
+	}
+
+	var variant *models.Variant
+	for i := range product.Variants {
+		if product.Variants[i].SKU == sku {
+			variant = &product.Variants[i]
+			break
+		}
+	}
+	if variant == nil {
+		return Variant{}, fmt.Errorf("%w: variant %s of product %s", api.ErrNotFound, sku, code)
+	}
+
+	variant.Name = req.Name
+	variant.Image = req.Image
+	s.setVariantPrice(variant, product, req.Price)
+
+	if err := s.repo.UpdateVariant(ctx, variant); err != nil {
+		return Variant{}, err
+	}
+
+	s.events.Publish(webhooks.EventProductUpdated, product.Code)
+	return s.toVariant(*variant, product, product.Currency)
+}
+
+// GetVariantStock returns the stock of the variant sku of the product
+// identified by code.
+func (s *CatalogService) GetVariantStock(ctx context.Context, code, sku string) (VariantStock, error) {
+	ctx, span := tracing.Start(ctx, "CatalogService.GetVariantStock")
+	defer span.End()
+
+	stock, err := s.repo.GetVariantStock(ctx, code, sku)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return VariantStock{}, fmt.Errorf("%w: variant %s of product %s", api.ErrNotFound, sku, code)
+		}
+		return VariantStock{}, err
+	}
+	return VariantStock{SKU: sku, Stock: stock}, nil
+}
+
+func (s *CatalogService) getProduct(ctx context.Context, code string) (models.Product, error) {
+	var product models.Product
+	if err := s.repo.GetProductByCode(ctx, code, &product); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return models.Product{}, fmt.Errorf("%w: product with code %s", api.ErrNotFound, code)
+		}
+		return models.Product{}, err
+	}
+	return product, nil
+}
+
+// setVariantPrice applies a variant price override, already validated by the
+// request. A nil or zero price means the variant inherits the product price.
+func (s *CatalogService) setVariantPrice(variant *models.Variant, product models.Product, price *float64) {
+	if price == nil || *price == 0 {
+		variant.Price = decimal.Zero
+		return
+	}
+
+	p := decimal.NewFromFloat(*price)
+
+	if s.maxPriceDeviation.IsPositive() && product.Price.IsPositive() {
+		deviation := p.Sub(product.Price).Abs().Mul(decimal.NewFromInt(100)).Div(product.Price)
+		if deviation.GreaterThan(s.maxPriceDeviation) {
+			log.Printf("warning: variant %s price %s deviates %s%% from product %s price %s",
+				variant.SKU, p.StringFixed(2), deviation.StringFixed(0), product.Code, product.Price.StringFixed(2))
+		}
+	}
+
+	variant.Price = p
+}
+
+// skuConflict turns a unique constraint violation on a SKU into ErrSKUExists,
+// marked as an api.ErrConflict.
+func skuConflict(err error) error {
+	if models.IsDuplicateKey(err) {
+		return api.Conflict(ErrSKUExists)
+	}
+	return err
+}
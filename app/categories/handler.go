@@ -0,0 +1,445 @@
+package categories
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/eya20/hiring_test/app/api"
+	"github.com/eya20/hiring_test/app/catalog"
+	"github.com/eya20/hiring_test/app/webhooks"
+	"github.com/eya20/hiring_test/models"
+	"gorm.io/gorm"
+)
+
+type Response struct {
+	XMLName    xml.Name   `json:"-" xml:"response"`
+	Categories []Category `json:"categories" xml:"categories>category"`
+}
+
+// Category is a category as returned by the API. ParentCode is empty for
+// top-level categories, and Children is only filled in tree listings.
+type Category struct {
+	XMLName      xml.Name   `json:"-" xml:"category"`
+	Code         string     `json:"code" xml:"code"`
+	Name         string     `json:"name" xml:"name"`
+	ParentCode   string     `json:"parent_code,omitempty" xml:"parent_code,omitempty"`
+	ProductCount int64      `json:"product_count" xml:"product_count"`
+	Children     []Category `json:"children,omitempty" xml:"children>category,omitempty"`
+}
+
+type CreateCategoryRequest struct {
+	Code       string `json:"code" validate:"required,code"`
+	Name       string `json:"name" validate:"required,name"`
+	ParentCode string `json:"parent_code" validate:"omitempty,code"`
+}
+
+// ParentRequest moves a category: under the category ParentCode, or to the
+// top level when it is empty or null.
+type ParentRequest struct {
+	ParentCode *string `json:"parent_code"`
+}
+
+// PatchCategoryRequest updates the fields of a category that are set and
+// leaves the others unchanged.
+type PatchCategoryRequest struct {
+	Name *string `json:"name" validate:"name"`
+}
+
+type CategoriesHandler struct {
+	repo    models.CategoriesRepositoryInterface
+	catalog *catalog.CatalogService
+	config  catalog.Config
+	events  webhooks.Publisher
+}
+
+func NewCategoriesHandler(r models.CategoriesRepositoryInterface, c *catalog.CatalogService, cfg catalog.Config, events webhooks.Publisher) *CategoriesHandler {
+	return &CategoriesHandler{
+		repo:    r,
+		catalog: c,
+		config:  cfg,
+		events:  events,
+	}
+}
+
+// GetCategories lists all categories. Product counts are only computed
+// when requested with ?with_count=true, and ?tree=true nests every category
+// under its parent instead of listing them flat.
+func (h *CategoriesHandler) GetCategories(w http.ResponseWriter, r *http.Request) {
+	withCount, err := boolParam(r, "with_count")
+	if err != nil {
+		api.ErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	tree, err := boolParam(r, "tree")
+	if err != nil {
+		api.ErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var categories []Category
+	if withCount {
+		res, err := h.repo.GetCategoriesWithProductCount(r.Context())
+		if err != nil {
+			api.HandleServiceError(w, err)
+			return
+		}
+		categories = toCategories(res, func(c models.CategoryWithCount) (models.Category, int64) {
+			return c.Category, c.ProductCount
+		})
+	} else {
+		res, err := h.repo.GetAllCategories(r.Context())
+		if err != nil {
+			api.HandleServiceError(w, err)
+			return
+		}
+		categories = toCategories(res, func(c models.Category) (models.Category, int64) {
+			return c, 0
+		})
+	}
+
+	if tree {
+		categories = nest(categories)
+	}
+
+	api.OKResponse(w, Response{
+		Categories: categories,
+	})
+}
+
+// boolParam reads an optional boolean query param, false when absent.
+func boolParam(r *http.Request, name string) (bool, error) {
+	v := r.URL.Query().Get(name)
+	if v == "" {
+		return false, nil
+	}
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		return false, fmt.Errorf("invalid %s %q", name, v)
+	}
+	return b, nil
+}
+
+// toCategories maps a full category listing to its response, resolving each
+// parent id to the parent's code.
+func toCategories[T any](res []T, split func(T) (models.Category, int64)) []Category {
+	codes := make(map[uint]string, len(res))
+	for _, c := range res {
+		category, _ := split(c)
+		codes[category.ID] = category.Code
+	}
+
+	categories := make([]Category, len(res))
+	for i, c := range res {
+		category, count := split(c)
+		categories[i] = Category{
+			Code:         category.Code,
+			Name:         category.Name,
+			ProductCount: count,
+		}
+		if category.ParentID != nil {
+			categories[i].ParentCode = codes[*category.ParentID]
+		}
+	}
+	return categories
+}
+
+// nest arranges a flat category listing into a tree of top-level categories,
+// keeping the listing order among siblings.
+func nest(categories []Category) []Category {
+	children := make(map[string][]Category)
+	for _, c := range categories {
+		children[c.ParentCode] = append(children[c.ParentCode], c)
+	}
+
+	var attach func(c Category) Category
+	attach = func(c Category) Category {
+		for _, child := range children[c.Code] {
+			c.Children = append(c.Children, attach(child))
+		}
+		return c
+	}
+
+	roots := []Category{}
+	for _, c := range children[""] {
+		roots = append(roots, attach(c))
+	}
+	return roots
+}
+
+// GetCategoryChildren lists the direct children of a category.
+func (h *CategoriesHandler) GetCategoryChildren(w http.ResponseWriter, r *http.Request) {
+	category, ok := h.category(w, r, r.PathValue("code"))
+	if !ok {
+		return
+	}
+
+	res, err := h.repo.GetChildCategories(r.Context(), category.ID)
+	if err != nil {
+		api.HandleServiceError(w, err)
+		return
+	}
+
+	categories := make([]Category, len(res))
+	for i, c := range res {
+		categories[i] = Category{
+			Code:       c.Code,
+			Name:       c.Name,
+			ParentCode: category.Code,
+		}
+	}
+
+	api.OKResponse(w, Response{
+		Categories: categories,
+	})
+}
+
+// SetCategoryParent moves a category under another one, or to the top level.
+// Moving a category under itself or one of its descendants is rejected.
+func (h *CategoriesHandler) SetCategoryParent(w http.ResponseWriter, r *http.Request) {
+	var req ParentRequest
+	if !api.DecodeBody(w, r, &req) {
+		return
+	}
+
+	category, ok := h.category(w, r, r.PathValue("code"))
+	if !ok {
+		return
+	}
+
+	var parentCode string
+	if req.ParentCode != nil {
+		parentCode = *req.ParentCode
+	}
+	parentID, ok := h.parentID(w, r, parentCode)
+	if !ok {
+		return
+	}
+
+	if err := h.repo.SetCategoryParent(r.Context(), category.ID, parentID); err != nil {
+		if errors.Is(err, models.ErrCategoryCycle) {
+			verr := &api.ValidationError{}
+			verr.Add("parent_code", err.Error())
+			api.ValidationErrorResponse(w, verr)
+			return
+		}
+		api.HandleServiceError(w, err)
+		return
+	}
+
+	h.events.Publish(webhooks.EventCategoryUpdated, category.Code)
+	api.OKResponse(w, Category{
+		Code:       category.Code,
+		Name:       category.Name,
+		ParentCode: parentCode,
+	})
+}
+
+// GetCategory returns a single category with its parent code.
+func (h *CategoriesHandler) GetCategory(w http.ResponseWriter, r *http.Request) {
+	category, ok := h.category(w, r, r.PathValue("code"))
+	if !ok {
+		return
+	}
+
+	parentCode, err := h.parentCode(r.Context(), category)
+	if err != nil {
+		api.HandleServiceError(w, err)
+		return
+	}
+
+	api.OKResponse(w, Category{
+		Code:       category.Code,
+		Name:       category.Name,
+		ParentCode: parentCode,
+	})
+}
+
+// PatchCategory updates the fields of a category set in the body. A body
+// without any field set is rejected.
+func (h *CategoriesHandler) PatchCategory(w http.ResponseWriter, r *http.Request) {
+	var req PatchCategoryRequest
+	if !api.DecodeBody(w, r, &req) {
+		return
+	}
+	if req.Name == nil {
+		api.ErrorResponse(w, http.StatusBadRequest, "no fields to update")
+		return
+	}
+	verr := &api.ValidationError{}
+	if errors.As(api.ValidateStruct(req), &verr) {
+		api.ValidationErrorResponse(w, verr)
+		return
+	}
+	if strings.TrimSpace(*req.Name) == "" {
+		verr.Add("name", "name can't be empty")
+		api.ValidationErrorResponse(w, verr)
+		return
+	}
+
+	category, ok := h.category(w, r, r.PathValue("code"))
+	if !ok {
+		return
+	}
+
+	category.Name = *req.Name
+	if err := h.repo.UpdateCategory(r.Context(), &category); err != nil {
+		api.HandleServiceError(w, err)
+		return
+	}
+
+	parentCode, err := h.parentCode(r.Context(), category)
+	if err != nil {
+		api.HandleServiceError(w, err)
+		return
+	}
+
+	h.events.Publish(webhooks.EventCategoryUpdated, category.Code)
+	api.OKResponse(w, Category{
+		Code:       category.Code,
+		Name:       category.Name,
+		ParentCode: parentCode,
+	})
+}
+
+// parentCode returns the code of the parent of category, empty for top-level
+// categories.
+func (h *CategoriesHandler) parentCode(ctx context.Context, category models.Category) (string, error) {
+	if category.ParentID == nil {
+		return "", nil
+	}
+
+	all, err := h.repo.GetAllCategories(ctx)
+	if err != nil {
+		return "", err
+	}
+	for _, c := range all {
+		if c.ID == *category.ParentID {
+			return c.Code, nil
+		}
+	}
+	return "", nil
+}
+
+// category looks up the category code, writing an error response and
+// returning false when it can't.
+func (h *CategoriesHandler) category(w http.ResponseWriter, r *http.Request, code string) (models.Category, bool) {
+	category, err := h.findCategory(r.Context(), code)
+	if err != nil {
+		api.HandleServiceError(w, err)
+		return models.Category{}, false
+	}
+	return category, true
+}
+
+// findCategory looks up the category code, translating a missing record to
+// api.ErrNotFound so callers don't depend on gorm errors.
+func (h *CategoriesHandler) findCategory(ctx context.Context, code string) (models.Category, error) {
+	var category models.Category
+	if err := h.repo.GetCategoryByCode(ctx, code, &category); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return models.Category{}, fmt.Errorf("%w: category with code %s", api.ErrNotFound, code)
+		}
+		return models.Category{}, err
+	}
+	return category, nil
+}
+
+// parentID resolves the parent_code of a request to the parent's id, nil for
+// an empty code. An unknown code is a 400, as the parent is part of the body.
+func (h *CategoriesHandler) parentID(w http.ResponseWriter, r *http.Request, code string) (*uint, bool) {
+	if code == "" {
+		return nil, true
+	}
+
+	var parent models.Category
+	if err := h.repo.GetCategoryByCode(r.Context(), code, &parent); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			verr := &api.ValidationError{}
+			verr.Add("parent_code", fmt.Sprintf("unknown parent category %q", code))
+			api.ValidationErrorResponse(w, verr)
+			return nil, false
+		}
+		api.HandleServiceError(w, err)
+		return nil, false
+	}
+	return &parent.ID, true
+}
+
+// CreateCategory adds a category after validating the request body. There is
+// no existence check beforehand: the unique index on code settles concurrent
+// creates of the same code, and the losers get a 409.
+func (h *CategoriesHandler) CreateCategory(w http.ResponseWriter, r *http.Request) {
+	var req CreateCategoryRequest
+	if !api.DecodeBody(w, r, &req) {
+		return
+	}
+	var verr *api.ValidationError
+	if errors.As(api.ValidateStruct(req), &verr) {
+		api.ValidationErrorResponse(w, verr)
+		return
+	}
+
+	parentID, ok := h.parentID(w, r, req.ParentCode)
+	if !ok {
+		return
+	}
+
+	category := models.Category{
+		Code:     req.Code,
+		Name:     req.Name,
+		ParentID: parentID,
+	}
+	if err := h.repo.CreateCategory(r.Context(), &category); err != nil {
+		if models.IsDuplicateKey(err) {
+			err = api.Conflict(fmt.Errorf("category %s already exists", category.Code))
+		}
+		api.HandleServiceError(w, err)
+		return
+	}
+
+	h.events.Publish(webhooks.EventCategoryCreated, category.Code)
+
+	api.CreatedResponse(w, "/categories/"+url.PathEscape(category.Code), Category{
+		Code:       category.Code,
+		Name:       category.Name,
+		ParentCode: req.ParentCode,
+	})
+}
+
+// GetCategoryProducts lists the products of a single category. It accepts the
+// same pagination, sort, price filter, fields and envelope params as GET
+// /catalog.
+func (h *CategoriesHandler) GetCategoryProducts(w http.ResponseWriter, r *http.Request) {
+	params, err := catalog.ParseListParams(r, h.config)
+	if err != nil {
+		catalog.ParamsErrorResponse(w, err)
+		return
+	}
+
+	if params.Currency != "" && !h.catalog.SupportsCurrency(params.Currency) {
+		api.ErrorResponse(w, http.StatusBadRequest, "unsupported currency "+params.Currency)
+		return
+	}
+
+	category, ok := h.category(w, r, r.PathValue("code"))
+	if !ok {
+		return
+	}
+
+	filters := params.FilterParams
+	filters.Categories = []string{category.Name}
+	res, err := h.catalog.GetProductsPaginatedWithFilters(r.Context(), params.Offset, params.Limit, filters, params.Sort, params.Currency)
+	if err != nil {
+		api.HandleServiceError(w, err)
+		return
+	}
+
+	api.SetLinkHeader(w, r, params.Offset, params.Limit, res.Total)
+	catalog.WriteListing(w, res, params)
+}
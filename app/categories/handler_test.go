@@ -0,0 +1,604 @@
+package categories
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/eya20/hiring_test/app/catalog"
+	"github.com/eya20/hiring_test/app/webhooks"
+	"github.com/eya20/hiring_test/models"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+type mockCategoriesRepository struct {
+	mu         sync.Mutex
+	categories []models.Category
+	counts     map[string]int64
+	err        error
+}
+
+func (m *mockCategoriesRepository) GetAllCategories(ctx context.Context) ([]models.Category, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	return m.categories, nil
+}
+
+func (m *mockCategoriesRepository) GetCategoriesWithProductCount(ctx context.Context) ([]models.CategoryWithCount, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	categories := make([]models.CategoryWithCount, len(m.categories))
+	for i, c := range m.categories {
+		categories[i] = models.CategoryWithCount{Category: c, ProductCount: m.counts[c.Code]}
+	}
+	return categories, nil
+}
+
+func (m *mockCategoriesRepository) GetCategoryByCode(ctx context.Context, code string, category *models.Category) error {
+	if m.err != nil {
+		return m.err
+	}
+	for _, c := range m.categories {
+		if c.Code == code {
+			*category = c
+			return nil
+		}
+	}
+	return gorm.ErrRecordNotFound
+}
+
+func (m *mockCategoriesRepository) GetChildCategories(ctx context.Context, parentID uint) ([]models.Category, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	categories := []models.Category{}
+	for _, c := range m.categories {
+		if c.ParentID != nil && *c.ParentID == parentID {
+			categories = append(categories, c)
+		}
+	}
+	return categories, nil
+}
+
+// CreateCategory enforces unique codes like the database's unique index.
+func (m *mockCategoriesRepository) CreateCategory(ctx context.Context, category *models.Category) error {
+	if m.err != nil {
+		return m.err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, c := range m.categories {
+		if c.Code == category.Code {
+			return gorm.ErrDuplicatedKey
+		}
+	}
+	m.categories = append(m.categories, *category)
+	return nil
+}
+
+func (m *mockCategoriesRepository) UpdateCategory(ctx context.Context, category *models.Category) error {
+	if m.err != nil {
+		return m.err
+	}
+	for i := range m.categories {
+		if m.categories[i].ID == category.ID {
+			m.categories[i] = *category
+			return nil
+		}
+	}
+	return gorm.ErrRecordNotFound
+}
+
+func (m *mockCategoriesRepository) DeleteCategory(ctx context.Context, code string) error {
+	return m.err
+}
+
+// SetCategoryParent only rejects direct self-nesting; the ancestor walk is
+// covered by the repository integration tests.
+func (m *mockCategoriesRepository) SetCategoryParent(ctx context.Context, id uint, parentID *uint) error {
+	if m.err != nil {
+		return m.err
+	}
+	if parentID != nil && *parentID == id {
+		return models.ErrCategoryCycle
+	}
+	for i := range m.categories {
+		if m.categories[i].ID == id {
+			m.categories[i].ParentID = parentID
+			return nil
+		}
+	}
+	return gorm.ErrRecordNotFound
+}
+
+type mockProductsRepository struct {
+	products []models.Product
+
+	categories []string
+}
+
+func (m *mockProductsRepository) GetAllProducts(ctx context.Context) ([]models.Product, error) {
+	return m.products, nil
+}
+
+func (m *mockProductsRepository) EachProduct(ctx context.Context, batchSize int, fn func(models.Product) error) error {
+	return nil
+}
+
+func (m *mockProductsRepository) GetProductByCode(ctx context.Context, code string, product *models.Product) error {
+	return gorm.ErrRecordNotFound
+}
+
+func (m *mockProductsRepository) GetProductBySKU(ctx context.Context, sku string, product *models.Product) error {
+	return gorm.ErrRecordNotFound
+}
+
+func (m *mockProductsRepository) GetProducts(ctx context.Context, q models.ProductQuery) ([]models.Product, int64, error) {
+	m.categories = q.Categories
+	return m.products, int64(len(m.products)), nil
+}
+
+func (m *mockProductsRepository) CountProducts(ctx context.Context, q models.ProductQuery) (int64, error) {
+	m.categories = q.Categories
+	return int64(len(m.products)), nil
+}
+
+func (m *mockProductsRepository) GetFeaturedProducts(ctx context.Context) ([]models.Product, error) {
+	return nil, nil
+}
+
+func (m *mockProductsRepository) SetProductFeatured(ctx context.Context, code string, featured bool) error {
+	return nil
+}
+
+func (m *mockProductsRepository) CreatePriceChangeEvent(ctx context.Context, event *models.PriceChangeEvent) error {
+	return nil
+}
+
+func (m *mockProductsRepository) GetPriceHistory(ctx context.Context, code string) ([]models.PriceChangeEvent, error) {
+	return nil, nil
+}
+
+func (m *mockProductsRepository) SetProductCategory(ctx context.Context, code string, categoryID uint) error {
+	return nil
+}
+
+func (m *mockProductsRepository) LockCategoryProducts(ctx context.Context, categoryID uint) ([]models.Product, error) {
+	return nil, nil
+}
+
+func (m *mockProductsRepository) GetSimilarProducts(ctx context.Context, code string, limit int) ([]models.Product, error) {
+	return nil, nil
+}
+
+func (m *mockProductsRepository) GetRandomProducts(ctx context.Context, count int, category string) ([]models.Product, error) {
+	return nil, nil
+}
+
+func (m *mockProductsRepository) GetPriceTotals(ctx context.Context) ([]models.PriceTotal, error) {
+	return nil, nil
+}
+
+func (m *mockProductsRepository) CreateProduct(ctx context.Context, product *models.Product) error {
+	return nil
+}
+
+func (m *mockProductsRepository) CreateVariant(ctx context.Context, variant *models.Variant) error {
+	return nil
+}
+
+func (m *mockProductsRepository) CountVariants(ctx context.Context, productID uint) (int64, error) {
+	return 0, nil
+}
+
+func (m *mockProductsRepository) GetVariantStock(ctx context.Context, code, sku string) (int, error) {
+	return 0, gorm.ErrRecordNotFound
+}
+
+func (m *mockProductsRepository) UpdateVariant(ctx context.Context, variant *models.Variant) error {
+	return nil
+}
+
+func (m *mockProductsRepository) UpdateProduct(ctx context.Context, code string, version int, updates map[string]any) error {
+	return nil
+}
+
+func testCategories() []models.Category {
+	return []models.Category{
+		{ID: 1, Code: "CLOTHING", Name: "Clothing"},
+		{ID: 2, Code: "SHOES", Name: "Shoes"},
+	}
+}
+
+// nestedCategories adds BOOTS under SHOES and HIKING under BOOTS.
+func nestedCategories() []models.Category {
+	shoes, boots := uint(2), uint(3)
+	return append(testCategories(),
+		models.Category{ID: 3, Code: "BOOTS", Name: "Boots", ParentID: &shoes},
+		models.Category{ID: 4, Code: "HIKING", Name: "Hiking", ParentID: &boots},
+	)
+}
+
+// recordingPublisher records every published event as "event code".
+type recordingPublisher struct {
+	events []string
+}
+
+func (p *recordingPublisher) Publish(event, code string) {
+	p.events = append(p.events, event+" "+code)
+}
+
+func newTestHandler(categories *mockCategoriesRepository, products *mockProductsRepository) *CategoriesHandler {
+	return newTestHandlerWithEvents(categories, products, webhooks.Discard)
+}
+
+func newTestHandlerWithEvents(categories *mockCategoriesRepository, products *mockProductsRepository, events webhooks.Publisher) *CategoriesHandler {
+	return NewCategoriesHandler(categories, catalog.NewCatalogService(products, catalog.ExchangeRates{"USD": decimal.NewFromInt(1)}), catalog.DefaultConfig(), events)
+}
+
+func TestGetCategories(t *testing.T) {
+	t.Run("returns all categories", func(t *testing.T) {
+		h := newTestHandler(&mockCategoriesRepository{categories: testCategories()}, &mockProductsRepository{})
+
+		recorder := httptest.NewRecorder()
+		h.GetCategories(recorder, httptest.NewRequest(http.MethodGet, "/categories", nil))
+
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		assert.JSONEq(t, `{"categories":[
+			{"code":"CLOTHING","name":"Clothing","product_count":0},
+			{"code":"SHOES","name":"Shoes","product_count":0}
+		]}`, recorder.Body.String())
+	})
+
+	t.Run("includes product counts when requested", func(t *testing.T) {
+		h := newTestHandler(&mockCategoriesRepository{
+			categories: testCategories(),
+			counts:     map[string]int64{"CLOTHING": 3},
+		}, &mockProductsRepository{})
+
+		recorder := httptest.NewRecorder()
+		h.GetCategories(recorder, httptest.NewRequest(http.MethodGet, "/categories?with_count=true", nil))
+
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		assert.JSONEq(t, `{"categories":[
+			{"code":"CLOTHING","name":"Clothing","product_count":3},
+			{"code":"SHOES","name":"Shoes","product_count":0}
+		]}`, recorder.Body.String())
+	})
+
+	t.Run("invalid with_count", func(t *testing.T) {
+		h := newTestHandler(&mockCategoriesRepository{categories: testCategories()}, &mockProductsRepository{})
+
+		recorder := httptest.NewRecorder()
+		h.GetCategories(recorder, httptest.NewRequest(http.MethodGet, "/categories?with_count=maybe", nil))
+
+		assert.Equal(t, http.StatusBadRequest, recorder.Code)
+	})
+
+	t.Run("includes parent codes", func(t *testing.T) {
+		h := newTestHandler(&mockCategoriesRepository{categories: nestedCategories()}, &mockProductsRepository{})
+
+		recorder := httptest.NewRecorder()
+		h.GetCategories(recorder, httptest.NewRequest(http.MethodGet, "/categories", nil))
+
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		assert.JSONEq(t, `{"categories":[
+			{"code":"CLOTHING","name":"Clothing","product_count":0},
+			{"code":"SHOES","name":"Shoes","product_count":0},
+			{"code":"BOOTS","name":"Boots","parent_code":"SHOES","product_count":0},
+			{"code":"HIKING","name":"Hiking","parent_code":"BOOTS","product_count":0}
+		]}`, recorder.Body.String())
+	})
+
+	t.Run("nests categories as a tree", func(t *testing.T) {
+		h := newTestHandler(&mockCategoriesRepository{categories: nestedCategories()}, &mockProductsRepository{})
+
+		recorder := httptest.NewRecorder()
+		h.GetCategories(recorder, httptest.NewRequest(http.MethodGet, "/categories?tree=true", nil))
+
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		assert.JSONEq(t, `{"categories":[
+			{"code":"CLOTHING","name":"Clothing","product_count":0},
+			{"code":"SHOES","name":"Shoes","product_count":0,"children":[
+				{"code":"BOOTS","name":"Boots","parent_code":"SHOES","product_count":0,"children":[
+					{"code":"HIKING","name":"Hiking","parent_code":"BOOTS","product_count":0}
+				]}
+			]}
+		]}`, recorder.Body.String())
+	})
+
+	t.Run("invalid tree", func(t *testing.T) {
+		h := newTestHandler(&mockCategoriesRepository{categories: testCategories()}, &mockProductsRepository{})
+
+		recorder := httptest.NewRecorder()
+		h.GetCategories(recorder, httptest.NewRequest(http.MethodGet, "/categories?tree=maybe", nil))
+
+		assert.Equal(t, http.StatusBadRequest, recorder.Code)
+	})
+
+	t.Run("repository error", func(t *testing.T) {
+		h := newTestHandler(&mockCategoriesRepository{err: errors.New("boom")}, &mockProductsRepository{})
+
+		recorder := httptest.NewRecorder()
+		h.GetCategories(recorder, httptest.NewRequest(http.MethodGet, "/categories", nil))
+
+		assert.Equal(t, http.StatusInternalServerError, recorder.Code)
+	})
+}
+
+func TestGetCategory(t *testing.T) {
+	tests := []struct {
+		name     string
+		code     string
+		err      error
+		status   int
+		response string
+	}{
+		{name: "top-level category", code: "SHOES", status: http.StatusOK, response: `{"code":"SHOES","name":"Shoes","product_count":0}`},
+		{name: "nested category", code: "HIKING", status: http.StatusOK, response: `{"code":"HIKING","name":"Hiking","parent_code":"BOOTS","product_count":0}`},
+		{name: "unknown category", code: "NOPE", status: http.StatusNotFound, response: `{"error":"resource not found: category with code NOPE"}`},
+		{name: "repository failure", code: "SHOES", err: errors.New("connection refused"), status: http.StatusInternalServerError, response: `{"error":"internal server error"}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := newTestHandler(&mockCategoriesRepository{categories: nestedCategories(), err: tt.err}, &mockProductsRepository{})
+
+			req := httptest.NewRequest(http.MethodGet, "/categories/"+tt.code, nil)
+			req.SetPathValue("code", tt.code)
+			recorder := httptest.NewRecorder()
+			h.GetCategory(recorder, req)
+
+			assert.Equal(t, tt.status, recorder.Code)
+			assert.JSONEq(t, tt.response, recorder.Body.String())
+		})
+	}
+}
+
+func TestGetCategoryChildren(t *testing.T) {
+	t.Run("lists direct children only", func(t *testing.T) {
+		h := newTestHandler(&mockCategoriesRepository{categories: nestedCategories()}, &mockProductsRepository{})
+
+		req := httptest.NewRequest(http.MethodGet, "/categories/SHOES/children", nil)
+		req.SetPathValue("code", "SHOES")
+		recorder := httptest.NewRecorder()
+		h.GetCategoryChildren(recorder, req)
+
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		assert.JSONEq(t, `{"categories":[
+			{"code":"BOOTS","name":"Boots","parent_code":"SHOES","product_count":0}
+		]}`, recorder.Body.String())
+	})
+
+	t.Run("unknown category", func(t *testing.T) {
+		h := newTestHandler(&mockCategoriesRepository{categories: nestedCategories()}, &mockProductsRepository{})
+
+		req := httptest.NewRequest(http.MethodGet, "/categories/NOPE/children", nil)
+		req.SetPathValue("code", "NOPE")
+		recorder := httptest.NewRecorder()
+		h.GetCategoryChildren(recorder, req)
+
+		assert.Equal(t, http.StatusNotFound, recorder.Code)
+	})
+}
+
+func TestSetCategoryParent(t *testing.T) {
+	tests := []struct {
+		name     string
+		code     string
+		body     string
+		status   int
+		response string
+	}{
+		{name: "moves the category", code: "CLOTHING", body: `{"parent_code":"SHOES"}`, status: http.StatusOK, response: `{"code":"CLOTHING","name":"Clothing","parent_code":"SHOES","product_count":0}`},
+		{name: "moves the category to the top level", code: "BOOTS", body: `{"parent_code":null}`, status: http.StatusOK, response: `{"code":"BOOTS","name":"Boots","product_count":0}`},
+		{name: "nesting under itself", code: "SHOES", body: `{"parent_code":"SHOES"}`, status: http.StatusBadRequest, response: `{"errors":[{"field":"parent_code","message":"category cannot be nested under itself or one of its descendants"}]}`},
+		{name: "unknown parent", code: "SHOES", body: `{"parent_code":"NOPE"}`, status: http.StatusBadRequest, response: `{"errors":[{"field":"parent_code","message":"unknown parent category \"NOPE\""}]}`},
+		{name: "unknown category", code: "NOPE", body: `{"parent_code":"SHOES"}`, status: http.StatusNotFound, response: `{"error":"resource not found: category with code NOPE"}`},
+		{name: "malformed body", code: "SHOES", body: `{`, status: http.StatusBadRequest, response: `{"error":"invalid request body"}`},
+		{name: "unknown field", code: "SHOES", body: `{"parent":"CLOTHING"}`, status: http.StatusBadRequest, response: `{"error":"unknown field: parent"}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			events := &recordingPublisher{}
+			h := newTestHandlerWithEvents(&mockCategoriesRepository{categories: nestedCategories()}, &mockProductsRepository{}, events)
+
+			req := httptest.NewRequest(http.MethodPatch, "/categories/"+tt.code+"/parent", strings.NewReader(tt.body))
+			req.SetPathValue("code", tt.code)
+			recorder := httptest.NewRecorder()
+			h.SetCategoryParent(recorder, req)
+
+			assert.Equal(t, tt.status, recorder.Code)
+			assert.JSONEq(t, tt.response, recorder.Body.String())
+			if tt.status == http.StatusOK {
+				assert.Equal(t, []string{"category.updated " + tt.code}, events.events)
+			} else {
+				assert.Empty(t, events.events)
+			}
+		})
+	}
+}
+
+func TestPatchCategory(t *testing.T) {
+	tests := []struct {
+		name     string
+		code     string
+		body     string
+		status   int
+		response string
+	}{
+		{name: "renames the category", code: "BOOTS", body: `{"name":"Winter boots"}`, status: http.StatusOK, response: `{"code":"BOOTS","name":"Winter boots","parent_code":"SHOES","product_count":0}`},
+		{name: "no fields", code: "BOOTS", body: `{}`, status: http.StatusBadRequest, response: `{"error":"no fields to update"}`},
+		{name: "null name", code: "BOOTS", body: `{"name":null}`, status: http.StatusBadRequest, response: `{"error":"no fields to update"}`},
+		{name: "empty name", code: "BOOTS", body: `{"name":" "}`, status: http.StatusBadRequest, response: `{"errors":[{"field":"name","message":"name can't be empty"}]}`},
+		{name: "name too long", code: "BOOTS", body: `{"name":"` + strings.Repeat("a", 201) + `"}`, status: http.StatusBadRequest, response: `{"errors":[{"field":"name","message":"name exceeds maximum length of 200 characters"}]}`},
+		{name: "unknown category", code: "NOPE", body: `{"name":"Hats"}`, status: http.StatusNotFound, response: `{"error":"resource not found: category with code NOPE"}`},
+		{name: "malformed body", code: "BOOTS", body: `{`, status: http.StatusBadRequest, response: `{"error":"invalid request body"}`},
+		{name: "unknown field", code: "BOOTS", body: `{"namme":"Winter boots"}`, status: http.StatusBadRequest, response: `{"error":"unknown field: namme"}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			events := &recordingPublisher{}
+			repo := &mockCategoriesRepository{categories: nestedCategories()}
+			h := newTestHandlerWithEvents(repo, &mockProductsRepository{}, events)
+
+			req := httptest.NewRequest(http.MethodPatch, "/categories/"+tt.code, strings.NewReader(tt.body))
+			req.SetPathValue("code", tt.code)
+			recorder := httptest.NewRecorder()
+			h.PatchCategory(recorder, req)
+
+			assert.Equal(t, tt.status, recorder.Code)
+			assert.JSONEq(t, tt.response, recorder.Body.String())
+			if tt.status == http.StatusOK {
+				assert.Equal(t, []string{"category.updated " + tt.code}, events.events)
+				assert.Equal(t, "Winter boots", repo.categories[2].Name)
+				assert.NotNil(t, repo.categories[2].ParentID)
+			} else {
+				assert.Empty(t, events.events)
+			}
+		})
+	}
+}
+
+func TestGetCategoryProducts(t *testing.T) {
+	t.Run("lists products of the resolved category", func(t *testing.T) {
+		products := &mockProductsRepository{products: []models.Product{
+			{Code: "PROD001", Price: decimal.RequireFromString("10.99"), Currency: "USD", Category: models.Category{Name: "Clothing"}},
+		}}
+		h := newTestHandler(&mockCategoriesRepository{categories: testCategories()}, products)
+
+		req := httptest.NewRequest(http.MethodGet, "/categories/CLOTHING/products", nil)
+		req.SetPathValue("code", "CLOTHING")
+		recorder := httptest.NewRecorder()
+		h.GetCategoryProducts(recorder, req)
+
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		assert.Equal(t, []string{"Clothing"}, products.categories)
+		assert.JSONEq(t, `{"total":1,"products":[
+			{"code":"PROD001","sku":"","price":10.99,"currency":"USD","category":"Clothing"}
+		]}`, recorder.Body.String())
+	})
+
+	t.Run("without envelope", func(t *testing.T) {
+		products := &mockProductsRepository{products: []models.Product{
+			{Code: "PROD001", Price: decimal.RequireFromString("10.99"), Currency: "USD", Category: models.Category{Name: "Clothing"}},
+		}}
+		h := newTestHandler(&mockCategoriesRepository{categories: testCategories()}, products)
+
+		req := httptest.NewRequest(http.MethodGet, "/categories/CLOTHING/products?envelope=false&fields=code", nil)
+		req.SetPathValue("code", "CLOTHING")
+		recorder := httptest.NewRecorder()
+		h.GetCategoryProducts(recorder, req)
+
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		assert.Equal(t, "1", recorder.Header().Get("X-Total-Count"))
+		assert.JSONEq(t, `[{"code":"PROD001"}]`, recorder.Body.String())
+	})
+
+	t.Run("unknown category", func(t *testing.T) {
+		h := newTestHandler(&mockCategoriesRepository{categories: testCategories()}, &mockProductsRepository{})
+
+		req := httptest.NewRequest(http.MethodGet, "/categories/NOPE/products", nil)
+		req.SetPathValue("code", "NOPE")
+		recorder := httptest.NewRecorder()
+		h.GetCategoryProducts(recorder, req)
+
+		assert.Equal(t, http.StatusNotFound, recorder.Code)
+	})
+
+	t.Run("invalid query params", func(t *testing.T) {
+		h := newTestHandler(&mockCategoriesRepository{categories: testCategories()}, &mockProductsRepository{})
+
+		req := httptest.NewRequest(http.MethodGet, "/categories/CLOTHING/products?limit=abc", nil)
+		req.SetPathValue("code", "CLOTHING")
+		recorder := httptest.NewRecorder()
+		h.GetCategoryProducts(recorder, req)
+
+		assert.Equal(t, http.StatusBadRequest, recorder.Code)
+	})
+}
+
+func TestCreateCategoryConcurrently(t *testing.T) {
+	categories := &mockCategoriesRepository{categories: testCategories()}
+	h := newTestHandler(categories, &mockProductsRepository{})
+
+	const creates = 20
+	statuses := make(chan int, creates)
+	var wg sync.WaitGroup
+	for range creates {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			recorder := httptest.NewRecorder()
+			h.CreateCategory(recorder, httptest.NewRequest(http.MethodPost, "/categories", strings.NewReader(`{"code":"HATS","name":"Hats"}`)))
+			statuses <- recorder.Code
+		}()
+	}
+	wg.Wait()
+	close(statuses)
+
+	counts := map[int]int{}
+	for status := range statuses {
+		counts[status]++
+	}
+	assert.Equal(t, map[int]int{http.StatusCreated: 1, http.StatusConflict: creates - 1}, counts)
+	assert.Len(t, categories.categories, len(testCategories())+1)
+}
+
+func TestCreateCategory(t *testing.T) {
+	tests := []struct {
+		name     string
+		body     string
+		err      error
+		status   int
+		response string
+	}{
+		{name: "creates the category", body: `{"code":"HATS","name":"Hats"}`, status: http.StatusCreated, response: `{"code":"HATS","name":"Hats","product_count":0}`},
+		{name: "invalid code", body: `{"code":"my hats","name":"Hats"}`, status: http.StatusBadRequest, response: `{"errors":[{"field":"code","message":"code must be 3 to 50 characters of A-Z, 0-9, _ or -"}]}`},
+		{name: "name at the maximum length", body: `{"code":"HATS","name":"` + strings.Repeat("a", 200) + `"}`, status: http.StatusCreated, response: `{"code":"HATS","name":"` + strings.Repeat("a", 200) + `","product_count":0}`},
+		{name: "name too long", body: `{"code":"HATS","name":"` + strings.Repeat("a", 201) + `"}`, status: http.StatusBadRequest, response: `{"errors":[{"field":"name","message":"name exceeds maximum length of 200 characters"}]}`},
+		{name: "every invalid field is reported", body: `{"code":"h"}`, status: http.StatusBadRequest, response: `{"errors":[{"field":"code","message":"code must be 3 to 50 characters of A-Z, 0-9, _ or -"},{"field":"name","message":"name is required"}]}`},
+		{name: "missing name", body: `{"code":"HATS"}`, status: http.StatusBadRequest, response: `{"errors":[{"field":"name","message":"name is required"}]}`},
+		{name: "creates a child category", body: `{"code":"SANDALS","name":"Sandals","parent_code":"SHOES"}`, status: http.StatusCreated, response: `{"code":"SANDALS","name":"Sandals","parent_code":"SHOES","product_count":0}`},
+		{name: "unknown parent", body: `{"code":"SANDALS","name":"Sandals","parent_code":"NOPE"}`, status: http.StatusBadRequest, response: `{"errors":[{"field":"parent_code","message":"unknown parent category \"NOPE\""}]}`},
+		{name: "malformed body", body: `{`, status: http.StatusBadRequest, response: `{"error":"invalid request body"}`},
+		{name: "unknown field", body: `{"code":"HATS","namme":"Hats"}`, status: http.StatusBadRequest, response: `{"error":"unknown field: namme"}`},
+		{name: "duplicate code", body: `{"code":"HATS","name":"Hats"}`, err: gorm.ErrDuplicatedKey, status: http.StatusConflict, response: `{"error":"category HATS already exists"}`},
+		{name: "repository error", body: `{"code":"HATS","name":"Hats"}`, err: errors.New("boom"), status: http.StatusInternalServerError, response: `{"error":"internal server error"}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &mockCategoriesRepository{categories: testCategories(), err: tt.err}
+			events := &recordingPublisher{}
+			h := newTestHandlerWithEvents(repo, &mockProductsRepository{}, events)
+
+			recorder := httptest.NewRecorder()
+			h.CreateCategory(recorder, httptest.NewRequest(http.MethodPost, "/categories", strings.NewReader(tt.body)))
+
+			assert.Equal(t, tt.status, recorder.Code)
+			assert.JSONEq(t, tt.response, recorder.Body.String())
+			if tt.status == http.StatusCreated {
+				assert.Len(t, events.events, 1)
+				assert.Contains(t, events.events[0], "category.created ")
+				var created Category
+				assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &created))
+				assert.Equal(t, "/categories/"+created.Code, recorder.Header().Get("Location"))
+			} else {
+				assert.Empty(t, events.events)
+				assert.Empty(t, recorder.Header().Get("Location"))
+			}
+		})
+	}
+}
@@ -0,0 +1,281 @@
+// Package config reads the settings of the server from the environment.
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/eya20/hiring_test/app/catalog"
+	"github.com/eya20/hiring_test/app/database"
+	"github.com/shopspring/decimal"
+)
+
+// Config holds every setting of the server.
+type Config struct {
+	// Env is the deployment environment, e.g. development or production.
+	Env      string
+	HTTP     HTTP
+	Database Database
+	Catalog  Catalog
+	Debug    Debug
+	// TracingEndpoint is the OTLP collector spans are exported to; empty
+	// disables tracing.
+	TracingEndpoint string
+}
+
+// HTTP holds the settings of the HTTP server.
+type HTTP struct {
+	Port int
+	// MaxConcurrentRequests caps the requests served at once; 0 is no cap.
+	MaxConcurrentRequests int
+	// RequestTimeout bounds the handling of a request. Keep WriteTimeout
+	// above it, so requests cut short still get their error response.
+	RequestTimeout    time.Duration
+	ReadHeaderTimeout time.Duration
+	ReadTimeout       time.Duration
+	WriteTimeout      time.Duration
+	IdleTimeout       time.Duration
+	// MaxBodyBytes caps the size of request bodies; 0 is no cap.
+	MaxBodyBytes int64
+	// WriteToken is the bearer token the bulk write routes require; they
+	// reject every request while it is empty.
+	WriteToken string
+}
+
+// Addr is the address the server listens on.
+func (h HTTP) Addr() string {
+	return fmt.Sprintf("localhost:%d", h.Port)
+}
+
+// Database holds the connection, schema and repository settings.
+type Database struct {
+	Connection        database.Config
+	ConnectRetries    int
+	ConnectRetryDelay time.Duration
+	MigrationsDir     string
+	SkipMigrations    bool
+	// SQLDir holds the SQL scripts cmd/seed runs, in name order.
+	SQLDir string
+	// SeedFile is loaded on startup when set.
+	SeedFile string
+	// SlowQuery is the duration above which queries are logged as slow.
+	SlowQuery    time.Duration
+	RedactParams bool
+	// CaseInsensitiveCodes matches product codes regardless of case.
+	CaseInsensitiveCodes bool
+	// CategoriesCacheTTL is how long categories are cached; 0 disables the
+	// cache.
+	CategoriesCacheTTL time.Duration
+}
+
+// Catalog holds the settings of the catalog service and handlers.
+type Catalog struct {
+	Listing       catalog.Config
+	ExchangeRates catalog.ExchangeRates
+	Rounding      catalog.Rounding
+	MaxVariants   int
+	// PriceDeviationPercent warns about variants priced further than this
+	// from their product; 0 disables the warning.
+	PriceDeviationPercent float64
+	Shipping              catalog.WeightBasedCalculator
+}
+
+// Debug holds the debugging aids, to keep off in production.
+type Debug struct {
+	// JSON indents every response.
+	JSON bool
+	// QueryCount exposes the number of queries of a request in a header.
+	QueryCount bool
+}
+
+// ShowErrorDetails reports whether error responses may carry the underlying
+// error. Only named non-production environments do, so a deployment that
+// forgets APP_ENV doesn't leak connection details.
+func (c *Config) ShowErrorDetails() bool {
+	return c.Env != "" && c.Env != "production"
+}
+
+// Load reads the configuration from the environment, applying the defaults
+// of unset variables. The error lists every invalid variable, not just the
+// first one.
+func Load() (*Config, error) {
+	var e env
+	cfg := &Config{
+		Env: os.Getenv("APP_ENV"),
+		HTTP: HTTP{
+			Port:                  e.int("HTTP_PORT", 8484),
+			MaxConcurrentRequests: e.int("MAX_CONCURRENT_REQUESTS", 0),
+			RequestTimeout:        e.duration("REQUEST_TIMEOUT", 5*time.Second),
+			ReadHeaderTimeout:     e.duration("HTTP_READ_HEADER_TIMEOUT", 5*time.Second),
+			ReadTimeout:           e.duration("HTTP_READ_TIMEOUT", 10*time.Second),
+			WriteTimeout:          e.duration("HTTP_WRITE_TIMEOUT", 15*time.Second),
+			IdleTimeout:           e.duration("HTTP_IDLE_TIMEOUT", 60*time.Second),
+			MaxBodyBytes:          int64(e.int("MAX_BODY_BYTES", 1<<20)),
+			WriteToken:            os.Getenv("WRITE_API_TOKEN"),
+		},
+		Database: Database{
+			Connection:           e.connection(),
+			ConnectRetries:       e.int("DB_CONNECT_RETRIES", 10),
+			ConnectRetryDelay:    e.duration("DB_CONNECT_RETRY_DELAY", 500*time.Millisecond),
+			MigrationsDir:        os.Getenv("MIGRATIONS_DIR"),
+			SkipMigrations:       e.bool("SKIP_MIGRATIONS"),
+			SQLDir:               os.Getenv("POSTGRES_SQL_DIR"),
+			SeedFile:             os.Getenv("SEED_FILE"),
+			SlowQuery:            time.Duration(e.int("SLOW_QUERY_MS", 200)) * time.Millisecond,
+			RedactParams:         e.bool("SQL_REDACT_PARAMS"),
+			CaseInsensitiveCodes: e.bool("CASE_INSENSITIVE_CODES"),
+			CategoriesCacheTTL:   e.duration("CATEGORIES_CACHE_TTL", 60*time.Second),
+		},
+		Catalog: Catalog{
+			Listing: catalog.Config{
+				DefaultPageSize: e.int("DEFAULT_PAGE_SIZE", catalog.DefaultConfig().DefaultPageSize),
+				MaxPageSize:     e.int("MAX_PAGE_SIZE", catalog.DefaultConfig().MaxPageSize),
+				CacheSeconds:    e.int("CATALOG_CACHE_SECONDS", catalog.DefaultConfig().CacheSeconds),
+			},
+			MaxVariants:           e.int("MAX_VARIANTS_PER_PRODUCT", catalog.DefaultMaxVariants),
+			PriceDeviationPercent: e.float("VARIANT_PRICE_DEVIATION_PERCENT", 0),
+			Shipping: catalog.WeightBasedCalculator{
+				BaseFee: decimal.NewFromFloat(e.float("SHIPPING_BASE_FEE", 0)),
+				PerKg:   decimal.NewFromFloat(e.float("SHIPPING_PER_KG", 0)),
+			},
+		},
+		Debug: Debug{
+			JSON:       e.bool("DEBUG_JSON"),
+			QueryCount: e.bool("DEBUG_QUERY_COUNT"),
+		},
+		TracingEndpoint: os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT"),
+	}
+
+	if cfg.HTTP.Port < 1 || cfg.HTTP.Port > 65535 {
+		e.fail("HTTP_PORT", fmt.Errorf("must be between 1 and 65535, got %d", cfg.HTTP.Port))
+	}
+	if err := cfg.Catalog.Listing.Validate(); err != nil {
+		e.fail("DEFAULT_PAGE_SIZE, MAX_PAGE_SIZE or CATALOG_CACHE_SECONDS", err)
+	}
+
+	var err error
+	if cfg.Catalog.ExchangeRates, err = catalog.ParseExchangeRates(os.Getenv("EXCHANGE_RATES")); err != nil {
+		e.fail("EXCHANGE_RATES", err)
+	}
+	places := e.int("PRICE_ROUNDING_PLACES", 2)
+	if cfg.Catalog.Rounding, err = catalog.ParseRounding(os.Getenv("PRICE_ROUNDING_MODE"), places); err != nil {
+		e.fail("PRICE_ROUNDING_MODE or PRICE_ROUNDING_PLACES", err)
+	}
+	if cfg.Catalog.Shipping.Surcharges, err = catalog.ParseShippingSurcharges(os.Getenv("SHIPPING_SURCHARGES")); err != nil {
+		e.fail("SHIPPING_SURCHARGES", err)
+	}
+
+	if len(e.errs) > 0 {
+		return nil, fmt.Errorf("invalid configuration:\n%w", errors.Join(e.errs...))
+	}
+	return cfg, nil
+}
+
+// env reads variables, collecting the problems of every invalid one.
+type env struct {
+	errs []error
+}
+
+func (e *env) fail(key string, err error) {
+	e.errs = append(e.errs, fmt.Errorf("%s: %w", key, err))
+}
+
+// connection reads the connection settings from DATABASE_URL when it is
+// set, and from the POSTGRES_* vars otherwise, which must then name the
+// user, database and numeric port.
+func (e *env) connection() database.Config {
+	if raw := os.Getenv("DATABASE_URL"); raw != "" {
+		cfg, err := database.ParseURL(raw)
+		if err != nil {
+			e.fail("DATABASE_URL", err)
+		}
+		return cfg
+	}
+
+	cfg := database.Config{
+		Host:     os.Getenv("POSTGRES_HOST"),
+		User:     e.required("POSTGRES_USER"),
+		Password: os.Getenv("POSTGRES_PASSWORD"),
+		DBName:   e.required("POSTGRES_DB"),
+		Port:     e.required("POSTGRES_PORT"),
+		SSLMode:  os.Getenv("POSTGRES_SSLMODE"),
+	}
+	if cfg.Port != "" {
+		if _, err := strconv.ParseUint(cfg.Port, 10, 16); err != nil {
+			e.fail("POSTGRES_PORT", fmt.Errorf("must be a port number, got %q", cfg.Port))
+		}
+	}
+	return cfg
+}
+
+// required reads a variable that must be set.
+func (e *env) required(key string) string {
+	v := os.Getenv(key)
+	if v == "" {
+		e.fail(key, errors.New("required when DATABASE_URL is not set"))
+	}
+	return v
+}
+
+// int reads an integer variable, falling back to def when unset.
+func (e *env) int(key string, def int) int {
+	v := os.Getenv(key)
+	if v == "" {
+		return def
+	}
+
+	i, err := strconv.Atoi(v)
+	if err != nil {
+		e.fail(key, fmt.Errorf("must be an integer, got %q", v))
+		return def
+	}
+	return i
+}
+
+// float reads a numeric variable, falling back to def when unset.
+func (e *env) float(key string, def float64) float64 {
+	v := os.Getenv(key)
+	if v == "" {
+		return def
+	}
+
+	f, err := strconv.ParseFloat(v, 64)
+	if err != nil {
+		e.fail(key, fmt.Errorf("must be a number, got %q", v))
+		return def
+	}
+	return f
+}
+
+// duration reads a duration variable (e.g. "5s"), falling back to def when
+// unset.
+func (e *env) duration(key string, def time.Duration) time.Duration {
+	v := os.Getenv(key)
+	if v == "" {
+		return def
+	}
+
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		e.fail(key, fmt.Errorf("must be a duration such as 5s, got %q", v))
+		return def
+	}
+	return d
+}
+
+// bool reads a boolean variable, false when unset.
+func (e *env) bool(key string) bool {
+	v := os.Getenv(key)
+	if v == "" {
+		return false
+	}
+
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		e.fail(key, fmt.Errorf("must be true or false, got %q", v))
+	}
+	return b
+}
@@ -0,0 +1,89 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"log"
+	"log/slog"
+	"os"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// WithQueryLogger replaces GORM's default logger with one that reports slow
+// queries and query errors as structured records through l.
+//
+// Queries slower than slowThreshold are logged with their SQL and duration;
+// a zero threshold disables slow query logging. When redactParams is set the
+// SQL is logged with placeholders instead of the bound values, so no user
+// data ends up in the logs.
+func WithQueryLogger(l *slog.Logger, slowThreshold time.Duration, redactParams bool) Option {
+	return func(c *gorm.Config) {
+		c.Logger = newQueryLogger(l, slowThreshold, redactParams)
+	}
+}
+
+// queryLogger writes slow and failed queries to slog and leaves the plain
+// Info/Warn/Error messages to GORM's own logger.
+type queryLogger struct {
+	logger.Interface
+	log           *slog.Logger
+	slowThreshold time.Duration
+	redactParams  bool
+}
+
+func newQueryLogger(l *slog.Logger, slowThreshold time.Duration, redactParams bool) *queryLogger {
+	return &queryLogger{
+		Interface: logger.New(log.New(os.Stderr, "", log.LstdFlags), logger.Config{
+			SlowThreshold:        slowThreshold,
+			LogLevel:             logger.Warn,
+			ParameterizedQueries: redactParams,
+		}),
+		log:           l,
+		slowThreshold: slowThreshold,
+		redactParams:  redactParams,
+	}
+}
+
+func (l *queryLogger) LogMode(level logger.LogLevel) logger.Interface {
+	clone := *l
+	clone.Interface = l.Interface.LogMode(level)
+	return &clone
+}
+
+func (l *queryLogger) Trace(ctx context.Context, begin time.Time, fc func() (string, int64), err error) {
+	elapsed := time.Since(begin)
+	switch {
+	case err != nil && !errors.Is(err, logger.ErrRecordNotFound):
+		sql, rows := fc()
+		l.log.ErrorContext(ctx, "query failed",
+			slog.String("sql", sql),
+			slog.Int64("rows", rows),
+			slog.Float64("duration_ms", milliseconds(elapsed)),
+			slog.String("error", err.Error()),
+		)
+	case l.slowThreshold > 0 && elapsed > l.slowThreshold:
+		sql, rows := fc()
+		l.log.WarnContext(ctx, "slow query",
+			slog.String("sql", sql),
+			slog.Int64("rows", rows),
+			slog.Float64("duration_ms", milliseconds(elapsed)),
+			slog.Float64("threshold_ms", milliseconds(l.slowThreshold)),
+		)
+	}
+}
+
+// ParamsFilter drops the bound values when redaction is enabled, which makes
+// GORM render the SQL with placeholders.
+func (l *queryLogger) ParamsFilter(ctx context.Context, sql string, params ...any) (string, []any) {
+	if l.redactParams {
+		return sql, nil
+	}
+	return sql, params
+}
+
+func milliseconds(d time.Duration) float64 {
+	return float64(d.Nanoseconds()) / 1e6
+}
@@ -0,0 +1,73 @@
+package database
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func TestQueryLoggerTrace(t *testing.T) {
+	query := func() (string, int64) { return "SELECT * FROM products WHERE code = 'PROD001'", 1 }
+
+	tests := []struct {
+		name    string
+		elapsed time.Duration
+		err     error
+		level   string
+		msg     string
+	}{
+		{name: "slow query", elapsed: 300 * time.Millisecond, level: "WARN", msg: "slow query"},
+		{name: "fast query", elapsed: time.Millisecond},
+		{name: "failed query", elapsed: time.Millisecond, err: errors.New("boom"), level: "ERROR", msg: "query failed"},
+		{name: "record not found", elapsed: time.Millisecond, err: gorm.ErrRecordNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			l := newQueryLogger(slog.New(slog.NewJSONHandler(&buf, nil)), 200*time.Millisecond, false)
+
+			l.Trace(context.Background(), time.Now().Add(-tt.elapsed), query, tt.err)
+
+			if tt.msg == "" {
+				assert.Empty(t, buf.String())
+				return
+			}
+
+			var record map[string]any
+			require.NoError(t, json.Unmarshal(buf.Bytes(), &record))
+			assert.Equal(t, tt.level, record["level"])
+			assert.Equal(t, tt.msg, record["msg"])
+			assert.Equal(t, "SELECT * FROM products WHERE code = 'PROD001'", record["sql"])
+			assert.GreaterOrEqual(t, record["duration_ms"], float64(tt.elapsed.Milliseconds()))
+		})
+	}
+}
+
+func TestQueryLoggerDisabledThreshold(t *testing.T) {
+	var buf bytes.Buffer
+	l := newQueryLogger(slog.New(slog.NewJSONHandler(&buf, nil)), 0, false)
+
+	l.Trace(context.Background(), time.Now().Add(-time.Hour), func() (string, int64) { return "SELECT 1", 1 }, nil)
+
+	assert.Empty(t, buf.String())
+}
+
+func TestQueryLoggerParamsFilter(t *testing.T) {
+	l := newQueryLogger(slog.Default(), time.Second, false)
+	_, params := l.ParamsFilter(context.Background(), "SELECT $1", "secret")
+	assert.Equal(t, []any{"secret"}, params)
+
+	redacted := newQueryLogger(slog.Default(), time.Second, true)
+	sql, params := redacted.ParamsFilter(context.Background(), "SELECT $1", "secret")
+	assert.Equal(t, "SELECT $1", sql)
+	assert.Nil(t, params)
+}
@@ -0,0 +1,126 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// migration is a single numbered up migration read from disk.
+type migration struct {
+	version uint64
+	name    string
+	path    string
+}
+
+// Migrate applies every up migration in migrationsPath newer than the
+// database's current version, oldest first.
+//
+// The layout follows golang-migrate: files are named
+// {version}_{title}.up.sql / .down.sql and the current version is tracked
+// in a schema_migrations(version, dirty) table, so the same directory can
+// be driven by the migrate CLI, e.g. to roll back with the .down.sql files.
+func Migrate(db *sql.DB, migrationsPath string) error {
+	migrations, err := readMigrations(migrationsPath)
+	if err != nil {
+		return err
+	}
+
+	if _, err := db.Exec(`CREATE TABLE IF NOT EXISTS schema_migrations (version BIGINT NOT NULL PRIMARY KEY, dirty BOOLEAN NOT NULL)`); err != nil {
+		return fmt.Errorf("creating schema_migrations: %w", err)
+	}
+
+	var (
+		current uint64
+		dirty   bool
+	)
+	err = db.QueryRow(`SELECT version, dirty FROM schema_migrations LIMIT 1`).Scan(&current, &dirty)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("reading schema version: %w", err)
+	}
+	if dirty {
+		return fmt.Errorf("database is dirty at version %d, fix it manually and force the version", current)
+	}
+
+	for _, m := range migrations {
+		if m.version <= current {
+			continue
+		}
+		if err := apply(db, m); err != nil {
+			return fmt.Errorf("applying migration %s: %w", m.name, err)
+		}
+	}
+	return nil
+}
+
+// apply runs m and records its version in a single transaction, so a
+// failing migration leaves the schema at the previous version.
+func apply(db *sql.DB, m migration) error {
+	content, err := os.ReadFile(m.path)
+	if err != nil {
+		return err
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(string(content)); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`DELETE FROM schema_migrations`); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`INSERT INTO schema_migrations (version, dirty) VALUES ($1, FALSE)`, m.version); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// readMigrations lists the up migrations in dir sorted by version.
+func readMigrations(dir string) ([]migration, error) {
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("reading migrations directory: %w", err)
+	}
+
+	var migrations []migration
+	seen := map[uint64]string{}
+	for _, file := range files {
+		name := file.Name()
+		if file.IsDir() || !strings.HasSuffix(name, ".up.sql") {
+			continue
+		}
+
+		prefix, _, ok := strings.Cut(name, "_")
+		if !ok {
+			return nil, fmt.Errorf("invalid migration file name %q", name)
+		}
+		version, err := strconv.ParseUint(prefix, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid migration file name %q", name)
+		}
+		if other, ok := seen[version]; ok {
+			return nil, fmt.Errorf("duplicate migration version %d: %s and %s", version, other, name)
+		}
+		seen[version] = name
+
+		migrations = append(migrations, migration{
+			version: version,
+			name:    name,
+			path:    filepath.Join(dir, name),
+		})
+	}
+
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].version < migrations[j].version
+	})
+	return migrations, nil
+}
@@ -0,0 +1,59 @@
+package database
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadMigrations(t *testing.T) {
+	t.Run("sorted by version, up files only", func(t *testing.T) {
+		dir := t.TempDir()
+		for _, name := range []string{"000010_b.up.sql", "000002_a.up.sql", "000002_a.down.sql", "README.md"} {
+			require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte("SELECT 1;"), 0o644))
+		}
+
+		migrations, err := readMigrations(dir)
+
+		require.NoError(t, err)
+		require.Len(t, migrations, 2)
+		assert.Equal(t, uint64(2), migrations[0].version)
+		assert.Equal(t, "000002_a.up.sql", migrations[0].name)
+		assert.Equal(t, uint64(10), migrations[1].version)
+	})
+
+	t.Run("invalid version", func(t *testing.T) {
+		dir := t.TempDir()
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "first_a.up.sql"), nil, 0o644))
+
+		_, err := readMigrations(dir)
+
+		assert.ErrorContains(t, err, "invalid migration file name")
+	})
+
+	t.Run("duplicate version", func(t *testing.T) {
+		dir := t.TempDir()
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "1_a.up.sql"), nil, 0o644))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "01_b.up.sql"), nil, 0o644))
+
+		_, err := readMigrations(dir)
+
+		assert.ErrorContains(t, err, "duplicate migration version 1")
+	})
+
+	t.Run("repository migrations have a down file", func(t *testing.T) {
+		dir := filepath.Join("..", "..", "migrations")
+		migrations, err := readMigrations(dir)
+
+		require.NoError(t, err)
+		assert.NotEmpty(t, migrations)
+		for _, m := range migrations {
+			down := strings.TrimSuffix(m.path, ".up.sql") + ".down.sql"
+			assert.FileExists(t, down)
+		}
+	})
+}
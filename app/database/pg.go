@@ -1,26 +1,115 @@
 package database
 
 import (
+	"errors"
 	"fmt"
 	"log"
+	"net"
+	"net/url"
+	"strings"
 
 	_ "github.com/lib/pq"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 )
 
-func New(user, password, dbname, port string) (db *gorm.DB, close func() error) {
-	dsn := fmt.Sprintf("postgres://%s:%s@localhost:%s/%s?sslmode=disable", user, password, port, dbname)
+// Option customises the GORM configuration used by New.
+type Option func(*gorm.Config)
 
-	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
+// Defaults used for empty Config fields, which suit a local database
+// without TLS.
+const (
+	defaultHost    = "localhost"
+	defaultSSLMode = "disable"
+)
+
+// Config holds the PostgreSQL connection settings.
+type Config struct {
+	// Host is the database server's host name or address. Defaults to
+	// localhost.
+	Host     string
+	User     string
+	Password string
+	DBName   string
+	Port     string
+
+	// SSLMode is the libpq sslmode, e.g. disable, require or verify-full.
+	// Managed databases such as RDS or Cloud SQL usually need require or
+	// stricter. Defaults to disable.
+	SSLMode string
+
+	// URL is a complete connection string, such as the DATABASE_URL set by
+	// Heroku, Render or Railway. When set, the other fields are ignored.
+	URL string
+}
+
+// ParseURL returns the Config for a postgres:// or postgresql:// connection
+// URL, checking that it names a host and a database. Errors never include
+// the URL itself, as it usually holds a password.
+func ParseURL(raw string) (Config, error) {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return Config{}, errors.New("malformed database URL")
+	}
+	if u.Scheme != "postgres" && u.Scheme != "postgresql" {
+		return Config{}, fmt.Errorf("database URL scheme must be postgres or postgresql, got %q", u.Scheme)
+	}
+	if u.Hostname() == "" {
+		return Config{}, errors.New("database URL has no host")
+	}
+	if strings.Trim(u.Path, "/") == "" {
+		return Config{}, errors.New("database URL has no database name")
+	}
+	if _, err := url.ParseQuery(u.RawQuery); err != nil {
+		return Config{}, errors.New("database URL has a malformed query")
+	}
+	return Config{URL: raw}, nil
+}
+
+// DSN returns the connection string for c.
+func (c Config) DSN() string {
+	if c.URL != "" {
+		return c.URL
+	}
+	host := c.Host
+	if host == "" {
+		host = defaultHost
+	}
+	sslMode := c.SSLMode
+	if sslMode == "" {
+		sslMode = defaultSSLMode
+	}
+	return fmt.Sprintf("postgres://%s:%s@%s/%s?sslmode=%s", c.User, c.Password, net.JoinHostPort(host, c.Port), c.DBName, url.QueryEscape(sslMode))
+}
+
+func New(cfg Config, opts ...Option) (db *gorm.DB, close func() error) {
+	db, close, err := open(cfg, opts...)
 	if err != nil {
 		log.Fatalf("failed to connect database: %s", err)
 	}
+	return db, close
+}
+
+// open connects to the database described by cfg. GORM pings the server on
+// open, so an unreachable database is reported here rather than on the first
+// query.
+func open(cfg Config, opts ...Option) (*gorm.DB, func() error, error) {
+	// TranslateError maps constraint violations to gorm errors such as
+	// gorm.ErrDuplicatedKey, so callers don't depend on driver error codes.
+	config := &gorm.Config{TranslateError: true}
+	for _, opt := range opts {
+		opt(config)
+	}
+
+	db, err := gorm.Open(postgres.Open(cfg.DSN()), config)
+	if err != nil {
+		return nil, nil, err
+	}
 
 	sqlDB, err := db.DB()
 	if err != nil {
-		log.Fatalf("Failed to get database connection: %s", err)
+		return nil, nil, fmt.Errorf("getting database connection: %w", err)
 	}
 
-	return db, sqlDB.Close
+	return db, sqlDB.Close, nil
 }
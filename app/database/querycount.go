@@ -0,0 +1,59 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+
+	"gorm.io/gorm"
+)
+
+type queryCountKey struct{}
+
+// WithQueryCounter returns a copy of ctx that counts the queries run with it,
+// and its descendants, once QueryCounter is registered. The count is read
+// with QueryCount.
+func WithQueryCounter(ctx context.Context) context.Context {
+	return context.WithValue(ctx, queryCountKey{}, new(atomic.Int64))
+}
+
+// QueryCount returns the number of queries run so far with ctx, 0 when it
+// doesn't count them.
+func QueryCount(ctx context.Context) int64 {
+	if n, ok := ctx.Value(queryCountKey{}).(*atomic.Int64); ok {
+		return n.Load()
+	}
+	return 0
+}
+
+// QueryCounter is a GORM plugin counting every query in the counter of its
+// statement context, see WithQueryCounter. Preloads are queries of their
+// own, so an N+1 shows up as a count growing with the result size.
+type QueryCounter struct{}
+
+func (QueryCounter) Name() string {
+	return "querycount"
+}
+
+func (QueryCounter) Initialize(db *gorm.DB) error {
+	cb := db.Callback()
+
+	errs := []error{
+		cb.Create().After("gorm:create").Register("querycount:create", countQuery),
+		cb.Query().After("gorm:query").Register("querycount:query", countQuery),
+		cb.Update().After("gorm:update").Register("querycount:update", countQuery),
+		cb.Delete().After("gorm:delete").Register("querycount:delete", countQuery),
+		cb.Row().After("gorm:row").Register("querycount:row", countQuery),
+		cb.Raw().After("gorm:raw").Register("querycount:raw", countQuery),
+	}
+	return errors.Join(errs...)
+}
+
+func countQuery(db *gorm.DB) {
+	if db.Statement.Context == nil {
+		return
+	}
+	if n, ok := db.Statement.Context.Value(queryCountKey{}).(*atomic.Int64); ok {
+		n.Add(1)
+	}
+}
@@ -0,0 +1,55 @@
+package database
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func TestQueryCounter(t *testing.T) {
+	// A dry run builds the statements and runs the callbacks without a
+	// database.
+	db, err := gorm.Open(postgres.New(postgres.Config{DSN: "host=localhost"}), &gorm.Config{
+		DryRun:               true,
+		DisableAutomaticPing: true,
+		Logger:               logger.Discard,
+	})
+	require.NoError(t, err)
+	require.NoError(t, db.Use(QueryCounter{}))
+
+	type product struct {
+		ID   uint
+		Code string
+	}
+
+	t.Run("counts the queries of the context", func(t *testing.T) {
+		ctx := WithQueryCounter(context.Background())
+
+		db.WithContext(ctx).Find(&[]product{})
+		db.WithContext(ctx).Create(&product{Code: "PROD001"})
+		db.WithContext(ctx).Model(&product{}).Where("id = ?", 1).Update("code", "PROD002")
+		db.WithContext(ctx).Exec("SELECT 1")
+
+		assert.Equal(t, int64(4), QueryCount(ctx))
+	})
+
+	t.Run("contexts count separately", func(t *testing.T) {
+		first, second := WithQueryCounter(context.Background()), WithQueryCounter(context.Background())
+
+		db.WithContext(first).Find(&[]product{})
+
+		assert.Equal(t, int64(1), QueryCount(first))
+		assert.Zero(t, QueryCount(second))
+	})
+
+	t.Run("contexts without a counter", func(t *testing.T) {
+		db.WithContext(context.Background()).Find(&[]product{})
+
+		assert.Zero(t, QueryCount(context.Background()))
+	})
+}
@@ -0,0 +1,53 @@
+package database
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// maxRetryDelay caps the backoff between connection attempts, so retrying
+// forever keeps probing at a steady pace.
+const maxRetryDelay = 30 * time.Second
+
+// NewWithRetry connects like New but retries when the database is not
+// reachable yet, e.g. while its container is still starting. It sleeps
+// baseDelay * 2^attempt between attempts and gives up after maxRetries
+// retries; 0 retries forever.
+func NewWithRetry(cfg Config, maxRetries int, baseDelay time.Duration, opts ...Option) (*gorm.DB, func() error, error) {
+	var (
+		db    *gorm.DB
+		close func() error
+	)
+	err := retry(maxRetries, baseDelay, time.Sleep, func() error {
+		var err error
+		db, close, err = open(cfg, opts...)
+		return err
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return db, close, nil
+}
+
+// retry calls fn until it succeeds or maxRetries retries have failed.
+func retry(maxRetries int, baseDelay time.Duration, sleep func(time.Duration), fn func() error) error {
+	for attempt := 0; ; attempt++ {
+		err := fn()
+		if err == nil {
+			return nil
+		}
+		if maxRetries > 0 && attempt >= maxRetries {
+			return fmt.Errorf("connecting to database, giving up after %d attempts: %w", attempt+1, err)
+		}
+
+		delay := maxRetryDelay
+		if attempt < 32 {
+			delay = min(baseDelay<<attempt, maxRetryDelay)
+		}
+		log.Printf("connecting to database failed (attempt %d), retrying in %s: %s", attempt+1, delay, err)
+		sleep(delay)
+	}
+}
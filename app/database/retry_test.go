@@ -0,0 +1,60 @@
+package database
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRetry(t *testing.T) {
+	errDown := errors.New("connection refused")
+
+	t.Run("succeeds after failures with exponential backoff", func(t *testing.T) {
+		var delays []time.Duration
+		calls := 0
+
+		err := retry(5, 100*time.Millisecond, func(d time.Duration) { delays = append(delays, d) }, func() error {
+			calls++
+			if calls < 4 {
+				return errDown
+			}
+			return nil
+		})
+
+		assert.NoError(t, err)
+		assert.Equal(t, 4, calls)
+		assert.Equal(t, []time.Duration{100 * time.Millisecond, 200 * time.Millisecond, 400 * time.Millisecond}, delays)
+	})
+
+	t.Run("gives up after max retries", func(t *testing.T) {
+		calls := 0
+
+		err := retry(2, time.Millisecond, func(time.Duration) {}, func() error {
+			calls++
+			return errDown
+		})
+
+		assert.ErrorIs(t, err, errDown)
+		assert.ErrorContains(t, err, "giving up after 3 attempts")
+		assert.Equal(t, 3, calls)
+	})
+
+	t.Run("zero retries forever with a capped delay", func(t *testing.T) {
+		var last time.Duration
+		calls := 0
+
+		err := retry(0, time.Second, func(d time.Duration) { last = d }, func() error {
+			calls++
+			if calls < 100 {
+				return errDown
+			}
+			return nil
+		})
+
+		assert.NoError(t, err)
+		assert.Equal(t, 100, calls)
+		assert.Equal(t, maxRetryDelay, last)
+	})
+}
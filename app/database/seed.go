@@ -0,0 +1,167 @@
+package database
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/eya20/hiring_test/models"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// seedFile is the layout of the JSON files read by SeedFromFile.
+type seedFile struct {
+	Categories []seedCategory `json:"categories"`
+	Products   []seedProduct  `json:"products"`
+}
+
+type seedCategory struct {
+	Code string `json:"code"`
+	Name string `json:"name"`
+}
+
+type seedProduct struct {
+	Code     string          `json:"code"`
+	SKU      string          `json:"sku"`
+	Price    decimal.Decimal `json:"price"`
+	Currency string          `json:"currency"`
+	Category string          `json:"category"`
+	Featured bool            `json:"featured"`
+	Variants []seedVariant   `json:"variants"`
+}
+
+type seedVariant struct {
+	Name      string           `json:"name"`
+	SKU       string           `json:"sku"`
+	Price     decimal.Decimal  `json:"price"`
+	SalePrice *decimal.Decimal `json:"sale_price"`
+	Stock     int              `json:"stock"`
+}
+
+// SeedFromFile inserts the categories, products and variants described by
+// the JSON file at path, in a single transaction.
+//
+// Seeding is idempotent: categories and products whose code already exists
+// are skipped, along with the variants of skipped products. Products refer to
+// their category by code, which may be seeded by the same file or already be
+// in the database.
+func SeedFromFile(db *gorm.DB, path string) error {
+	seed, err := readSeedFile(path)
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	txm := models.NewTransactionManager(db)
+	tx, err := txm.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer txm.Rollback(tx)
+
+	if err := insertSeed(ctx, tx, models.NewProductsRepository(db).WithTx(tx), seed); err != nil {
+		return err
+	}
+	return txm.Commit(tx)
+}
+
+// insertSeed inserts seed in the transaction tx, products and variants
+// through products, which must run in tx too.
+func insertSeed(ctx context.Context, tx *gorm.DB, products models.ProductsRepositoryInterface, seed seedFile) error {
+	for _, c := range seed.Categories {
+		category := models.Category{Code: c.Code, Name: c.Name}
+		err := tx.Clauses(clause.OnConflict{Columns: []clause.Column{{Name: "code"}}, DoNothing: true}).Create(&category).Error
+		if err != nil {
+			return fmt.Errorf("seeding category %s: %w", c.Code, err)
+		}
+	}
+
+	categoryIDs := map[string]uint{}
+	for _, p := range seed.Products {
+		var count int64
+		if err := tx.Model(&models.Product{}).Where("code = ?", p.Code).Count(&count).Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			continue
+		}
+
+		product := models.Product{
+			Code:     p.Code,
+			SKU:      p.SKU,
+			Price:    p.Price,
+			Currency: p.Currency,
+			Featured: p.Featured,
+		}
+		if product.Currency == "" {
+			product.Currency = "USD"
+		}
+		if p.Category != "" {
+			id, ok := categoryIDs[p.Category]
+			if !ok {
+				var category models.Category
+				if err := tx.Where("code = ?", p.Category).First(&category).Error; err != nil {
+					return fmt.Errorf("seeding product %s: category %s: %w", p.Code, p.Category, err)
+				}
+				id = category.ID
+				categoryIDs[p.Category] = id
+			}
+			product.CategoryID = &id
+		}
+		if err := products.CreateProduct(ctx, &product); err != nil {
+			return fmt.Errorf("seeding product %s: %w", p.Code, err)
+		}
+
+		for _, v := range p.Variants {
+			variant := models.Variant{
+				ProductID: product.ID,
+				Name:      v.Name,
+				SKU:       v.SKU,
+				Price:     v.Price,
+				SalePrice: v.SalePrice,
+				Stock:     v.Stock,
+			}
+			if err := products.CreateVariant(ctx, &variant); err != nil {
+				return fmt.Errorf("seeding variant %s: %w", v.SKU, err)
+			}
+		}
+	}
+	return nil
+}
+
+// readSeedFile parses and sanity checks a seed file.
+func readSeedFile(path string) (seedFile, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return seedFile{}, fmt.Errorf("reading seed file: %w", err)
+	}
+	defer f.Close()
+
+	var seed seedFile
+	dec := json.NewDecoder(f)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&seed); err != nil {
+		return seedFile{}, fmt.Errorf("parsing seed file %s: %w", path, err)
+	}
+
+	for _, c := range seed.Categories {
+		if c.Code == "" || c.Name == "" {
+			return seedFile{}, errors.New("seed file: every category needs a code and a name")
+		}
+	}
+	for _, p := range seed.Products {
+		if p.Code == "" {
+			return seedFile{}, errors.New("seed file: every product needs a code")
+		}
+		for _, v := range p.Variants {
+			if v.Name == "" || v.SKU == "" {
+				return seedFile{}, fmt.Errorf("seed file: every variant of product %s needs a name and a SKU", p.Code)
+			}
+		}
+	}
+	return seed, nil
+}
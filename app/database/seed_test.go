@@ -0,0 +1,61 @@
+package database
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadSeedFile(t *testing.T) {
+	write := func(t *testing.T, content string) string {
+		path := filepath.Join(t.TempDir(), "seed.json")
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+		return path
+	}
+
+	t.Run("repository seed file", func(t *testing.T) {
+		seed, err := readSeedFile(filepath.Join("..", "..", "sql", "seed.json"))
+
+		require.NoError(t, err)
+		assert.NotEmpty(t, seed.Categories)
+		require.NotEmpty(t, seed.Products)
+		assert.True(t, seed.Products[0].Price.Equal(decimal.RequireFromString("10.99")))
+	})
+
+	t.Run("numeric prices", func(t *testing.T) {
+		seed, err := readSeedFile(write(t, `{"products":[{"code":"PROD001","price":10.5,"variants":[{"name":"A","sku":"SKU001A","price":11}]}]}`))
+
+		require.NoError(t, err)
+		assert.True(t, seed.Products[0].Price.Equal(decimal.RequireFromString("10.5")))
+		assert.True(t, seed.Products[0].Variants[0].Price.Equal(decimal.NewFromInt(11)))
+	})
+
+	tests := []struct {
+		name    string
+		content string
+		err     string
+	}{
+		{name: "malformed", content: `{`, err: "parsing seed file"},
+		{name: "unknown field", content: `{"product":[]}`, err: "unknown field"},
+		{name: "category without name", content: `{"categories":[{"code":"HATS"}]}`, err: "every category needs a code and a name"},
+		{name: "product without code", content: `{"products":[{"price":1}]}`, err: "every product needs a code"},
+		{name: "variant without sku", content: `{"products":[{"code":"PROD001","variants":[{"name":"A"}]}]}`, err: "every variant of product PROD001 needs a name and a SKU"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := readSeedFile(write(t, tt.content))
+
+			assert.ErrorContains(t, err, tt.err)
+		})
+	}
+
+	t.Run("missing file", func(t *testing.T) {
+		_, err := readSeedFile(filepath.Join(t.TempDir(), "missing.json"))
+
+		assert.ErrorContains(t, err, "reading seed file")
+	})
+}
@@ -0,0 +1,48 @@
+// Package docs serves the OpenAPI description of the API and a Swagger UI
+// to browse it.
+package docs
+
+import (
+	_ "embed"
+	"net/http"
+)
+
+// spec is the OpenAPI 3.0 document. Keep it in sync with the routes
+// registered in cmd/server when adding or changing endpoints.
+//
+//go:embed openapi.json
+var spec []byte
+
+// swaggerUI renders the spec served at /openapi.json with Swagger UI.
+const swaggerUI = `<!DOCTYPE html>
+<html lang="en">
+<head>
+  <meta charset="utf-8">
+  <title>Catalog API</title>
+  <link rel="stylesheet" href="https://unpkg.com/swagger-ui-dist@5/swagger-ui.css">
+</head>
+<body>
+  <div id="swagger-ui"></div>
+  <script src="https://unpkg.com/swagger-ui-dist@5/swagger-ui-bundle.js"></script>
+  <script>
+    window.ui = SwaggerUIBundle({ url: "/openapi.json", dom_id: "#swagger-ui" });
+  </script>
+</body>
+</html>
+`
+
+type DocsHandler struct{}
+
+func NewDocsHandler() *DocsHandler {
+	return &DocsHandler{}
+}
+
+func (h *DocsHandler) GetSpec(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(spec)
+}
+
+func (h *DocsHandler) GetUI(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Write([]byte(swaggerUI))
+}
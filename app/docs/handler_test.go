@@ -0,0 +1,43 @@
+package docs
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetSpec(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	NewDocsHandler().GetSpec(recorder, httptest.NewRequest(http.MethodGet, "/openapi.json", nil))
+
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, "application/json", recorder.Header().Get("Content-Type"))
+
+	var doc struct {
+		OpenAPI string                                `json:"openapi"`
+		Paths   map[string]map[string]json.RawMessage `json:"paths"`
+	}
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &doc))
+	assert.Equal(t, "3.0.3", doc.OpenAPI)
+
+	for path, method := range map[string]string{
+		"/catalog":        "get",
+		"/catalog/{code}": "get",
+		"/categories":     "post",
+	} {
+		assert.Contains(t, doc.Paths[path], method, path)
+	}
+	assert.Contains(t, doc.Paths["/categories"], "get")
+}
+
+func TestGetUI(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	NewDocsHandler().GetUI(recorder, httptest.NewRequest(http.MethodGet, "/docs", nil))
+
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Contains(t, recorder.Body.String(), `url: "/openapi.json"`)
+}
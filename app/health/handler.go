@@ -0,0 +1,118 @@
+// Package health serves the liveness and readiness probes used by the
+// orchestrator.
+package health
+
+import (
+	"context"
+	"encoding/xml"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/eya20/hiring_test/app/api"
+)
+
+// pingTimeout bounds the database check of a health probe, so a hanging
+// connection fails the probe rather than the probe timing out.
+const pingTimeout = 2 * time.Second
+
+// Pinger checks that a dependency is reachable. *sql.DB implements it.
+type Pinger interface {
+	PingContext(ctx context.Context) error
+}
+
+// BuildInfo identifies the running binary. Both fields are set at build time
+// with -ldflags, see the Makefile.
+type BuildInfo struct {
+	Version string `json:"version,omitempty" xml:"version,omitempty"`
+	Commit  string `json:"commit,omitempty" xml:"commit,omitempty"`
+}
+
+type Status struct {
+	XMLName xml.Name `json:"-" xml:"health"`
+	Status  string   `json:"status" xml:"status"`
+	BuildInfo
+}
+
+// Readiness is the body of the Ready probe.
+type Readiness struct {
+	XMLName xml.Name `json:"-" xml:"readiness"`
+	Ready   bool     `json:"ready" xml:"ready"`
+}
+
+// DependencyStatus is the state of a dependency in a Detail report: "ok" or
+// "unavailable", and how long the check took.
+type DependencyStatus struct {
+	Status    string `json:"status" xml:"status"`
+	LatencyMs int64  `json:"latency_ms" xml:"latency_ms"`
+}
+
+// Detail reports the state of every dependency. Status is "ok" when all of
+// them are, "unavailable" otherwise.
+type Detail struct {
+	XMLName  xml.Name         `json:"-" xml:"health"`
+	Status   string           `json:"status" xml:"status"`
+	Database DependencyStatus `json:"database" xml:"database"`
+}
+
+type HealthHandler struct {
+	db    Pinger
+	build BuildInfo
+}
+
+func NewHealthHandler(db Pinger, build BuildInfo) *HealthHandler {
+	return &HealthHandler{
+		db:    db,
+		build: build,
+	}
+}
+
+// Ready is the liveness probe: it succeeds as long as the server accepts
+// connections, without touching the database, so a database outage doesn't
+// get the pod restarted.
+func (h *HealthHandler) Ready(w http.ResponseWriter, r *http.Request) {
+	api.OKResponse(w, Readiness{Ready: true})
+}
+
+// Health is the readiness probe: it responds 503 while the database is
+// unreachable, so no traffic is routed to the instance meanwhile. It reports
+// the build of the binary, so operators can check what is deployed.
+func (h *HealthHandler) Health(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), pingTimeout)
+	defer cancel()
+
+	if err := h.db.PingContext(ctx); err != nil {
+		log.Printf("health check failed: %s", err)
+		api.ErrorResponse(w, http.StatusServiceUnavailable, "database unavailable")
+		return
+	}
+	api.OKResponse(w, Status{Status: "ok", BuildInfo: h.build})
+}
+
+// Detail reports the status and latency of each dependency, for operators
+// debugging an incident. It always responds 200: the report is in the body,
+// and load balancers should keep using /health and /ready.
+func (h *HealthHandler) Detail(w http.ResponseWriter, r *http.Request) {
+	database := h.check(r.Context(), "database", h.db)
+
+	status := "ok"
+	if database.Status != "ok" {
+		status = "unavailable"
+	}
+	api.OKResponse(w, Detail{Status: status, Database: database})
+}
+
+// check pings dep, bounded by pingTimeout, and times it.
+func (h *HealthHandler) check(ctx context.Context, name string, dep Pinger) DependencyStatus {
+	ctx, cancel := context.WithTimeout(ctx, pingTimeout)
+	defer cancel()
+
+	start := time.Now()
+	err := dep.PingContext(ctx)
+	latency := time.Since(start).Milliseconds()
+	if err != nil {
+		log.Printf("health check of %s failed: %s", name, err)
+		return DependencyStatus{Status: "unavailable", LatencyMs: latency}
+	}
+	return DependencyStatus{Status: "ok", LatencyMs: latency}
+}
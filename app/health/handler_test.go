@@ -0,0 +1,101 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type pingerFunc func(ctx context.Context) error
+
+func (f pingerFunc) PingContext(ctx context.Context) error {
+	return f(ctx)
+}
+
+var (
+	reachable   = pingerFunc(func(ctx context.Context) error { return nil })
+	unreachable = pingerFunc(func(ctx context.Context) error { return errors.New("connection refused") })
+)
+
+func TestReady(t *testing.T) {
+	for name, db := range map[string]Pinger{"database up": reachable, "database down": unreachable} {
+		recorder := httptest.NewRecorder()
+		NewHealthHandler(db, BuildInfo{}).Ready(recorder, httptest.NewRequest(http.MethodGet, "/ready", nil))
+
+		assert.Equal(t, http.StatusOK, recorder.Code, name)
+		assert.JSONEq(t, `{"ready":true}`, recorder.Body.String(), name)
+	}
+}
+
+func TestHealth(t *testing.T) {
+	t.Run("database reachable", func(t *testing.T) {
+		recorder := httptest.NewRecorder()
+		NewHealthHandler(reachable, BuildInfo{}).Health(recorder, httptest.NewRequest(http.MethodGet, "/health", nil))
+
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		assert.JSONEq(t, `{"status":"ok"}`, recorder.Body.String())
+	})
+
+	t.Run("reports the build", func(t *testing.T) {
+		recorder := httptest.NewRecorder()
+		NewHealthHandler(reachable, BuildInfo{Version: "v1.4.0-3-gabc1234", Commit: "abc1234def"}).Health(recorder, httptest.NewRequest(http.MethodGet, "/health", nil))
+
+		var status Status
+		assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &status))
+		assert.Equal(t, "ok", status.Status)
+		assert.JSONEq(t, `{"status":"ok","version":"v1.4.0-3-gabc1234","commit":"abc1234def"}`, recorder.Body.String())
+	})
+
+	t.Run("database unreachable", func(t *testing.T) {
+		recorder := httptest.NewRecorder()
+		NewHealthHandler(unreachable, BuildInfo{}).Health(recorder, httptest.NewRequest(http.MethodGet, "/health", nil))
+
+		assert.Equal(t, http.StatusServiceUnavailable, recorder.Code)
+		assert.JSONEq(t, `{"error":"database unavailable"}`, recorder.Body.String())
+	})
+
+	t.Run("ping is bounded by a deadline", func(t *testing.T) {
+		var deadline bool
+		db := pingerFunc(func(ctx context.Context) error {
+			_, deadline = ctx.Deadline()
+			return nil
+		})
+
+		NewHealthHandler(db, BuildInfo{}).Health(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/health", nil))
+
+		assert.True(t, deadline)
+	})
+}
+
+func TestDetail(t *testing.T) {
+	t.Run("database reachable", func(t *testing.T) {
+		db := pingerFunc(func(ctx context.Context) error {
+			time.Sleep(5 * time.Millisecond)
+			return nil
+		})
+
+		recorder := httptest.NewRecorder()
+		NewHealthHandler(db, BuildInfo{}).Detail(recorder, httptest.NewRequest(http.MethodGet, "/health/detail", nil))
+
+		var detail Detail
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &detail))
+		assert.Equal(t, "ok", detail.Status)
+		assert.Equal(t, "ok", detail.Database.Status)
+		assert.GreaterOrEqual(t, detail.Database.LatencyMs, int64(5))
+	})
+
+	t.Run("database unreachable", func(t *testing.T) {
+		recorder := httptest.NewRecorder()
+		NewHealthHandler(unreachable, BuildInfo{}).Detail(recorder, httptest.NewRequest(http.MethodGet, "/health/detail", nil))
+
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		assert.JSONEq(t, `{"status":"unavailable","database":{"status":"unavailable","latency_ms":0}}`, recorder.Body.String())
+	})
+}
@@ -0,0 +1,14 @@
+package middleware
+
+import "net/http"
+
+// Chain composes middlewares into a single one that applies them in order,
+// the first being the outermost: Chain(a, b)(h) is a(b(h)).
+func Chain(middlewares ...func(http.Handler) http.Handler) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		for i := len(middlewares) - 1; i >= 0; i-- {
+			next = middlewares[i](next)
+		}
+		return next
+	}
+}
@@ -0,0 +1,37 @@
+package middleware
+
+import (
+	"net/http"
+	"net/url"
+	"path"
+)
+
+// CleanPath serves requests for unclean paths as if they had been made to
+// their clean form: /catalog/ is handled as /catalog and /catalog//PROD001 as
+// /catalog/PROD001. Without it, ServeMux answers 404 for trailing slashes and
+// redirects on repeated slashes.
+func CleanPath(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		p := r.URL.Path
+		if p == "" || p == "/" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		clean := path.Clean(p)
+		if clean == p {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		// Shallow copy the request as http.StripPrefix does, so the
+		// caller's URL is left untouched.
+		r2 := new(http.Request)
+		*r2 = *r
+		r2.URL = new(url.URL)
+		*r2.URL = *r.URL
+		r2.URL.Path = clean
+		r2.URL.RawPath = ""
+		next.ServeHTTP(w, r2)
+	})
+}
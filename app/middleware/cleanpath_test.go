@@ -0,0 +1,48 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCleanPath(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /catalog", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("catalog"))
+	})
+	mux.HandleFunc("GET /catalog/{code}", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("product " + r.PathValue("code")))
+	})
+	mux.HandleFunc("GET /", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("root"))
+	})
+	h := CleanPath(mux)
+
+	tests := []struct {
+		path string
+		body string
+	}{
+		{path: "/catalog", body: "catalog"},
+		{path: "/catalog/", body: "catalog"},
+		{path: "/catalog//", body: "catalog"},
+		{path: "/catalog/PROD001", body: "product PROD001"},
+		{path: "/catalog/PROD001/", body: "product PROD001"},
+		{path: "/catalog//PROD001", body: "product PROD001"},
+		{path: "/catalog?limit=5", body: "catalog"},
+		{path: "/catalog/?limit=5", body: "catalog"},
+		{path: "/", body: "root"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			h.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+			assert.Equal(t, http.StatusOK, recorder.Code)
+			assert.Equal(t, tt.body, recorder.Body.String())
+		})
+	}
+}
@@ -0,0 +1,33 @@
+// Package middleware holds HTTP middleware shared by every route.
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/eya20/hiring_test/app/api"
+)
+
+// ConcurrencyLimit caps the number of requests served at the same time.
+// Requests over the limit are rejected immediately with 503 and a
+// Retry-After header instead of queueing, which keeps latency bounded and
+// the database pool from being oversubscribed. A limit of 0 or less disables
+// the middleware.
+func ConcurrencyLimit(limit int) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if limit <= 0 {
+			return next
+		}
+
+		sem := make(chan struct{}, limit)
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			select {
+			case sem <- struct{}{}:
+				defer func() { <-sem }()
+				next.ServeHTTP(w, r)
+			default:
+				w.Header().Set("Retry-After", "1")
+				api.ErrorResponse(w, http.StatusServiceUnavailable, "too many concurrent requests")
+			}
+		})
+	}
+}
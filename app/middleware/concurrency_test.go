@@ -0,0 +1,54 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConcurrencyLimit(t *testing.T) {
+	started := make(chan struct{})
+	release := make(chan struct{})
+	h := ConcurrencyLimit(1)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		started <- struct{}{}
+		<-release
+	}))
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	first := httptest.NewRecorder()
+	go func() {
+		defer wg.Done()
+		h.ServeHTTP(first, httptest.NewRequest(http.MethodGet, "/catalog", nil))
+	}()
+	<-started
+
+	rejected := httptest.NewRecorder()
+	h.ServeHTTP(rejected, httptest.NewRequest(http.MethodGet, "/catalog", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, rejected.Code)
+	assert.Equal(t, "1", rejected.Header().Get("Retry-After"))
+	assert.JSONEq(t, `{"error":"too many concurrent requests"}`, rejected.Body.String())
+
+	close(release)
+	wg.Wait()
+	assert.Equal(t, http.StatusOK, first.Code)
+
+	// The slot is released once the first request completes.
+	go func() { <-started }()
+	accepted := httptest.NewRecorder()
+	h.ServeHTTP(accepted, httptest.NewRequest(http.MethodGet, "/catalog", nil))
+	assert.Equal(t, http.StatusOK, accepted.Code)
+}
+
+func TestConcurrencyLimitDisabled(t *testing.T) {
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+
+	h := ConcurrencyLimit(0)(next)
+
+	recorder := httptest.NewRecorder()
+	h.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/catalog", nil))
+	assert.Equal(t, http.StatusOK, recorder.Code)
+}
@@ -0,0 +1,20 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/eya20/hiring_test/app/api"
+)
+
+// ErrorDetails adds the underlying error to the error responses that hide
+// it, see api.WithErrorDetails, when enabled. Keep it off in production.
+func ErrorDetails(enabled bool) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if !enabled {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			next.ServeHTTP(api.WithErrorDetails(w), r)
+		})
+	}
+}
@@ -0,0 +1,36 @@
+package middleware
+
+import (
+	"database/sql/driver"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/eya20/hiring_test/app/api"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestErrorDetails(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		api.HandleServiceError(w, driver.ErrBadConn)
+	})
+
+	tests := []struct {
+		name     string
+		enabled  bool
+		expected string
+	}{
+		{name: "disabled", expected: `{"error":"database unavailable","code":"DATABASE_UNAVAILABLE"}`},
+		{name: "enabled", enabled: true, expected: `{"error":"database unavailable","code":"DATABASE_UNAVAILABLE","detail":"driver: bad connection"}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			ErrorDetails(tt.enabled)(handler).ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/catalog", nil))
+
+			assert.Equal(t, http.StatusServiceUnavailable, recorder.Code)
+			assert.JSONEq(t, tt.expected, recorder.Body.String())
+		})
+	}
+}
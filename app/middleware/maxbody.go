@@ -0,0 +1,18 @@
+package middleware
+
+import "net/http"
+
+// MaxBodySize caps request bodies to n bytes: reading past it fails, and
+// api.DecodeBody then responds 413 Request Entity Too Large. A size of 0 or
+// less disables the middleware.
+func MaxBodySize(n int64) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if n <= 0 {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			r.Body = http.MaxBytesReader(w, r.Body, n)
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,41 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/eya20/hiring_test/app/api"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMaxBodySize(t *testing.T) {
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var v map[string]any
+		if api.DecodeBody(w, r, &v) {
+			w.WriteHeader(http.StatusNoContent)
+		}
+	})
+	body := `{"code":"HATS","name":"Hats"}`
+
+	tests := []struct {
+		name   string
+		limit  int64
+		status int
+	}{
+		{name: "under the limit", limit: int64(len(body)), status: http.StatusNoContent},
+		{name: "over the limit", limit: int64(len(body)) - 1, status: http.StatusRequestEntityTooLarge},
+		{name: "disabled", limit: 0, status: http.StatusNoContent},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+
+			MaxBodySize(tt.limit)(next).ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/categories", strings.NewReader(body)))
+
+			assert.Equal(t, tt.status, recorder.Code)
+		})
+	}
+}
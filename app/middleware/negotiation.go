@@ -0,0 +1,97 @@
+package middleware
+
+import (
+	"crypto/rand"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/eya20/hiring_test/app/api"
+)
+
+// ContentNegotiation picks the response format from the Accept header:
+// responses written with the api helpers are XML when the client prefers
+// application/xml (or text/xml) over JSON, and JSON otherwise, including
+// when the header is missing or lists neither.
+//
+// JSON responses are enveloped, see api.WithEnvelope, when the header lists
+// api.ContentTypeEnvelope. Their request_id is the X-Request-ID header of
+// the request, or a random id without one.
+func ContentNegotiation(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Shared caches must not serve a JSON listing to an XML client.
+		w.Header().Add("Vary", "Accept")
+		accept := r.Header.Values("Accept")
+		switch {
+		case negotiate(accept) == api.ContentTypeXML:
+			w = api.WithContentType(w, api.ContentTypeXML)
+		case accepts(accept, api.ContentTypeEnvelope):
+			w = api.WithEnvelope(w, requestID(r))
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// accepts reports whether the Accept header values list mediaType with a
+// non-zero quality.
+func accepts(accept []string, mediaType string) bool {
+	for _, value := range accept {
+		for _, mediaRange := range strings.Split(value, ",") {
+			t, params, err := mime.ParseMediaType(strings.TrimSpace(mediaRange))
+			if err != nil || t != mediaType {
+				continue
+			}
+			if q, err := strconv.ParseFloat(params["q"], 64); err == nil && q <= 0 {
+				continue
+			}
+			return true
+		}
+	}
+	return false
+}
+
+// requestID returns the X-Request-ID header of r, or a random id when it has
+// none.
+func requestID(r *http.Request) string {
+	if id := r.Header.Get("X-Request-ID"); id != "" {
+		return id
+	}
+	return rand.Text()
+}
+
+// negotiate returns the supported content type with the highest quality in
+// the Accept header values. JSON wins ties, as the default format.
+func negotiate(accept []string) string {
+	best, bestQ := api.ContentTypeJSON, 0.0
+	for _, value := range accept {
+		for _, mediaRange := range strings.Split(value, ",") {
+			mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(mediaRange))
+			if err != nil {
+				continue
+			}
+
+			q := 1.0
+			if v, ok := params["q"]; ok {
+				if q, err = strconv.ParseFloat(v, 64); err != nil {
+					continue
+				}
+			}
+
+			var contentType string
+			switch mediaType {
+			case "application/json", api.ContentTypeEnvelope, "application/*", "*/*":
+				contentType = api.ContentTypeJSON
+			case "application/xml", "text/xml":
+				contentType = api.ContentTypeXML
+			default:
+				continue
+			}
+
+			if q > bestQ || (q == bestQ && contentType == api.ContentTypeJSON) {
+				best, bestQ = contentType, q
+			}
+		}
+	}
+	return best
+}
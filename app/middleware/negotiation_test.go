@@ -0,0 +1,105 @@
+package middleware
+
+import (
+	"encoding/xml"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/eya20/hiring_test/app/api"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNegotiate(t *testing.T) {
+	tests := []struct {
+		accept   []string
+		expected string
+	}{
+		{accept: nil, expected: api.ContentTypeJSON},
+		{accept: []string{"application/json"}, expected: api.ContentTypeJSON},
+		{accept: []string{"application/xml"}, expected: api.ContentTypeXML},
+		{accept: []string{"text/xml"}, expected: api.ContentTypeXML},
+		{accept: []string{"*/*"}, expected: api.ContentTypeJSON},
+		{accept: []string{"text/html"}, expected: api.ContentTypeJSON},
+		{accept: []string{"application/xml, application/json"}, expected: api.ContentTypeJSON},
+		{accept: []string{"application/json;q=0.5, application/xml"}, expected: api.ContentTypeXML},
+		{accept: []string{"application/xml;q=0.9, */*;q=0.1"}, expected: api.ContentTypeXML},
+		{accept: []string{"application/xml;q=0"}, expected: api.ContentTypeJSON},
+		{accept: []string{"text/html", "application/xml"}, expected: api.ContentTypeXML},
+		{accept: []string{"application/xml;q=abc"}, expected: api.ContentTypeJSON},
+		{accept: []string{"application/vnd.envelope+json, application/xml;q=0.5"}, expected: api.ContentTypeJSON},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.expected, negotiate(tt.accept), "%q", tt.accept)
+	}
+}
+
+func TestContentNegotiation(t *testing.T) {
+	h := ContentNegotiation(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		api.ErrorResponse(w, http.StatusNotFound, "resource not found")
+	}))
+
+	t.Run("xml", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/catalog/UNKNOWN", nil)
+		req.Header.Set("Accept", "application/xml")
+		recorder := httptest.NewRecorder()
+
+		h.ServeHTTP(recorder, req)
+
+		assert.Equal(t, http.StatusNotFound, recorder.Code)
+		assert.Equal(t, "application/xml", recorder.Header().Get("Content-Type"))
+		assert.Equal(t, "Accept", recorder.Header().Get("Vary"))
+		assert.Contains(t, recorder.Body.String(), "<response><error>resource not found</error></response>")
+	})
+
+	t.Run("envelope", func(t *testing.T) {
+		ok := ContentNegotiation(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			api.OKResponse(w, struct {
+				XMLName xml.Name `json:"-" xml:"product"`
+				Code    string   `json:"code" xml:"code"`
+			}{Code: "PROD001"})
+		}))
+
+		for accept, expected := range map[string]string{
+			"application/vnd.envelope+json":                        `"data":{"code":"PROD001"}`,
+			"application/vnd.envelope+json;q=0":                    `{"code":"PROD001"}`,
+			"application/json":                                     `{"code":"PROD001"}`,
+			"application/xml, application/vnd.envelope+json;q=0.5": `<code>PROD001</code>`,
+		} {
+			req := httptest.NewRequest(http.MethodGet, "/catalog/PROD001", nil)
+			req.Header.Set("Accept", accept)
+			req.Header.Set("X-Request-ID", "req-1")
+			recorder := httptest.NewRecorder()
+
+			ok.ServeHTTP(recorder, req)
+
+			assert.Contains(t, recorder.Body.String(), expected, accept)
+			assert.Equal(t, strings.Contains(expected, "data"), strings.Contains(recorder.Body.String(), `"request_id":"req-1"`), accept)
+		}
+	})
+
+	t.Run("envelope with a generated request id", func(t *testing.T) {
+		ok := ContentNegotiation(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			api.OKResponse(w, map[string]string{"code": "PROD001"})
+		}))
+		req := httptest.NewRequest(http.MethodGet, "/catalog/PROD001", nil)
+		req.Header.Set("Accept", "application/vnd.envelope+json")
+		recorder := httptest.NewRecorder()
+
+		ok.ServeHTTP(recorder, req)
+
+		assert.True(t, regexp.MustCompile(`"request_id":"[A-Z2-7]{26}"`).MatchString(recorder.Body.String()), recorder.Body.String())
+	})
+
+	t.Run("json by default", func(t *testing.T) {
+		recorder := httptest.NewRecorder()
+
+		h.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/catalog/UNKNOWN", nil))
+
+		assert.Equal(t, "application/json", recorder.Header().Get("Content-Type"))
+		assert.JSONEq(t, `{"error":"resource not found"}`, recorder.Body.String())
+	})
+}
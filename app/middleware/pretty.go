@@ -0,0 +1,22 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/eya20/hiring_test/app/api"
+)
+
+// PrettyPrint indents the responses written with the api helpers when the
+// request has ?pretty=true, or every response when always is set. It is meant
+// for debugging: responses stay compact by default.
+func PrettyPrint(always bool) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if pretty, _ := strconv.ParseBool(r.URL.Query().Get("pretty")); always || pretty {
+				w = api.WithIndent(w)
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,39 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/eya20/hiring_test/app/api"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPrettyPrint(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		api.OKResponse(w, map[string]string{"status": "ok"})
+	})
+	compact, indented := "{\"status\":\"ok\"}\n", "{\n  \"status\": \"ok\"\n}\n"
+
+	tests := []struct {
+		name     string
+		always   bool
+		query    string
+		expected string
+	}{
+		{name: "compact by default", expected: compact},
+		{name: "pretty param", query: "?pretty=true", expected: indented},
+		{name: "pretty param off", query: "?pretty=false", expected: compact},
+		{name: "invalid pretty param is ignored", query: "?pretty=maybe", expected: compact},
+		{name: "always", always: true, expected: indented},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			PrettyPrint(tt.always)(handler).ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/health"+tt.query, nil))
+
+			assert.Equal(t, tt.expected, recorder.Body.String())
+		})
+	}
+}
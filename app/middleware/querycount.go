@@ -0,0 +1,56 @@
+package middleware
+
+import (
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/eya20/hiring_test/app/database"
+)
+
+// QueryCount counts the database queries run for every request, see
+// database.QueryCounter, and logs the count through l once the request is
+// served. With expose set, the count so far is also sent in the
+// X-DB-Query-Count header. Meant for debugging: it names the cost of each
+// route, which clients have no business knowing.
+func QueryCount(l *slog.Logger, expose bool) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := database.WithQueryCounter(r.Context())
+			if expose {
+				w = &queryCountWriter{ResponseWriter: w, count: func() int64 { return database.QueryCount(ctx) }}
+			}
+
+			next.ServeHTTP(w, r.WithContext(ctx))
+
+			l.Info("request served", "method", r.Method, "path", r.URL.Path, "queries", database.QueryCount(ctx))
+		})
+	}
+}
+
+// queryCountWriter sets the X-DB-Query-Count header when the response
+// header is written, as it can't be changed afterwards.
+type queryCountWriter struct {
+	http.ResponseWriter
+	count       func() int64
+	wroteHeader bool
+}
+
+func (w *queryCountWriter) WriteHeader(status int) {
+	if !w.wroteHeader {
+		w.wroteHeader = true
+		w.Header().Set("X-DB-Query-Count", strconv.FormatInt(w.count(), 10))
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *queryCountWriter) Write(b []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *queryCountWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
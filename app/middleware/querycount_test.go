@@ -0,0 +1,64 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/eya20/hiring_test/app/api"
+	"github.com/eya20/hiring_test/app/database"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func TestQueryCount(t *testing.T) {
+	// A dry run runs the callbacks, and so counts, without a database.
+	db, err := gorm.Open(postgres.New(postgres.Config{DSN: "host=localhost"}), &gorm.Config{
+		DryRun:               true,
+		DisableAutomaticPing: true,
+		Logger:               logger.Discard,
+	})
+	require.NoError(t, err)
+	require.NoError(t, db.Use(database.QueryCounter{}))
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var codes []string
+		db.WithContext(r.Context()).Table("products").Pluck("code", &codes)
+		db.WithContext(r.Context()).Table("categories").Pluck("code", &codes)
+		api.OKResponse(w, map[string]int{"count": len(codes)})
+	})
+
+	tests := []struct {
+		name   string
+		expose bool
+		header string
+	}{
+		{name: "header hidden", expose: false, header: ""},
+		{name: "header exposed", expose: true, header: "2"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var logs bytes.Buffer
+			l := slog.New(slog.NewJSONHandler(&logs, nil))
+
+			recorder := httptest.NewRecorder()
+			QueryCount(l, tt.expose)(handler).ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/catalog", nil))
+
+			assert.Equal(t, http.StatusOK, recorder.Code)
+			assert.Equal(t, tt.header, recorder.Header().Get("X-DB-Query-Count"))
+
+			var record map[string]any
+			require.NoError(t, json.Unmarshal(logs.Bytes(), &record))
+			assert.Equal(t, "GET", record["method"])
+			assert.Equal(t, "/catalog", record["path"])
+			assert.Equal(t, float64(2), record["queries"])
+		})
+	}
+}
@@ -0,0 +1,32 @@
+package middleware
+
+import (
+	"log/slog"
+	"net/http"
+	"runtime/debug"
+
+	"github.com/eya20/hiring_test/app/api"
+)
+
+// Recovery responds 500 to the requests whose handler panics, logging the
+// panic and its stack through l, instead of letting net/http drop the
+// connection. http.ErrAbortHandler is let through, as it aborts a response
+// on purpose. Keep it outermost, so it covers the other middlewares too.
+func Recovery(l *slog.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			defer func() {
+				v := recover()
+				if v == nil {
+					return
+				}
+				if v == http.ErrAbortHandler {
+					panic(v)
+				}
+				l.Error("panic serving request", "method", r.Method, "path", r.URL.Path, "panic", v, "stack", string(debug.Stack()))
+				api.ErrorResponse(w, http.StatusInternalServerError, "internal server error")
+			}()
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,26 @@
+package middleware
+
+import (
+	"mime"
+	"net/http"
+
+	"github.com/eya20/hiring_test/app/api"
+)
+
+// RequireJSON responds 415 Unsupported Media Type to POST, PUT and PATCH
+// requests whose Content-Type isn't application/json, parameters such as
+// charset aside, instead of letting the handler fail to decode the body.
+// Apply it to the routes that read a JSON body.
+func RequireJSON(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodPost, http.MethodPut, http.MethodPatch:
+			mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+			if err != nil || mediaType != "application/json" {
+				api.ErrorResponse(w, http.StatusUnsupportedMediaType, "Content-Type must be application/json")
+				return
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
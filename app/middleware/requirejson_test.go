@@ -0,0 +1,49 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequireJSON(t *testing.T) {
+	h := RequireJSON(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	tests := []struct {
+		name        string
+		method      string
+		contentType string
+		status      int
+	}{
+		{name: "json", method: http.MethodPost, contentType: "application/json", status: http.StatusNoContent},
+		{name: "json with charset", method: http.MethodPatch, contentType: "application/json; charset=utf-8", status: http.StatusNoContent},
+		{name: "json in another case", method: http.MethodPut, contentType: "Application/JSON", status: http.StatusNoContent},
+		{name: "plain text", method: http.MethodPost, contentType: "text/plain", status: http.StatusUnsupportedMediaType},
+		{name: "xml", method: http.MethodPut, contentType: "application/xml", status: http.StatusUnsupportedMediaType},
+		{name: "missing", method: http.MethodPatch, contentType: "", status: http.StatusUnsupportedMediaType},
+		{name: "malformed", method: http.MethodPost, contentType: "application/json; charset", status: http.StatusUnsupportedMediaType},
+		{name: "method without body", method: http.MethodGet, contentType: "", status: http.StatusNoContent},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "/categories", strings.NewReader(`{"code":"HATS"}`))
+			if tt.contentType != "" {
+				req.Header.Set("Content-Type", tt.contentType)
+			}
+			recorder := httptest.NewRecorder()
+
+			h.ServeHTTP(recorder, req)
+
+			assert.Equal(t, tt.status, recorder.Code)
+			if tt.status == http.StatusUnsupportedMediaType {
+				assert.JSONEq(t, `{"error":"Content-Type must be application/json"}`, recorder.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,36 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"path"
+	"slices"
+	"time"
+)
+
+// Timeout bounds the context of every request to d. Repositories run their
+// queries with the request context, so once d elapses the driver cancels the
+// query in PostgreSQL and the handler gets context.DeadlineExceeded instead of
+// waiting indefinitely. A duration of 0 or less disables the middleware.
+//
+// Requests for the exempt paths are left unbounded, for streams that take as
+// long as their data does. Paths are compared in their clean form, as
+// CleanPath serves them, so /catalog//stream is exempt like /catalog/stream.
+func Timeout(d time.Duration, exempt ...string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if d <= 0 {
+			return next
+		}
+
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if slices.Contains(exempt, path.Clean(r.URL.Path)) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			ctx, cancel := context.WithTimeout(r.Context(), d)
+			defer cancel()
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
@@ -0,0 +1,27 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+
+	"github.com/eya20/hiring_test/app/api"
+)
+
+// WriteAuth responds 401 Unauthorized to the requests that don't carry
+// token in an "Authorization: Bearer" header. Apply it to the routes
+// changing data in bulk. An empty token rejects every request, so a
+// deployment that forgets to set one doesn't leave the routes open.
+func WriteAuth(token string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			given, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+			if !ok || token == "" || subtle.ConstantTimeCompare([]byte(given), []byte(token)) != 1 {
+				w.Header().Set("WWW-Authenticate", "Bearer")
+				api.ErrorResponse(w, http.StatusUnauthorized, "missing or invalid API token")
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,46 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWriteAuth(t *testing.T) {
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	tests := []struct {
+		name          string
+		token         string
+		authorization string
+		status        int
+	}{
+		{name: "valid token", token: "secret", authorization: "Bearer secret", status: http.StatusNoContent},
+		{name: "wrong token", token: "secret", authorization: "Bearer guess", status: http.StatusUnauthorized},
+		{name: "missing header", token: "secret", status: http.StatusUnauthorized},
+		{name: "other scheme", token: "secret", authorization: "Basic secret", status: http.StatusUnauthorized},
+		{name: "no token configured", authorization: "Bearer ", status: http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/catalog/price-adjust", nil)
+			if tt.authorization != "" {
+				req.Header.Set("Authorization", tt.authorization)
+			}
+			recorder := httptest.NewRecorder()
+
+			WriteAuth(tt.token)(next).ServeHTTP(recorder, req)
+
+			assert.Equal(t, tt.status, recorder.Code)
+			if tt.status == http.StatusUnauthorized {
+				assert.Equal(t, "Bearer", recorder.Header().Get("WWW-Authenticate"))
+				assert.JSONEq(t, `{"error":"missing or invalid API token"}`, recorder.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,181 @@
+// Package router maps the API routes to their handlers, for every version of
+// the API.
+package router
+
+import (
+	"encoding/xml"
+	"net/http"
+	"strings"
+
+	"github.com/eya20/hiring_test/app/api"
+	"github.com/eya20/hiring_test/app/catalog"
+	"github.com/eya20/hiring_test/app/categories"
+	"github.com/eya20/hiring_test/app/docs"
+	"github.com/eya20/hiring_test/app/middleware"
+	"github.com/eya20/hiring_test/app/webhooks"
+)
+
+// Versions lists the API versions served, each under its own path prefix.
+var Versions = []string{"v1"}
+
+// Streams lists the paths of the streaming routes, in every version. Their
+// responses take as long as their data does, so they are exempt from the
+// request timeout.
+func Streams() []string {
+	paths := []string{"/catalog/stream"}
+	for _, version := range Versions {
+		paths = append(paths, "/"+version+"/catalog/stream")
+	}
+	return paths
+}
+
+// bySKU is the pattern GET /catalog/by-sku/{sku} is registered as, see
+// registerV1.
+const bySKU = "/catalog/{code}/{sku}"
+
+// Handlers are the handlers the API routes are served by.
+type Handlers struct {
+	Catalog    *catalog.CatalogHandler
+	Categories *categories.CategoriesHandler
+	Webhooks   *webhooks.WebhooksHandler
+	Docs       *docs.DocsHandler
+	// WriteToken is the bearer token the bulk write routes require, see
+	// middleware.WriteAuth.
+	WriteToken string
+}
+
+// VersionsResponse lists the API versions served.
+type VersionsResponse struct {
+	XMLName  xml.Name `json:"-" xml:"versions"`
+	Versions []string `json:"versions" xml:"version"`
+}
+
+// New serves every API version under its prefix, e.g. GET /v1/catalog, and
+// lists the versions at GET /. Paths without a version prefix are served by
+// v1, for clients written before versioning.
+func New(h Handlers) http.Handler {
+	mux := http.NewServeMux()
+	registerV1(mux, "/v1", h)
+	registerV1(mux, "", h)
+	mux.HandleFunc("GET /{$}", listVersions)
+	mux.HandleFunc("/", unmatched(mux))
+	return mux
+}
+
+// V1 serves version 1 of the API alone, under /v1.
+func V1(h Handlers) http.Handler {
+	mux := http.NewServeMux()
+	registerV1(mux, "/v1", h)
+	mux.HandleFunc("/", unmatched(mux))
+	return mux
+}
+
+// registerV1 registers the routes of version 1 of the API on mux, their
+// paths starting with prefix. Every route is registered on one mux, rather
+// than on a mux of its own behind http.StripPrefix, so the pattern tracing
+// names spans after is the full path.
+func registerV1(mux *http.ServeMux, prefix string, h Handlers) {
+	handle := func(method, path string, handler http.HandlerFunc) {
+		mux.HandleFunc(method+" "+prefix+path, handler)
+	}
+
+	// GET /catalog/by-sku/{sku} can't be registered as such: it overlaps the
+	// GET /catalog/{code}/<name> routes of products on paths such as
+	// /catalog/by-sku/similar, and ServeMux panics on overlapping patterns
+	// neither of which is more specific. So it is served by the less
+	// specific GET /catalog/{code}/{sku}, and by the product routes when code
+	// is "by-sku". Either names the request after the path it documents, for
+	// tracing.
+	getBySKU := func(w http.ResponseWriter, r *http.Request, sku string) {
+		r.SetPathValue("sku", sku)
+		r.Pattern = "GET " + prefix + "/catalog/by-sku/{sku}"
+		h.Catalog.GetProductBySKU(w, r)
+	}
+	writeAuth := middleware.WriteAuth(h.WriteToken)
+	notFound := unmatched(mux)
+	handle("GET", bySKU, func(w http.ResponseWriter, r *http.Request) {
+		if r.PathValue("code") != "by-sku" {
+			notFound(w, r)
+			return
+		}
+		getBySKU(w, r, r.PathValue("sku"))
+	})
+	handleProduct := func(name string, handler http.HandlerFunc) {
+		handle("GET", "/catalog/{code}/"+name, func(w http.ResponseWriter, r *http.Request) {
+			if r.PathValue("code") == "by-sku" {
+				getBySKU(w, r, name)
+				return
+			}
+			handler(w, r)
+		})
+	}
+
+	handle("GET", "/catalog", h.Catalog.GetCatalog)
+	handle("POST", "/catalog", requireJSON(h.Catalog.CreateProduct))
+	handle("POST", "/catalog/price-adjust", writeAuth(requireJSON(h.Catalog.AdjustPrices)).ServeHTTP)
+	handle("GET", "/catalog/{code}", h.Catalog.GetProduct)
+	handle("PATCH", "/catalog/{code}", requireJSON(h.Catalog.UpdateProduct))
+	handleProduct("similar", h.Catalog.GetSimilar)
+	handleProduct("related", h.Catalog.GetRelated)
+	handleProduct("shipping", h.Catalog.GetShipping)
+	handle("POST", "/catalog/{code}/variants", requireJSON(h.Catalog.CreateVariant))
+	handle("PUT", "/catalog/{code}/variants/{sku}", requireJSON(h.Catalog.UpdateVariant))
+	handle("GET", "/catalog/{code}/variants/{sku}/stock", h.Catalog.GetVariantStock)
+	handle("POST", "/catalog/{code}/images", requireJSON(h.Catalog.AddImage))
+	handle("DELETE", "/catalog/{code}/images/{index}", h.Catalog.DeleteImage)
+	handle("GET", "/catalog/featured", h.Catalog.GetFeatured)
+	handle("GET", "/catalog/random", h.Catalog.GetRandom)
+	handle("GET", "/catalog/stats", h.Catalog.GetStats)
+	handle("GET", "/catalog/count", h.Catalog.GetCount)
+	handle("GET", "/catalog/stream", h.Catalog.GetStream)
+	handle("PATCH", "/catalog/{code}/featured", requireJSON(h.Catalog.SetFeatured))
+	handle("PATCH", "/catalog/{code}/category", requireJSON(h.Catalog.SetCategory))
+	handleProduct("price-history", h.Catalog.GetPriceHistory)
+	handle("GET", "/categories", h.Categories.GetCategories)
+	handle("POST", "/categories", requireJSON(h.Categories.CreateCategory))
+	handle("GET", "/categories/{code}", h.Categories.GetCategory)
+	handle("PATCH", "/categories/{code}", requireJSON(h.Categories.PatchCategory))
+	handle("GET", "/categories/{code}/products", h.Categories.GetCategoryProducts)
+	handle("GET", "/categories/{code}/children", h.Categories.GetCategoryChildren)
+	handle("PATCH", "/categories/{code}/parent", requireJSON(h.Categories.SetCategoryParent))
+	handle("POST", "/webhooks", requireJSON(h.Webhooks.CreateWebhook))
+	handle("DELETE", "/webhooks/{id}", h.Webhooks.DeleteWebhook)
+	handle("GET", "/openapi.json", h.Docs.GetSpec)
+	handle("GET", "/docs", h.Docs.GetUI)
+}
+
+// unmatched answers the requests no route serves, which the catch-all route
+// "/" of mux receives whatever their method, as does the route of bySKU for
+// other codes than by-sku: with 405 and the methods the
+// path is served for in the Allow header when there are any, as ServeMux
+// would without the catch-all, and with 404 otherwise.
+func unmatched(mux *http.ServeMux) http.HandlerFunc {
+	methods := []string{http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete}
+	return func(w http.ResponseWriter, r *http.Request) {
+		var allow []string
+		for _, method := range methods {
+			probe := r.Clone(r.Context())
+			probe.Method = method
+			if _, pattern := mux.Handler(probe); pattern != "" && pattern != "/" && !strings.HasSuffix(pattern, bySKU) {
+				allow = append(allow, method)
+			}
+		}
+		if len(allow) == 0 {
+			api.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Allow", strings.Join(allow, ", "))
+		api.ErrorResponse(w, http.StatusMethodNotAllowed, "method not allowed")
+	}
+}
+
+// requireJSON wraps the handler of a route reading a JSON body in
+// middleware.RequireJSON.
+func requireJSON(handler http.HandlerFunc) http.HandlerFunc {
+	return middleware.RequireJSON(handler).ServeHTTP
+}
+
+// listVersions lists the API versions served.
+func listVersions(w http.ResponseWriter, r *http.Request) {
+	api.OKResponse(w, VersionsResponse{Versions: Versions})
+}
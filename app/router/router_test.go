@@ -0,0 +1,237 @@
+package router
+
+import (
+	"cmp"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/eya20/hiring_test/app/catalog"
+	"github.com/eya20/hiring_test/app/categories"
+	"github.com/eya20/hiring_test/app/docs"
+	"github.com/eya20/hiring_test/app/webhooks"
+	"github.com/stretchr/testify/assert"
+)
+
+// testHandlers builds handlers without repositories. The tests only make
+// requests answered before any repository is used.
+func testHandlers() Handlers {
+	rates, _ := catalog.ParseExchangeRates("")
+	service := catalog.NewCatalogService(nil, rates)
+	return Handlers{
+		Catalog:    catalog.NewCatalogHandler(service, catalog.DefaultConfig()),
+		Categories: categories.NewCategoriesHandler(nil, service, catalog.DefaultConfig(), webhooks.Discard),
+		Webhooks:   webhooks.NewWebhooksHandler(nil),
+		Docs:       docs.NewDocsHandler(),
+		WriteToken: "secret",
+	}
+}
+
+func TestV1(t *testing.T) {
+	h := V1(testHandlers())
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		status int
+	}{
+		{name: "versioned route", method: http.MethodGet, path: "/v1/openapi.json", status: http.StatusOK},
+		{name: "versioned route reaching its handler", method: http.MethodGet, path: "/v1/catalog?limit=abc", status: http.StatusBadRequest},
+		{name: "related products", method: http.MethodGet, path: "/v1/catalog/PROD001/related?limit=abc", status: http.StatusBadRequest},
+		{name: "unversioned route", method: http.MethodGet, path: "/openapi.json", status: http.StatusNotFound},
+		{name: "unknown version", method: http.MethodGet, path: "/v2/openapi.json", status: http.StatusNotFound},
+		{name: "unknown route", method: http.MethodGet, path: "/v1/nope", status: http.StatusNotFound},
+		{name: "known route, other method", method: http.MethodDelete, path: "/v1/catalog/PROD001", status: http.StatusMethodNotAllowed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			h.ServeHTTP(recorder, httptest.NewRequest(tt.method, tt.path, nil))
+
+			assert.Equal(t, tt.status, recorder.Code)
+		})
+	}
+}
+
+func TestNew(t *testing.T) {
+	h := New(testHandlers())
+
+	t.Run("lists the versions", func(t *testing.T) {
+		recorder := httptest.NewRecorder()
+		h.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/", nil))
+
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		assert.JSONEq(t, `{"versions":["v1"]}`, recorder.Body.String())
+	})
+
+	t.Run("serves unversioned paths as v1", func(t *testing.T) {
+		for _, path := range []string{"/v1/openapi.json", "/openapi.json"} {
+			recorder := httptest.NewRecorder()
+			h.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, path, nil))
+
+			assert.Equal(t, http.StatusOK, recorder.Code, path)
+		}
+	})
+
+	t.Run("records the full pattern", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/v1/catalog?limit=abc", nil)
+		h.ServeHTTP(httptest.NewRecorder(), req)
+
+		assert.Equal(t, "GET /v1/catalog", req.Pattern)
+	})
+
+	t.Run("unknown route", func(t *testing.T) {
+		recorder := httptest.NewRecorder()
+		h.ServeHTTP(recorder, httptest.NewRequest(http.MethodDelete, "/v2/catalog", nil))
+
+		assert.Equal(t, http.StatusNotFound, recorder.Code)
+		assert.Empty(t, recorder.Header().Get("Allow"))
+		assert.JSONEq(t, `{"error":"resource not found"}`, recorder.Body.String())
+	})
+
+	t.Run("method not allowed", func(t *testing.T) {
+		tests := []struct {
+			path  string
+			allow string
+		}{
+			{path: "/v1/catalog/PROD001", allow: "GET, HEAD, PATCH"},
+			{path: "/catalog/PROD001", allow: "GET, HEAD, PATCH"},
+			{path: "/v1/webhooks/1", allow: "DELETE"},
+		}
+
+		for _, tt := range tests {
+			recorder := httptest.NewRecorder()
+			h.ServeHTTP(recorder, httptest.NewRequest(http.MethodPut, tt.path, nil))
+
+			assert.Equal(t, http.StatusMethodNotAllowed, recorder.Code, tt.path)
+			assert.Equal(t, tt.allow, recorder.Header().Get("Allow"), tt.path)
+			assert.JSONEq(t, `{"error":"method not allowed"}`, recorder.Body.String(), tt.path)
+		}
+	})
+
+	t.Run("serves products by SKU", func(t *testing.T) {
+		// Unknown currencies are rejected before the repository is used.
+		tests := []struct {
+			path string
+			sku  string
+		}{
+			{path: "/v1/catalog/by-sku/SKU001?currency=XXX", sku: "SKU001"},
+			{path: "/catalog/by-sku/SKU001?currency=XXX", sku: "SKU001"},
+			{path: "/v1/catalog/by-sku/similar?currency=XXX", sku: "similar"},
+			{path: "/v1/catalog/by-sku/price-history?currency=XXX", sku: "price-history"},
+		}
+
+		for _, tt := range tests {
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			recorder := httptest.NewRecorder()
+			h.ServeHTTP(recorder, req)
+
+			assert.Equal(t, http.StatusBadRequest, recorder.Code, tt.path)
+			assert.JSONEq(t, `{"error":"unsupported currency XXX"}`, recorder.Body.String(), tt.path)
+			assert.Equal(t, tt.sku, req.PathValue("sku"), tt.path)
+			assert.Equal(t, "GET "+strings.TrimSuffix(req.URL.Path, tt.sku)+"{sku}", req.Pattern, tt.path)
+		}
+	})
+
+	t.Run("product routes beside the SKU lookup", func(t *testing.T) {
+		tests := []struct {
+			path   string
+			status int
+			allow  string
+		}{
+			{path: "/v1/catalog/PROD001/similar?limit=abc", status: http.StatusBadRequest},
+			{path: "/v1/catalog/PROD001/nope", status: http.StatusNotFound},
+			{path: "/v1/catalog/PROD001/variants", status: http.StatusMethodNotAllowed, allow: "POST"},
+		}
+
+		for _, tt := range tests {
+			recorder := httptest.NewRecorder()
+			h.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+			assert.Equal(t, tt.status, recorder.Code, tt.path)
+			assert.Equal(t, tt.allow, recorder.Header().Get("Allow"), tt.path)
+		}
+	})
+
+	t.Run("requires json bodies", func(t *testing.T) {
+		for _, path := range []string{"/v1/categories", "/categories", "/v1/webhooks"} {
+			req := httptest.NewRequest(http.MethodPost, path, strings.NewReader("<category/>"))
+			req.Header.Set("Content-Type", "text/plain")
+			recorder := httptest.NewRecorder()
+			h.ServeHTTP(recorder, req)
+
+			assert.Equal(t, http.StatusUnsupportedMediaType, recorder.Code, path)
+		}
+	})
+
+	t.Run("requires the write token to adjust prices", func(t *testing.T) {
+		for _, path := range []string{"/v1/catalog/price-adjust", "/catalog/price-adjust"} {
+			req := httptest.NewRequest(http.MethodPost, path, strings.NewReader("percent=-10"))
+			req.Header.Set("Content-Type", "text/plain")
+			recorder := httptest.NewRecorder()
+			h.ServeHTTP(recorder, req)
+
+			assert.Equal(t, http.StatusUnauthorized, recorder.Code, path)
+
+			// With the token, the request reaches the JSON check.
+			req.Header.Set("Authorization", "Bearer secret")
+			recorder = httptest.NewRecorder()
+			h.ServeHTTP(recorder, req)
+
+			assert.Equal(t, http.StatusUnsupportedMediaType, recorder.Code, path)
+		}
+	})
+}
+
+// TestRoutes builds the mux, which panics on conflicting patterns, and checks
+// that every operation of the OpenAPI spec is served by the route of the
+// same path, both under /v1 and without prefix.
+func TestRoutes(t *testing.T) {
+	mux, ok := New(testHandlers()).(*http.ServeMux)
+	if !assert.True(t, ok) {
+		return
+	}
+
+	recorder := httptest.NewRecorder()
+	mux.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/v1/openapi.json", nil))
+	var spec struct {
+		Paths map[string]map[string]json.RawMessage `json:"paths"`
+	}
+	if !assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &spec)) {
+		return
+	}
+
+	wildcard := regexp.MustCompile(`\{[^}]+\}`)
+	// Paths ServeMux can't register as such, and the route serving them
+	// instead, see registerV1.
+	routes := map[string]string{"/catalog/by-sku/{sku}": bySKU}
+	for path, item := range spec.Paths {
+		if _, ok := item["servers"]; ok {
+			continue // served at the root, outside the router
+		}
+		for method := range item {
+			if method == "parameters" {
+				continue
+			}
+			route := strings.ToUpper(method)
+			if route == http.MethodHead {
+				route = http.MethodGet // GET routes serve HEAD too
+			}
+			for _, prefix := range []string{"/v1", ""} {
+				req := httptest.NewRequest(strings.ToUpper(method), prefix+wildcard.ReplaceAllString(path, "X1"), nil)
+				_, pattern := mux.Handler(req)
+
+				assert.Equal(t, route+" "+prefix+cmp.Or(routes[path], path), pattern)
+			}
+		}
+	}
+}
+
+func TestStreams(t *testing.T) {
+	assert.Equal(t, []string{"/catalog/stream", "/v1/catalog/stream"}, Streams())
+}
@@ -0,0 +1,86 @@
+// Package testutil provides helpers to seed a real database in integration tests.
+package testutil
+
+import (
+	"testing"
+
+	"github.com/eya20/hiring_test/models"
+	"gorm.io/gorm"
+)
+
+// batchSize is the number of rows inserted per statement when seeding.
+const batchSize = 100
+
+// SeedCategories inserts categories in a single transaction and deletes them
+// again when the test finishes. The generated IDs are written back into the
+// slice, so callers can reference them when seeding products.
+func SeedCategories(t testing.TB, db *gorm.DB, categories []models.Category) {
+	t.Helper()
+	if len(categories) == 0 {
+		return
+	}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		return tx.CreateInBatches(&categories, batchSize).Error
+	})
+	if err != nil {
+		t.Fatalf("seeding categories: %s", err)
+	}
+
+	ids := make([]uint, len(categories))
+	for i, c := range categories {
+		ids[i] = c.ID
+	}
+	t.Cleanup(func() {
+		if err := db.Delete(&models.Category{}, ids).Error; err != nil {
+			t.Errorf("cleaning up categories: %s", err)
+		}
+	})
+}
+
+// SeedProducts inserts products along with their variants in a single
+// transaction and deletes them again when the test finishes. Products should
+// reference their category through CategoryID; the generated IDs are written
+// back into the slice.
+func SeedProducts(t testing.TB, db *gorm.DB, products []models.Product) {
+	t.Helper()
+	if len(products) == 0 {
+		return
+	}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		return tx.Omit("Category").CreateInBatches(&products, batchSize).Error
+	})
+	if err != nil {
+		t.Fatalf("seeding products: %s", err)
+	}
+
+	ids := make([]uint, len(products))
+	for i, p := range products {
+		ids[i] = p.ID
+	}
+	t.Cleanup(func() {
+		// product_variants rows go with their product through ON DELETE CASCADE.
+		if err := db.Delete(&models.Product{}, ids).Error; err != nil {
+			t.Errorf("cleaning up products: %s", err)
+		}
+	})
+}
+
+// TruncateAll empties every catalog table now and again when the test
+// finishes, so each test starts from and leaves behind an empty database.
+func TruncateAll(t testing.TB, db *gorm.DB) {
+	t.Helper()
+
+	truncate := func() error {
+		return db.Exec("TRUNCATE TABLE product_variants, products, categories, webhooks, price_change_events RESTART IDENTITY CASCADE").Error
+	}
+	if err := truncate(); err != nil {
+		t.Fatalf("truncating tables: %s", err)
+	}
+	t.Cleanup(func() {
+		if err := truncate(); err != nil {
+			t.Errorf("truncating tables: %s", err)
+		}
+	})
+}
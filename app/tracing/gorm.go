@@ -0,0 +1,65 @@
+package tracing
+
+import (
+	"errors"
+
+	"gorm.io/gorm"
+)
+
+const gormSpanKey = "tracing:span"
+
+// GormPlugin creates a client span around every query GORM executes, using
+// the span carried by the statement context as parent.
+type GormPlugin struct{}
+
+func (GormPlugin) Name() string {
+	return "tracing"
+}
+
+func (GormPlugin) Initialize(db *gorm.DB) error {
+	cb := db.Callback()
+
+	errs := []error{
+		cb.Create().Before("gorm:create").Register("tracing:before_create", before("gorm.create")),
+		cb.Create().After("gorm:create").Register("tracing:after_create", after),
+		cb.Query().Before("gorm:query").Register("tracing:before_query", before("gorm.query")),
+		cb.Query().After("gorm:query").Register("tracing:after_query", after),
+		cb.Update().Before("gorm:update").Register("tracing:before_update", before("gorm.update")),
+		cb.Update().After("gorm:update").Register("tracing:after_update", after),
+		cb.Delete().Before("gorm:delete").Register("tracing:before_delete", before("gorm.delete")),
+		cb.Delete().After("gorm:delete").Register("tracing:after_delete", after),
+		cb.Row().Before("gorm:row").Register("tracing:before_row", before("gorm.row")),
+		cb.Row().After("gorm:row").Register("tracing:after_row", after),
+		cb.Raw().Before("gorm:raw").Register("tracing:before_raw", before("gorm.raw")),
+		cb.Raw().After("gorm:raw").Register("tracing:after_raw", after),
+	}
+	return errors.Join(errs...)
+}
+
+func before(name string) func(*gorm.DB) {
+	return func(db *gorm.DB) {
+		if db.Statement.Context == nil {
+			return
+		}
+		_, span := Start(db.Statement.Context, name, SpanKindClient)
+		if span != nil {
+			db.InstanceSet(gormSpanKey, span)
+		}
+	}
+}
+
+func after(db *gorm.DB) {
+	v, ok := db.InstanceGet(gormSpanKey)
+	if !ok {
+		return
+	}
+	span := v.(*Span)
+
+	span.SetAttribute("db.system", "postgresql")
+	span.SetAttribute("db.sql.table", db.Statement.Table)
+	span.SetAttribute("db.statement", db.Statement.SQL.String())
+	if db.Error != nil && !errors.Is(db.Error, gorm.ErrRecordNotFound) {
+		span.RecordError(db.Error)
+	}
+	span.End()
+}
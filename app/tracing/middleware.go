@@ -0,0 +1,59 @@
+package tracing
+
+import (
+	"net/http"
+	"strconv"
+)
+
+// Middleware starts a server span for every request, continuing the caller's
+// trace when a valid traceparent header is present.
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		if sc, err := ParseTraceparent(r.Header.Get("traceparent")); err == nil {
+			ctx = ContextWithRemoteParent(ctx, sc)
+		}
+
+		ctx, span := Start(ctx, r.Method, SpanKindServer)
+		if span == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+		defer span.End()
+
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		r = r.WithContext(ctx)
+		next.ServeHTTP(rec, r)
+
+		// The mux records the matched pattern on the request it was given.
+		if r.Pattern != "" {
+			span.name = r.Pattern
+		}
+		span.SetAttribute("http.request.method", r.Method)
+		span.SetAttribute("url.path", r.URL.Path)
+		span.SetAttribute("http.response.status_code", strconv.Itoa(rec.status))
+		if rec.status >= http.StatusInternalServerError {
+			span.RecordError(errServer(rec.status))
+		}
+	})
+}
+
+type errServer int
+
+func (e errServer) Error() string {
+	return http.StatusText(int(e))
+}
+
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
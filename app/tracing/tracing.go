@@ -0,0 +1,201 @@
+// Package tracing provides lightweight distributed tracing for the
+// handler → service → repository path. Spans are exported with the OTLP/HTTP
+// JSON protocol to the collector configured by OTEL_EXPORTER_OTLP_ENDPOINT and
+// trace context is propagated with the W3C traceparent header.
+// Tracing is a no-op until Init is called with a non-empty endpoint.
+package tracing
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// SpanKind mirrors the OTLP span kinds used by this package.
+type SpanKind int
+
+const (
+	SpanKindInternal SpanKind = 1
+	SpanKindServer   SpanKind = 2
+	SpanKindClient   SpanKind = 3
+)
+
+type TraceID [16]byte
+
+type SpanID [8]byte
+
+// SpanContext identifies a span within a trace.
+type SpanContext struct {
+	TraceID TraceID
+	SpanID  SpanID
+}
+
+func (sc SpanContext) IsValid() bool {
+	return sc.TraceID != TraceID{} && sc.SpanID != SpanID{}
+}
+
+// Span is a single timed operation. All methods are safe to call on a nil
+// span, which is what Start returns while tracing is disabled.
+type Span struct {
+	tracer     *Tracer
+	context    SpanContext
+	parentID   SpanID
+	name       string
+	kind       SpanKind
+	start      time.Time
+	end        time.Time
+	attributes map[string]string
+	err        error
+}
+
+// SetAttribute records a key/value pair on the span.
+func (s *Span) SetAttribute(key, value string) {
+	if s == nil {
+		return
+	}
+	s.attributes[key] = value
+}
+
+// RecordError marks the span as failed.
+func (s *Span) RecordError(err error) {
+	if s == nil || err == nil {
+		return
+	}
+	s.err = err
+}
+
+// End finishes the span and queues it for export.
+func (s *Span) End() {
+	if s == nil {
+		return
+	}
+	s.end = time.Now()
+	s.tracer.export(s)
+}
+
+// SpanContext returns the identifiers of the span.
+func (s *Span) SpanContext() SpanContext {
+	if s == nil {
+		return SpanContext{}
+	}
+	return s.context
+}
+
+type spanKey struct{}
+
+type remoteKey struct{}
+
+var global atomic.Pointer[Tracer]
+
+// Init configures the global tracer to export spans to endpoint and returns a
+// function that flushes pending spans. An empty endpoint disables tracing.
+func Init(endpoint, serviceName string) (shutdown func(context.Context) error) {
+	if endpoint == "" {
+		return func(context.Context) error { return nil }
+	}
+
+	t := NewTracer(NewExporter(endpoint, serviceName))
+	global.Store(t)
+
+	return func(ctx context.Context) error {
+		global.CompareAndSwap(t, nil)
+		return t.Shutdown(ctx)
+	}
+}
+
+// Start creates a span as a child of the span in ctx, or of the remote parent
+// extracted from an incoming request. It returns the context carrying the new span.
+func Start(ctx context.Context, name string, kind ...SpanKind) (context.Context, *Span) {
+	t := global.Load()
+	if t == nil {
+		return ctx, nil
+	}
+	return t.Start(ctx, name, kind...)
+}
+
+// FromContext returns the active span in ctx, if any.
+func FromContext(ctx context.Context) *Span {
+	span, _ := ctx.Value(spanKey{}).(*Span)
+	return span
+}
+
+// Tracer creates spans and hands finished ones to its exporter.
+type Tracer struct {
+	exporter *Exporter
+}
+
+func NewTracer(e *Exporter) *Tracer {
+	return &Tracer{
+		exporter: e,
+	}
+}
+
+func (t *Tracer) Start(ctx context.Context, name string, kind ...SpanKind) (context.Context, *Span) {
+	span := &Span{
+		tracer:     t,
+		name:       name,
+		kind:       SpanKindInternal,
+		start:      time.Now(),
+		attributes: map[string]string{},
+	}
+	if len(kind) > 0 {
+		span.kind = kind[0]
+	}
+
+	if parent := FromContext(ctx); parent != nil {
+		span.context.TraceID = parent.context.TraceID
+		span.parentID = parent.context.SpanID
+	} else if remote, ok := ctx.Value(remoteKey{}).(SpanContext); ok {
+		span.context.TraceID = remote.TraceID
+		span.parentID = remote.SpanID
+	} else {
+		rand.Read(span.context.TraceID[:])
+	}
+	rand.Read(span.context.SpanID[:])
+
+	return context.WithValue(ctx, spanKey{}, span), span
+}
+
+func (t *Tracer) Shutdown(ctx context.Context) error {
+	return t.exporter.Shutdown(ctx)
+}
+
+func (t *Tracer) export(s *Span) {
+	t.exporter.enqueue(s)
+}
+
+// ParseTraceparent parses a W3C traceparent header value,
+// e.g. "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01".
+func ParseTraceparent(header string) (SpanContext, error) {
+	parts := strings.Split(strings.TrimSpace(header), "-")
+	if len(parts) != 4 || parts[0] != "00" {
+		return SpanContext{}, fmt.Errorf("invalid traceparent %q", header)
+	}
+
+	var sc SpanContext
+	if n, err := hex.Decode(sc.TraceID[:], []byte(parts[1])); err != nil || n != len(sc.TraceID) || len(parts[1]) != 32 {
+		return SpanContext{}, fmt.Errorf("invalid trace id in traceparent %q", header)
+	}
+	if n, err := hex.Decode(sc.SpanID[:], []byte(parts[2])); err != nil || n != len(sc.SpanID) || len(parts[2]) != 16 {
+		return SpanContext{}, fmt.Errorf("invalid span id in traceparent %q", header)
+	}
+	if !sc.IsValid() {
+		return SpanContext{}, fmt.Errorf("invalid traceparent %q", header)
+	}
+	return sc, nil
+}
+
+// Traceparent formats sc as a W3C traceparent header value.
+func Traceparent(sc SpanContext) string {
+	return fmt.Sprintf("00-%s-%s-01", hex.EncodeToString(sc.TraceID[:]), hex.EncodeToString(sc.SpanID[:]))
+}
+
+// ContextWithRemoteParent returns a context whose next span continues the
+// trace started by a remote caller.
+func ContextWithRemoteParent(ctx context.Context, sc SpanContext) context.Context {
+	return context.WithValue(ctx, remoteKey{}, sc)
+}
@@ -0,0 +1,88 @@
+package tracing
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStartIsNoopWhenDisabled(t *testing.T) {
+	ctx, span := Start(context.Background(), "noop")
+
+	assert.Nil(t, span)
+	assert.Nil(t, FromContext(ctx))
+
+	// nil spans are safe to use
+	span.SetAttribute("key", "value")
+	span.RecordError(assert.AnError)
+	span.End()
+}
+
+func TestParseTraceparent(t *testing.T) {
+	sc, err := ParseTraceparent("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+
+	assert.NoError(t, err)
+	assert.Equal(t, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", Traceparent(sc))
+
+	for _, header := range []string{
+		"",
+		"garbage",
+		"01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		"00-00000000000000000000000000000000-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e47-00f067aa0ba902b7-01",
+	} {
+		_, err := ParseTraceparent(header)
+		assert.Error(t, err, header)
+	}
+}
+
+func TestMiddlewareExportsSpans(t *testing.T) {
+	var (
+		mu       sync.Mutex
+		received otlpRequest
+	)
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/traces", r.URL.Path)
+
+		mu.Lock()
+		defer mu.Unlock()
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&received))
+	}))
+	defer collector.Close()
+
+	shutdown := Init(collector.URL, "catalog-test")
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /catalog/{code}", func(w http.ResponseWriter, r *http.Request) {
+		_, span := Start(r.Context(), "CatalogService.GetProductByCode")
+		span.End()
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/catalog/PROD001", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	Middleware(mux).ServeHTTP(httptest.NewRecorder(), req)
+
+	assert.NoError(t, shutdown(context.Background()))
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	spans := received.ResourceSpans[0].ScopeSpans[0].Spans
+	assert.Len(t, spans, 2)
+
+	child, server := spans[0], spans[1]
+	assert.Equal(t, "CatalogService.GetProductByCode", child.Name)
+	assert.Equal(t, "GET /catalog/{code}", server.Name)
+	assert.Equal(t, SpanKindServer, server.Kind)
+
+	// the whole request continues the caller's trace
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", server.TraceID)
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", child.TraceID)
+	assert.Equal(t, "00f067aa0ba902b7", server.ParentSpanID)
+	assert.Equal(t, server.SpanID, child.ParentSpanID)
+}
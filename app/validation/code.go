@@ -0,0 +1,25 @@
+// Package validation holds the format rules shared by request validation.
+package validation
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+)
+
+// CodeFormat describes the accepted code format in error messages.
+const CodeFormat = "must be 3 to 50 characters of A-Z, 0-9, _ or -"
+
+// ErrInvalidCode is returned by ValidateCode for malformed codes.
+var ErrInvalidCode = errors.New("invalid code")
+
+var codePattern = regexp.MustCompile(`^[A-Z0-9_-]{3,50}$`)
+
+// ValidateCode checks that code is a valid category or product code, such as
+// CLOTHING or PROD001: 3 to 50 uppercase letters, digits, underscores or hyphens.
+func ValidateCode(code string) error {
+	if !codePattern.MatchString(code) {
+		return fmt.Errorf("%w %q: %s", ErrInvalidCode, code, CodeFormat)
+	}
+	return nil
+}
@@ -0,0 +1,22 @@
+package validation
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateCode(t *testing.T) {
+	valid := []string{"PROD001", "CLOTHING", "SKU001A", "ABC", "MY_CODE-1", strings.Repeat("A", 50)}
+	for _, code := range valid {
+		assert.NoError(t, ValidateCode(code), code)
+	}
+
+	invalid := []string{"", "AB", "my code!", "prod001", "PROD 001", "PRÖD", strings.Repeat("A", 51)}
+	for _, code := range invalid {
+		assert.ErrorIs(t, ValidateCode(code), ErrInvalidCode, code)
+	}
+
+	assert.EqualError(t, ValidateCode("my code!"), `invalid code "my code!": must be 3 to 50 characters of A-Z, 0-9, _ or -`)
+}
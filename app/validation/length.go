@@ -0,0 +1,21 @@
+package validation
+
+import (
+	"fmt"
+	"unicode/utf8"
+)
+
+// Length limits for free-text fields, in characters.
+const (
+	MaxNameLength        = 200
+	MaxDescriptionLength = 2000
+)
+
+// ValidateLength checks that value is at most max characters long. field
+// names the value in the error message.
+func ValidateLength(field, value string, max int) error {
+	if utf8.RuneCountInString(value) > max {
+		return fmt.Errorf("%s exceeds maximum length of %d characters", field, max)
+	}
+	return nil
+}
@@ -0,0 +1,38 @@
+package validation
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateLength(t *testing.T) {
+	tests := []struct {
+		name  string
+		field string
+		value string
+		max   int
+		err   string
+	}{
+		{name: "name below the limit", field: "name", value: strings.Repeat("a", 199), max: MaxNameLength},
+		{name: "name at the limit", field: "name", value: strings.Repeat("a", 200), max: MaxNameLength},
+		{name: "name over the limit", field: "name", value: strings.Repeat("a", 201), max: MaxNameLength, err: "name exceeds maximum length of 200 characters"},
+		{name: "multi-byte characters count once", field: "name", value: strings.Repeat("é", 200), max: MaxNameLength},
+		{name: "description below the limit", field: "description", value: strings.Repeat("a", 1999), max: MaxDescriptionLength},
+		{name: "description at the limit", field: "description", value: strings.Repeat("a", 2000), max: MaxDescriptionLength},
+		{name: "description over the limit", field: "description", value: strings.Repeat("a", 2001), max: MaxDescriptionLength, err: "description exceeds maximum length of 2000 characters"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateLength(tt.field, tt.value, tt.max)
+
+			if tt.err == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, tt.err)
+			}
+		})
+	}
+}
@@ -0,0 +1,174 @@
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/eya20/hiring_test/models"
+)
+
+const (
+	// maxRetries is how many times a failed delivery is retried before the
+	// event is given up on for that webhook.
+	maxRetries = 3
+
+	// queueSize is the number of events waiting for delivery before Publish
+	// starts dropping them.
+	queueSize = 256
+
+	defaultRetryBackoff    = time.Second
+	defaultDeliveryTimeout = 5 * time.Second
+)
+
+// Dispatcher queues published events and delivers them to the webhooks
+// subscribed to them. Events are delivered by a single worker, started with
+// Run, in the order they were published.
+type Dispatcher struct {
+	repo   models.WebhooksRepositoryInterface
+	client *http.Client
+	events chan Event
+
+	// retryBackoff is the delay before the first retry, doubled for each
+	// subsequent one.
+	retryBackoff time.Duration
+}
+
+// Option configures optional Dispatcher behaviour.
+type Option func(*Dispatcher)
+
+// WithHTTPClient sets the client deliveries are sent with.
+func WithHTTPClient(c *http.Client) Option {
+	return func(d *Dispatcher) {
+		d.client = c
+	}
+}
+
+// WithRetryBackoff sets the delay before the first retry of a failed delivery.
+func WithRetryBackoff(backoff time.Duration) Option {
+	return func(d *Dispatcher) {
+		d.retryBackoff = backoff
+	}
+}
+
+func NewDispatcher(r models.WebhooksRepositoryInterface, opts ...Option) *Dispatcher {
+	d := &Dispatcher{
+		repo:         r,
+		client:       &http.Client{Timeout: defaultDeliveryTimeout},
+		events:       make(chan Event, queueSize),
+		retryBackoff: defaultRetryBackoff,
+	}
+	for _, opt := range opts {
+		opt(d)
+	}
+	return d
+}
+
+// Publish queues event for delivery without waiting for it. When the queue is
+// full the event is dropped and logged, so slow receivers never hold up API
+// responses.
+func (d *Dispatcher) Publish(event, code string) {
+	select {
+	case d.events <- Event{Type: event, Code: code, OccurredAt: time.Now().UTC()}:
+	default:
+		log.Printf("webhooks: queue full, dropping %s event for %s", event, code)
+	}
+}
+
+// Run delivers queued events until ctx is done. Events still queued then are
+// not delivered.
+func (d *Dispatcher) Run(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event := <-d.events:
+			d.dispatch(ctx, event)
+		}
+	}
+}
+
+// dispatch delivers event to every webhook subscribed to it.
+func (d *Dispatcher) dispatch(ctx context.Context, event Event) {
+	webhooks, err := d.repo.GetWebhooksForEvent(ctx, event.Type)
+	if err != nil {
+		log.Printf("webhooks: looking up webhooks for %s event: %s", event.Type, err)
+		return
+	}
+	if len(webhooks) == 0 {
+		return
+	}
+
+	body, err := json.Marshal(event)
+	if err != nil {
+		log.Printf("webhooks: encoding %s event: %s", event.Type, err)
+		return
+	}
+
+	for _, webhook := range webhooks {
+		if err := d.deliver(ctx, webhook, event.Type, body); err != nil {
+			log.Printf("webhooks: delivering %s event for %s to webhook %d: %s", event.Type, event.Code, webhook.ID, err)
+		}
+	}
+}
+
+// deliver posts body to the webhook, retrying up to maxRetries times with an
+// exponential backoff. It returns the error of the last attempt.
+func (d *Dispatcher) deliver(ctx context.Context, webhook models.Webhook, event string, body []byte) error {
+	signature := Sign(webhook.Secret, body)
+	backoff := d.retryBackoff
+
+	err := d.post(ctx, webhook.URL, event, signature, body)
+	for retry := 0; err != nil && retry < maxRetries; retry++ {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+
+		err = d.post(ctx, webhook.URL, event, signature, body)
+	}
+	return err
+}
+
+// post sends a single delivery. Any status outside 2xx is a failure.
+func (d *Dispatcher) post(ctx context.Context, url, event, signature string, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Event", event)
+	req.Header.Set("X-Signature", signature)
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	// Drain the body so the connection can be reused.
+	io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// Sign returns the X-Signature header of a delivery: the hex encoded
+// HMAC-SHA256 of body, keyed with the webhook secret. Receivers recompute it
+// to check a delivery is genuine.
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
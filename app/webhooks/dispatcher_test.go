@@ -0,0 +1,144 @@
+package webhooks
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/eya20/hiring_test/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// delivery is a request received by a receiver.
+type delivery struct {
+	event     string
+	signature string
+	body      []byte
+}
+
+// receiver is a webhook endpoint failing the first failures deliveries it
+// gets with a 500.
+type receiver struct {
+	mu         sync.Mutex
+	failures   int
+	attempts   int
+	deliveries []delivery
+	received   chan struct{}
+}
+
+func newReceiver(failures int) *receiver {
+	return &receiver{failures: failures, received: make(chan struct{}, 16)}
+}
+
+func (rc *receiver) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+
+	rc.attempts++
+	if rc.attempts <= rc.failures {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	body, _ := io.ReadAll(r.Body)
+	rc.deliveries = append(rc.deliveries, delivery{
+		event:     r.Header.Get("X-Event"),
+		signature: r.Header.Get("X-Signature"),
+		body:      body,
+	})
+	w.WriteHeader(http.StatusNoContent)
+	rc.received <- struct{}{}
+}
+
+func TestDispatcher(t *testing.T) {
+	t.Run("delivers signed events to subscribed webhooks", func(t *testing.T) {
+		products, categories := newReceiver(0), newReceiver(0)
+		productsServer, categoriesServer := httptest.NewServer(products), httptest.NewServer(categories)
+		defer productsServer.Close()
+		defer categoriesServer.Close()
+
+		d := NewDispatcher(&mockWebhooksRepository{webhooks: []models.Webhook{
+			{ID: 1, URL: productsServer.URL, Secret: "s3cret", Events: []string{EventProductCreated}},
+			{ID: 2, URL: categoriesServer.URL, Secret: "other", Events: []string{EventCategoryCreated}},
+		}})
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		go d.Run(ctx)
+
+		d.Publish(EventProductCreated, "PROD001")
+		waitFor(t, products.received)
+
+		require.Len(t, products.deliveries, 1)
+		got := products.deliveries[0]
+		assert.Equal(t, EventProductCreated, got.event)
+		assert.Equal(t, Sign("s3cret", got.body), got.signature)
+
+		var event Event
+		require.NoError(t, json.Unmarshal(got.body, &event))
+		assert.Equal(t, EventProductCreated, event.Type)
+		assert.Equal(t, "PROD001", event.Code)
+		assert.WithinDuration(t, time.Now(), event.OccurredAt, time.Minute)
+
+		assert.Zero(t, categories.attempts)
+	})
+
+	t.Run("retries failed deliveries", func(t *testing.T) {
+		rc := newReceiver(maxRetries)
+		server := httptest.NewServer(rc)
+		defer server.Close()
+
+		d := NewDispatcher(&mockWebhooksRepository{webhooks: []models.Webhook{
+			{ID: 1, URL: server.URL, Events: []string{EventProductUpdated}},
+		}}, WithRetryBackoff(time.Millisecond))
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		go d.Run(ctx)
+
+		d.Publish(EventProductUpdated, "PROD001")
+		waitFor(t, rc.received)
+
+		assert.Equal(t, maxRetries+1, rc.attempts)
+		assert.Len(t, rc.deliveries, 1)
+	})
+
+	t.Run("gives up after the last retry", func(t *testing.T) {
+		rc := newReceiver(maxRetries + 1)
+		server := httptest.NewServer(rc)
+		defer server.Close()
+
+		d := NewDispatcher(&mockWebhooksRepository{}, WithRetryBackoff(time.Millisecond))
+		err := d.deliver(context.Background(), models.Webhook{URL: server.URL}, EventProductUpdated, []byte(`{}`))
+
+		assert.EqualError(t, err, "unexpected status 500")
+		assert.Equal(t, maxRetries+1, rc.attempts)
+	})
+
+	t.Run("drops events when the queue is full", func(t *testing.T) {
+		d := NewDispatcher(&mockWebhooksRepository{})
+		for range queueSize + 1 {
+			d.Publish(EventProductUpdated, "PROD001")
+		}
+
+		assert.Len(t, d.events, queueSize)
+	})
+}
+
+func TestSign(t *testing.T) {
+	// echo -n '{"event":"product.created"}' | openssl dgst -sha256 -hmac s3cret
+	assert.Equal(t, "f460b9208e7cdf277ec86182f12ddbd5ea6b04ff37a76e8ff4d6349691c367f9", Sign("s3cret", []byte(`{"event":"product.created"}`)))
+}
+
+func waitFor(t *testing.T, received <-chan struct{}) {
+	t.Helper()
+	select {
+	case <-received:
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for a delivery")
+	}
+}
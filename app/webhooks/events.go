@@ -0,0 +1,46 @@
+// Package webhooks notifies registered URLs of catalog changes.
+package webhooks
+
+import (
+	"time"
+)
+
+// Event names a webhook can subscribe to.
+const (
+	EventProductCreated  = "product.created"
+	EventProductUpdated  = "product.updated"
+	EventProductDeleted  = "product.deleted"
+	EventCategoryCreated = "category.created"
+	EventCategoryUpdated = "category.updated"
+	EventCategoryDeleted = "category.deleted"
+)
+
+// Events lists every event name, in the order they are documented.
+var Events = []string{
+	EventProductCreated,
+	EventProductUpdated,
+	EventProductDeleted,
+	EventCategoryCreated,
+	EventCategoryUpdated,
+	EventCategoryDeleted,
+}
+
+// Event is the JSON payload delivered to webhooks. It only names the changed
+// product or category; receivers fetch its current state from the API.
+type Event struct {
+	Type       string    `json:"event"`
+	Code       string    `json:"code"`
+	OccurredAt time.Time `json:"occurred_at"`
+}
+
+// Publisher is notified of every successful product or category mutation.
+type Publisher interface {
+	Publish(event, code string)
+}
+
+// Discard is a Publisher that drops every event.
+var Discard Publisher = discard{}
+
+type discard struct{}
+
+func (discard) Publish(event, code string) {}
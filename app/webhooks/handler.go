@@ -0,0 +1,113 @@
+package webhooks
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+
+	"github.com/eya20/hiring_test/app/api"
+	"github.com/eya20/hiring_test/models"
+	"gorm.io/gorm"
+)
+
+// Webhook is a webhook as returned by the API. The secret is never returned.
+type Webhook struct {
+	XMLName xml.Name `json:"-" xml:"webhook"`
+	ID      uint     `json:"id" xml:"id"`
+	URL     string   `json:"url" xml:"url"`
+	Events  []string `json:"events" xml:"events>event"`
+}
+
+type CreateWebhookRequest struct {
+	URL    string   `json:"url"`
+	Secret string   `json:"secret"`
+	Events []string `json:"events"`
+}
+
+// validate returns a *api.ValidationError listing every invalid field of req.
+func (req CreateWebhookRequest) validate() error {
+	verr := &api.ValidationError{}
+
+	if req.URL == "" {
+		verr.Add("url", "url is required")
+	} else if u, err := url.Parse(req.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		verr.Add("url", "url must be an absolute http or https URL")
+	}
+
+	if req.Secret == "" {
+		verr.Add("secret", "secret is required")
+	}
+
+	if len(req.Events) == 0 {
+		verr.Add("events", "events is required")
+	}
+	for i, event := range req.Events {
+		if !slices.Contains(Events, event) {
+			verr.Add(fmt.Sprintf("events[%d]", i), fmt.Sprintf("unknown event %q", event))
+		}
+	}
+
+	return verr.Err()
+}
+
+type WebhooksHandler struct {
+	repo models.WebhooksRepositoryInterface
+}
+
+func NewWebhooksHandler(r models.WebhooksRepositoryInterface) *WebhooksHandler {
+	return &WebhooksHandler{
+		repo: r,
+	}
+}
+
+// CreateWebhook registers a webhook after validating the request body.
+func (h *WebhooksHandler) CreateWebhook(w http.ResponseWriter, r *http.Request) {
+	var req CreateWebhookRequest
+	if !api.DecodeBody(w, r, &req) {
+		return
+	}
+	var verr *api.ValidationError
+	if errors.As(req.validate(), &verr) {
+		api.ValidationErrorResponse(w, verr)
+		return
+	}
+
+	webhook := models.Webhook{
+		URL:    req.URL,
+		Secret: req.Secret,
+		Events: req.Events,
+	}
+	if err := h.repo.CreateWebhook(r.Context(), &webhook); err != nil {
+		api.HandleServiceError(w, err)
+		return
+	}
+
+	api.OKResponse(w, Webhook{
+		ID:     webhook.ID,
+		URL:    webhook.URL,
+		Events: webhook.Events,
+	})
+}
+
+// DeleteWebhook removes a webhook.
+func (h *WebhooksHandler) DeleteWebhook(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseUint(r.PathValue("id"), 10, 0)
+	if err != nil {
+		api.ErrorResponse(w, http.StatusBadRequest, "invalid webhook id "+strconv.Quote(r.PathValue("id")))
+		return
+	}
+
+	if err := h.repo.DeleteWebhook(r.Context(), uint(id)); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			err = fmt.Errorf("%w: webhook with id %d", api.ErrNotFound, id)
+		}
+		api.HandleServiceError(w, err)
+		return
+	}
+
+	api.NoContentResponse(w)
+}
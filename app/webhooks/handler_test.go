@@ -0,0 +1,115 @@
+package webhooks
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/eya20/hiring_test/models"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+type mockWebhooksRepository struct {
+	webhooks []models.Webhook
+	err      error
+}
+
+func (m *mockWebhooksRepository) GetWebhooksForEvent(ctx context.Context, event string) ([]models.Webhook, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	var webhooks []models.Webhook
+	for _, w := range m.webhooks {
+		if slices.Contains(w.Events, event) {
+			webhooks = append(webhooks, w)
+		}
+	}
+	return webhooks, nil
+}
+
+func (m *mockWebhooksRepository) CreateWebhook(ctx context.Context, webhook *models.Webhook) error {
+	if m.err != nil {
+		return m.err
+	}
+	webhook.ID = uint(len(m.webhooks) + 1)
+	m.webhooks = append(m.webhooks, *webhook)
+	return nil
+}
+
+func (m *mockWebhooksRepository) DeleteWebhook(ctx context.Context, id uint) error {
+	if m.err != nil {
+		return m.err
+	}
+	for i, w := range m.webhooks {
+		if w.ID == id {
+			m.webhooks = slices.Delete(m.webhooks, i, i+1)
+			return nil
+		}
+	}
+	return gorm.ErrRecordNotFound
+}
+
+func TestCreateWebhook(t *testing.T) {
+	tests := []struct {
+		name     string
+		body     string
+		err      error
+		status   int
+		response string
+	}{
+		{name: "registers the webhook", body: `{"url":"https://example.com/hook","secret":"s3cret","events":["product.created","category.updated"]}`, status: http.StatusOK, response: `{"id":1,"url":"https://example.com/hook","events":["product.created","category.updated"]}`},
+		{name: "relative url", body: `{"url":"/hook","secret":"s3cret","events":["product.created"]}`, status: http.StatusBadRequest, response: `{"errors":[{"field":"url","message":"url must be an absolute http or https URL"}]}`},
+		{name: "unknown event", body: `{"url":"https://example.com/hook","secret":"s3cret","events":["product.created","product.sold"]}`, status: http.StatusBadRequest, response: `{"errors":[{"field":"events[1]","message":"unknown event \"product.sold\""}]}`},
+		{name: "every invalid field is reported", body: `{}`, status: http.StatusBadRequest, response: `{"errors":[{"field":"url","message":"url is required"},{"field":"secret","message":"secret is required"},{"field":"events","message":"events is required"}]}`},
+		{name: "malformed body", body: `{`, status: http.StatusBadRequest, response: `{"error":"invalid request body"}`},
+		{name: "unknown field", body: `{"url":"https://example.com/hook","secret":"s3cret","events":["product.created"],"evnts":[]}`, status: http.StatusBadRequest, response: `{"error":"unknown field: evnts"}`},
+		{name: "repository error", body: `{"url":"https://example.com/hook","secret":"s3cret","events":["product.created"]}`, err: errors.New("boom"), status: http.StatusInternalServerError, response: `{"error":"internal server error"}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := NewWebhooksHandler(&mockWebhooksRepository{err: tt.err})
+
+			recorder := httptest.NewRecorder()
+			h.CreateWebhook(recorder, httptest.NewRequest(http.MethodPost, "/webhooks", strings.NewReader(tt.body)))
+
+			assert.Equal(t, tt.status, recorder.Code)
+			assert.JSONEq(t, tt.response, recorder.Body.String())
+		})
+	}
+}
+
+func TestDeleteWebhook(t *testing.T) {
+	tests := []struct {
+		name   string
+		id     string
+		status int
+	}{
+		{name: "removes the webhook", id: "1", status: http.StatusNoContent},
+		{name: "unknown webhook", id: "2", status: http.StatusNotFound},
+		{name: "invalid id", id: "abc", status: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &mockWebhooksRepository{webhooks: []models.Webhook{{ID: 1, URL: "https://example.com/hook"}}}
+			h := NewWebhooksHandler(repo)
+
+			req := httptest.NewRequest(http.MethodDelete, "/webhooks/"+tt.id, nil)
+			req.SetPathValue("id", tt.id)
+			recorder := httptest.NewRecorder()
+			h.DeleteWebhook(recorder, req)
+
+			assert.Equal(t, tt.status, recorder.Code)
+			if tt.status == http.StatusNoContent {
+				assert.Empty(t, repo.webhooks)
+				assert.Empty(t, recorder.Body.String())
+			}
+		})
+	}
+}
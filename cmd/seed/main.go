@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"log"
 	"os"
 	"path/filepath"
@@ -9,7 +10,9 @@ import (
 
 	"github.com/joho/godotenv"
 
+	"github.com/eya20/hiring_test/app/config"
 	"github.com/eya20/hiring_test/app/database"
+	"github.com/eya20/hiring_test/models"
 )
 
 func main() {
@@ -18,16 +21,26 @@ func main() {
 		log.Fatalf("Error loading .env file: %s", err)
 	}
 
-	// Initialize database connection
-	db, close := database.New(
-		os.Getenv("POSTGRES_USER"),
-		os.Getenv("POSTGRES_PASSWORD"),
-		os.Getenv("POSTGRES_DB"),
-		os.Getenv("POSTGRES_PORT"),
+	// Read and validate the settings as the server does, so both connect
+	// to the same database
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// Initialize database connection, waiting for it to come up like the
+	// server does, as both usually start alongside the database container
+	db, close, err := database.NewWithRetry(
+		cfg.Database.Connection,
+		cfg.Database.ConnectRetries,
+		cfg.Database.ConnectRetryDelay,
 	)
+	if err != nil {
+		log.Fatalf("Failed to connect to the database: %s", err)
+	}
 	defer close()
 
-	dir := os.Getenv("POSTGRES_SQL_DIR")
+	dir := cfg.Database.SQLDir
 	files, err := os.ReadDir(dir)
 	if err != nil {
 		log.Fatalf("reading directory failed: %v", err)
@@ -44,6 +57,15 @@ func main() {
 		return sqlFiles[i].Name() < sqlFiles[j].Name()
 	})
 
+	// Run every file in one transaction, so a failing file leaves the
+	// database as it was rather than half seeded
+	txm := models.NewTransactionManager(db)
+	tx, err := txm.Begin(context.Background())
+	if err != nil {
+		log.Fatalf("Failed to begin transaction: %s", err)
+	}
+	defer txm.Rollback(tx)
+
 	for _, file := range sqlFiles {
 		path := filepath.Join(dir, file.Name())
 
@@ -53,11 +75,15 @@ func main() {
 		}
 
 		sql := string(content)
-		if err := db.Exec(sql).Error; err != nil {
-			log.Printf("executing %s failed: %v", file.Name(), err)
+		if err := tx.Exec(sql).Error; err != nil {
+			log.Printf("executing %s failed, rolling back: %v", file.Name(), err)
 			return
 		}
 
 		log.Printf("Executed %s successfully\n", file.Name())
 	}
+
+	if err := txm.Commit(tx); err != nil {
+		log.Printf("committing failed: %v", err)
+	}
 }
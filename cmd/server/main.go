@@ -2,50 +2,168 @@ package main
 
 import (
 	"context"
-	"fmt"
 	"log"
+	"log/slog"
 	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
 
 	"github.com/eya20/hiring_test/app/catalog"
+	"github.com/eya20/hiring_test/app/categories"
+	"github.com/eya20/hiring_test/app/config"
 	"github.com/eya20/hiring_test/app/database"
+	"github.com/eya20/hiring_test/app/docs"
+	"github.com/eya20/hiring_test/app/health"
+	"github.com/eya20/hiring_test/app/middleware"
+	"github.com/eya20/hiring_test/app/router"
+	"github.com/eya20/hiring_test/app/tracing"
+	"github.com/eya20/hiring_test/app/webhooks"
 	"github.com/eya20/hiring_test/models"
 	"github.com/joho/godotenv"
 )
 
+// Version and Commit identify the build, reported by GET /health. They are
+// set with -ldflags "-X main.Version=... -X main.Commit=...", see the
+// Makefile.
+var (
+	Version = "dev"
+	Commit  = "unknown"
+)
+
 func main() {
 	// Load environment variables from .env file
 	if err := godotenv.Load(".env"); err != nil {
 		log.Fatalf("Error loading .env file: %s", err)
 	}
 
+	// Read and validate every setting up front
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatal(err)
+	}
+
 	// signal handling for graceful shutdown
 	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
 	defer stop()
 
-	// Initialize database connection
-	db, close := database.New(
-		os.Getenv("POSTGRES_USER"),
-		os.Getenv("POSTGRES_PASSWORD"),
-		os.Getenv("POSTGRES_DB"),
-		os.Getenv("POSTGRES_PORT"),
+	// Structured logs of queries and requests
+	logger := slog.New(slog.NewJSONHandler(os.Stdout, nil))
+
+	// Initialize database connection, waiting for it to come up
+	db, close, err := database.NewWithRetry(
+		cfg.Database.Connection,
+		cfg.Database.ConnectRetries,
+		cfg.Database.ConnectRetryDelay,
+		database.WithQueryLogger(logger, cfg.Database.SlowQuery, cfg.Database.RedactParams),
 	)
+	if err != nil {
+		log.Fatalf("Failed to connect to the database: %s", err)
+	}
 	defer close()
 
+	sqlDB, err := db.DB()
+	if err != nil {
+		log.Fatalf("Failed to get database connection: %s", err)
+	}
+
+	// Apply pending schema migrations
+	if !cfg.Database.SkipMigrations {
+		if err := database.Migrate(sqlDB, cfg.Database.MigrationsDir); err != nil {
+			log.Fatalf("Failed to apply migrations: %s", err)
+		}
+	}
+
+	// Load the optional seed dataset, skipping codes that already exist
+	if path := cfg.Database.SeedFile; path != "" {
+		if err := database.SeedFromFile(db, path); err != nil {
+			log.Fatalf("Failed to seed database: %s", err)
+		}
+	}
+
+	// Initialize tracing, a no-op when no collector endpoint is configured
+	shutdownTracing := tracing.Init(cfg.TracingEndpoint, "catalog")
+	defer shutdownTracing(context.Background())
+
+	if err := db.Use(tracing.GormPlugin{}); err != nil {
+		log.Fatalf("Failed to register tracing plugin: %s", err)
+	}
+	if err := db.Use(database.QueryCounter{}); err != nil {
+		log.Fatalf("Failed to register query counter: %s", err)
+	}
+
+	// Deliver catalog change events to the registered webhooks in the background
+	webhooksRepo := models.NewWebhooksRepository(db)
+	dispatcher := webhooks.NewDispatcher(webhooksRepo)
+	go dispatcher.Run(ctx)
+
 	// Initialize handlers
-	prodRepo := models.NewProductsRepository(db)
-	cat := catalog.NewCatalogHandler(prodRepo)
+	var productsOpts []models.ProductsRepositoryOption
+	if cfg.Database.CaseInsensitiveCodes {
+		productsOpts = append(productsOpts, models.WithCaseInsensitiveCodes())
+	}
+	prodRepo := models.NewProductsRepository(db, productsOpts...)
+	catalogService := catalog.NewCatalogService(prodRepo, cfg.Catalog.ExchangeRates,
+		catalog.WithPriceDeviationWarning(cfg.Catalog.PriceDeviationPercent),
+		catalog.WithTransactor(models.NewTransactor(db, productsOpts...)),
+		catalog.WithPublisher(dispatcher),
+		catalog.WithPriceRounding(cfg.Catalog.Rounding),
+		catalog.WithMaxVariants(cfg.Catalog.MaxVariants),
+		catalog.WithShippingCalculator(cfg.Catalog.Shipping),
+	)
+	cat := catalog.NewCatalogHandler(catalogService, cfg.Catalog.Listing)
+
+	var catRepo models.CategoriesRepositoryInterface = models.NewCategoriesRepository(db)
+	if ttl := cfg.Database.CategoriesCacheTTL; ttl > 0 {
+		catRepo = models.NewCachedCategoriesRepository(catRepo, ttl)
+	}
+	categ := categories.NewCategoriesHandler(catRepo, catalogService, cfg.Catalog.Listing, dispatcher)
+	hooks := webhooks.NewWebhooksHandler(webhooksRepo)
+	apiDocs := docs.NewDocsHandler()
+	probes := health.NewHealthHandler(sqlDB, health.BuildInfo{Version: Version, Commit: Commit})
 
 	// Set up routing
-	mux := http.NewServeMux()
-	mux.HandleFunc("GET /catalog", cat.HandleGet)
+	mux := router.New(router.Handlers{
+		Catalog:    cat,
+		Categories: categ,
+		Webhooks:   hooks,
+		Docs:       apiDocs,
+		WriteToken: cfg.HTTP.WriteToken,
+	})
+
+	// Wrap the mux, outermost first. Tracing sits right on top of the mux
+	// as it reads the matched pattern from the request it hands over.
+	handler := middleware.Chain(
+		middleware.Recovery(logger),
+		middleware.ErrorDetails(cfg.ShowErrorDetails()),
+		middleware.PrettyPrint(cfg.Debug.JSON),
+		middleware.ContentNegotiation,
+		middleware.ConcurrencyLimit(cfg.HTTP.MaxConcurrentRequests),
+		middleware.MaxBodySize(cfg.HTTP.MaxBodyBytes),
+		middleware.Timeout(cfg.HTTP.RequestTimeout, router.Streams()...),
+		middleware.CleanPath,
+		middleware.QueryCount(logger, cfg.Debug.QueryCount),
+		tracing.Middleware,
+	)(mux)
+
+	// Serve the probes outside the middleware stack, so a saturated server
+	// still answers them instead of getting restarted.
+	root := http.NewServeMux()
+	root.HandleFunc("GET /health", probes.Health)
+	root.HandleFunc("GET /ready", probes.Ready)
+	root.HandleFunc("GET /health/detail", probes.Detail)
+	root.Handle("/", handler)
 
-	// Set up the HTTP server
+	// Set up the HTTP server. The timeouts stop slow clients from holding
+	// connections open. Keep the write timeout above REQUEST_TIMEOUT, so
+	// requests cut short by it still get their error response.
 	srv := &http.Server{
-		Addr:    fmt.Sprintf("localhost:%s", os.Getenv("HTTP_PORT")),
-		Handler: mux,
+		Addr:              cfg.HTTP.Addr(),
+		Handler:           root,
+		ReadHeaderTimeout: cfg.HTTP.ReadHeaderTimeout,
+		ReadTimeout:       cfg.HTTP.ReadTimeout,
+		WriteTimeout:      cfg.HTTP.WriteTimeout,
+		IdleTimeout:       cfg.HTTP.IdleTimeout,
 	}
 
 	// Start the server
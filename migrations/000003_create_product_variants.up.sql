@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS product_variants (
+    id SERIAL PRIMARY KEY,
+    product_id INTEGER NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    name VARCHAR(256) NOT NULL,
+    sku VARCHAR(32) UNIQUE,
+    price DECIMAL(10, 2) NULL,
+    sale_price DECIMAL(10, 2) NULL,
+    created_at TIMESTAMP DEFAULT NOW(),
+    updated_at TIMESTAMP DEFAULT NOW()
+);
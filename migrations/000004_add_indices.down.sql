@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_product_variants_product_id;
+DROP INDEX IF EXISTS idx_products_featured_sort_order;
+DROP INDEX IF EXISTS idx_products_category_id;
+DROP INDEX IF EXISTS idx_products_code;
@@ -0,0 +1,4 @@
+CREATE UNIQUE INDEX IF NOT EXISTS idx_products_code ON products (code);
+CREATE INDEX IF NOT EXISTS idx_products_category_id ON products (category_id);
+CREATE INDEX IF NOT EXISTS idx_products_featured_sort_order ON products (sort_order, id) WHERE featured;
+CREATE INDEX IF NOT EXISTS idx_product_variants_product_id ON product_variants (product_id);
@@ -0,0 +1,21 @@
+-- Variant SKUs must be unique. Tables created by 000003 already have the
+-- constraint; databases created before it may hold duplicates, which are
+-- reported here so they can be resolved before migrating again.
+DO $$
+DECLARE
+    duplicates TEXT;
+BEGIN
+    SELECT string_agg(sku || ' (' || n || ' rows)', ', ' ORDER BY sku) INTO duplicates
+    FROM (SELECT sku, COUNT(*) AS n FROM product_variants GROUP BY sku HAVING COUNT(*) > 1) d;
+
+    IF duplicates IS NOT NULL THEN
+        RAISE EXCEPTION 'duplicate variant SKUs must be resolved before migrating: %', duplicates;
+    END IF;
+
+    IF NOT EXISTS (
+        SELECT 1 FROM pg_indexes
+        WHERE tablename = 'product_variants' AND indexdef LIKE 'CREATE UNIQUE INDEX % (sku)'
+    ) THEN
+        CREATE UNIQUE INDEX idx_product_variants_sku ON product_variants (sku);
+    END IF;
+END $$;
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_products_category_price;
+DROP INDEX IF EXISTS idx_products_price;
@@ -0,0 +1,8 @@
+-- Serves the price_lt filter on its own.
+CREATE INDEX IF NOT EXISTS idx_products_price ON products (price);
+
+-- Serves category and price_lt together: once the category name is resolved
+-- to its id, the products of that category are already ordered by price, so
+-- "category_id = ? AND price < ?" is a single range scan instead of reading
+-- every product of the category and discarding the expensive ones.
+CREATE INDEX IF NOT EXISTS idx_products_category_price ON products (category_id, price);
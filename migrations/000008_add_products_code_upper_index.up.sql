@@ -0,0 +1,2 @@
+-- Serves case-insensitive product code lookups (CASE_INSENSITIVE_CODES).
+CREATE INDEX IF NOT EXISTS idx_products_code_upper ON products (UPPER(code));
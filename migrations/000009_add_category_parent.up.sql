@@ -0,0 +1,6 @@
+-- Categories form a hierarchy (Clothing > Shirts). Deleting a category
+-- promotes its children to the top level rather than deleting them.
+ALTER TABLE categories ADD COLUMN IF NOT EXISTS parent_id INTEGER REFERENCES categories(id) ON DELETE SET NULL;
+
+-- Serves the children lookup of GET /categories/{code}/children.
+CREATE INDEX IF NOT EXISTS idx_categories_parent_id ON categories (parent_id);
@@ -0,0 +1,10 @@
+-- Webhooks registered to receive catalog change events. events lists the
+-- event names a webhook subscribes to, e.g. {product.created,category.updated}.
+CREATE TABLE IF NOT EXISTS webhooks (
+    id SERIAL PRIMARY KEY,
+    url TEXT NOT NULL,
+    secret TEXT NOT NULL,
+    events TEXT[] NOT NULL,
+    created_at TIMESTAMP DEFAULT NOW(),
+    updated_at TIMESTAMP DEFAULT NOW()
+);
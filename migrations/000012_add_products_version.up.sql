@@ -0,0 +1,3 @@
+-- Optimistic locking: every update of a product through PATCH /catalog/{code}
+-- must name the version it read, and increments it.
+ALTER TABLE products ADD COLUMN IF NOT EXISTS version INTEGER NOT NULL DEFAULT 1;
@@ -0,0 +1,2 @@
+ALTER TABLE product_variants DROP COLUMN IF EXISTS image;
+ALTER TABLE products DROP COLUMN IF EXISTS images;
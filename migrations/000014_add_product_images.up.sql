@@ -0,0 +1,3 @@
+-- Image URLs: an ordered list per product, and at most one per variant.
+ALTER TABLE products ADD COLUMN IF NOT EXISTS images TEXT[] NOT NULL DEFAULT '{}';
+ALTER TABLE product_variants ADD COLUMN IF NOT EXISTS image VARCHAR(2048) NOT NULL DEFAULT '';
@@ -0,0 +1,2 @@
+-- Display name of a product, which listings can be sorted by.
+ALTER TABLE products ADD COLUMN IF NOT EXISTS name VARCHAR(256) NOT NULL DEFAULT '';
@@ -0,0 +1,39 @@
+package models
+
+import (
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// Category represents a product category in the catalog.
+// It includes a unique code, always stored in uppercase, and a
+// human-readable name, and may be nested under a parent category.
+type Category struct {
+	ID       uint   `gorm:"primaryKey"`
+	Code     string `gorm:"uniqueIndex;not null"`
+	Name     string `gorm:"not null"`
+	ParentID *uint
+}
+
+func (c *Category) TableName() string {
+	return "categories"
+}
+
+// BeforeCreate uppercases the code of a new category.
+func (c *Category) BeforeCreate(tx *gorm.DB) error {
+	c.Code = strings.ToUpper(c.Code)
+	return nil
+}
+
+// BeforeSave uppercases the code of a category written with Save.
+func (c *Category) BeforeSave(tx *gorm.DB) error {
+	c.Code = strings.ToUpper(c.Code)
+	return nil
+}
+
+// CategoryWithCount is a category along with the number of products it contains.
+type CategoryWithCount struct {
+	Category
+	ProductCount int64
+}
@@ -0,0 +1,94 @@
+package models
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// CachedCategoriesRepository decorates a CategoriesRepositoryInterface with
+// an in-memory TTL cache for the full categories list.
+// Any write operation invalidates the cache immediately.
+type CachedCategoriesRepository struct {
+	CategoriesRepositoryInterface
+
+	ttl time.Duration
+	now func() time.Time
+
+	mu         sync.RWMutex
+	categories []Category
+	expiresAt  time.Time
+}
+
+func NewCachedCategoriesRepository(repo CategoriesRepositoryInterface, ttl time.Duration) *CachedCategoriesRepository {
+	return &CachedCategoriesRepository{
+		CategoriesRepositoryInterface: repo,
+		ttl:                           ttl,
+		now:                           time.Now,
+	}
+}
+
+func (r *CachedCategoriesRepository) GetAllCategories(ctx context.Context) ([]Category, error) {
+	r.mu.RLock()
+	if r.categories != nil && r.now().Before(r.expiresAt) {
+		categories := r.snapshot()
+		r.mu.RUnlock()
+		return categories, nil
+	}
+	r.mu.RUnlock()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// Another goroutine may have refreshed the cache while we waited for the lock.
+	if r.categories != nil && r.now().Before(r.expiresAt) {
+		return r.snapshot(), nil
+	}
+
+	categories, err := r.CategoriesRepositoryInterface.GetAllCategories(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	r.categories = categories
+	if r.categories == nil {
+		r.categories = []Category{}
+	}
+	r.expiresAt = r.now().Add(r.ttl)
+
+	return r.snapshot(), nil
+}
+
+func (r *CachedCategoriesRepository) CreateCategory(ctx context.Context, category *Category) error {
+	defer r.invalidate()
+	return r.CategoriesRepositoryInterface.CreateCategory(ctx, category)
+}
+
+func (r *CachedCategoriesRepository) UpdateCategory(ctx context.Context, category *Category) error {
+	defer r.invalidate()
+	return r.CategoriesRepositoryInterface.UpdateCategory(ctx, category)
+}
+
+func (r *CachedCategoriesRepository) DeleteCategory(ctx context.Context, code string) error {
+	defer r.invalidate()
+	return r.CategoriesRepositoryInterface.DeleteCategory(ctx, code)
+}
+
+func (r *CachedCategoriesRepository) SetCategoryParent(ctx context.Context, id uint, parentID *uint) error {
+	defer r.invalidate()
+	return r.CategoriesRepositoryInterface.SetCategoryParent(ctx, id, parentID)
+}
+
+func (r *CachedCategoriesRepository) invalidate() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.categories = nil
+}
+
+// snapshot returns a copy of the cached slice so callers can't mutate the cache.
+// The caller must hold the lock.
+func (r *CachedCategoriesRepository) snapshot() []Category {
+	categories := make([]Category, len(r.categories))
+	copy(categories, r.categories)
+	return categories
+}
@@ -0,0 +1,119 @@
+package models
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeCategoriesRepository struct {
+	mu         sync.Mutex
+	calls      int
+	categories []Category
+}
+
+func (f *fakeCategoriesRepository) GetAllCategories(ctx context.Context) ([]Category, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls++
+	return append([]Category(nil), f.categories...), nil
+}
+
+func (f *fakeCategoriesRepository) GetCategoriesWithProductCount(ctx context.Context) ([]CategoryWithCount, error) {
+	return nil, nil
+}
+
+func (f *fakeCategoriesRepository) GetCategoryByCode(ctx context.Context, code string, category *Category) error {
+	return nil
+}
+
+func (f *fakeCategoriesRepository) GetChildCategories(ctx context.Context, parentID uint) ([]Category, error) {
+	return nil, nil
+}
+
+func (f *fakeCategoriesRepository) CreateCategory(ctx context.Context, category *Category) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.categories = append(f.categories, *category)
+	return nil
+}
+
+func (f *fakeCategoriesRepository) UpdateCategory(ctx context.Context, category *Category) error {
+	return nil
+}
+
+func (f *fakeCategoriesRepository) DeleteCategory(ctx context.Context, code string) error {
+	return nil
+}
+
+func (f *fakeCategoriesRepository) SetCategoryParent(ctx context.Context, id uint, parentID *uint) error {
+	return nil
+}
+
+func TestCachedCategoriesRepository(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("serves repeated reads from cache", func(t *testing.T) {
+		inner := &fakeCategoriesRepository{categories: []Category{{Code: "CLOTHING", Name: "Clothing"}}}
+		repo := NewCachedCategoriesRepository(inner, time.Minute)
+
+		for range 3 {
+			categories, err := repo.GetAllCategories(ctx)
+			assert.NoError(t, err)
+			assert.Len(t, categories, 1)
+		}
+		assert.Equal(t, 1, inner.calls)
+	})
+
+	t.Run("expires after ttl", func(t *testing.T) {
+		inner := &fakeCategoriesRepository{}
+		repo := NewCachedCategoriesRepository(inner, time.Minute)
+
+		now := time.Now()
+		repo.now = func() time.Time { return now }
+
+		_, _ = repo.GetAllCategories(ctx)
+		now = now.Add(2 * time.Minute)
+		_, _ = repo.GetAllCategories(ctx)
+
+		assert.Equal(t, 2, inner.calls)
+	})
+
+	t.Run("invalidates on write", func(t *testing.T) {
+		inner := &fakeCategoriesRepository{}
+		repo := NewCachedCategoriesRepository(inner, time.Minute)
+
+		categories, _ := repo.GetAllCategories(ctx)
+		assert.Empty(t, categories)
+
+		assert.NoError(t, repo.CreateCategory(ctx, &Category{Code: "SHOES", Name: "Shoes"}))
+
+		categories, _ = repo.GetAllCategories(ctx)
+		assert.Len(t, categories, 1)
+		assert.Equal(t, 2, inner.calls)
+
+		assert.NoError(t, repo.SetCategoryParent(ctx, 1, nil))
+
+		repo.GetAllCategories(ctx)
+		assert.Equal(t, 3, inner.calls)
+	})
+
+	t.Run("concurrent reads are safe", func(t *testing.T) {
+		inner := &fakeCategoriesRepository{categories: []Category{{Code: "SHOES", Name: "Shoes"}}}
+		repo := NewCachedCategoriesRepository(inner, time.Minute)
+
+		var wg sync.WaitGroup
+		for range 50 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				_, _ = repo.GetAllCategories(ctx)
+				_ = repo.DeleteCategory(ctx, "SHOES")
+			}()
+		}
+		wg.Wait()
+	})
+}
@@ -0,0 +1,128 @@
+package models
+
+import (
+	"context"
+	"errors"
+
+	"gorm.io/gorm"
+)
+
+// ErrCategoryCycle is returned when a category would become its own ancestor.
+var ErrCategoryCycle = errors.New("category cannot be nested under itself or one of its descendants")
+
+// CategoriesRepositoryInterface defines the contract for category repository operations
+type CategoriesRepositoryInterface interface {
+	GetAllCategories(ctx context.Context) ([]Category, error)
+	GetCategoriesWithProductCount(ctx context.Context) ([]CategoryWithCount, error)
+	GetCategoryByCode(ctx context.Context, code string, category *Category) error
+	GetChildCategories(ctx context.Context, parentID uint) ([]Category, error)
+	CreateCategory(ctx context.Context, category *Category) error
+	UpdateCategory(ctx context.Context, category *Category) error
+	DeleteCategory(ctx context.Context, code string) error
+	SetCategoryParent(ctx context.Context, id uint, parentID *uint) error
+}
+
+type CategoriesRepository struct {
+	db *gorm.DB
+}
+
+func NewCategoriesRepository(db *gorm.DB) *CategoriesRepository {
+	return &CategoriesRepository{
+		db: db,
+	}
+}
+
+func (r *CategoriesRepository) GetAllCategories(ctx context.Context) ([]Category, error) {
+	var categories []Category
+	if err := r.db.WithContext(ctx).Order("code").Find(&categories).Error; err != nil {
+		return nil, err
+	}
+	return categories, nil
+}
+
+func (r *CategoriesRepository) GetCategoriesWithProductCount(ctx context.Context) ([]CategoryWithCount, error) {
+	var categories []CategoryWithCount
+	err := r.db.WithContext(ctx).
+		Model(&Category{}).
+		Select("categories.*, COUNT(products.id) AS product_count").
+		Joins("LEFT JOIN products ON products.category_id = categories.id").
+		Group("categories.id").
+		Order("categories.code").
+		Scan(&categories).Error
+	if err != nil {
+		return nil, err
+	}
+	return categories, nil
+}
+
+func (r *CategoriesRepository) GetCategoryByCode(ctx context.Context, code string, category *Category) error {
+	return r.db.WithContext(ctx).Where("code = ?", code).First(category).Error
+}
+
+// GetChildCategories returns the direct children of the category parentID,
+// ordered by code.
+func (r *CategoriesRepository) GetChildCategories(ctx context.Context, parentID uint) ([]Category, error) {
+	categories := []Category{}
+	if err := r.db.WithContext(ctx).Where("parent_id = ?", parentID).Order("code").Find(&categories).Error; err != nil {
+		return nil, err
+	}
+	return categories, nil
+}
+
+func (r *CategoriesRepository) CreateCategory(ctx context.Context, category *Category) error {
+	return r.db.WithContext(ctx).Create(category).Error
+}
+
+func (r *CategoriesRepository) UpdateCategory(ctx context.Context, category *Category) error {
+	return r.db.WithContext(ctx).Save(category).Error
+}
+
+func (r *CategoriesRepository) DeleteCategory(ctx context.Context, code string) error {
+	res := r.db.WithContext(ctx).Where("code = ?", code).Delete(&Category{})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// SetCategoryParent nests the category id under parentID, or moves it to the
+// top level when parentID is nil. It returns ErrCategoryCycle when parentID is
+// the category itself or one of its descendants.
+//
+// The table is locked for the check and the update, so two concurrent moves
+// can't each pass the check and form a cycle together.
+func (r *CategoriesRepository) SetCategoryParent(ctx context.Context, id uint, parentID *uint) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if parentID != nil {
+			if err := tx.Exec("LOCK TABLE categories IN SHARE ROW EXCLUSIVE MODE").Error; err != nil {
+				return err
+			}
+
+			var cycle bool
+			err := tx.Raw(`WITH RECURSIVE ancestors AS (
+				SELECT id, parent_id FROM categories WHERE id = ?
+				UNION
+				SELECT c.id, c.parent_id FROM categories c JOIN ancestors a ON c.id = a.parent_id
+			)
+			SELECT EXISTS (SELECT 1 FROM ancestors WHERE id = ?)`, *parentID, id).Scan(&cycle).Error
+			if err != nil {
+				return err
+			}
+			if cycle {
+				return ErrCategoryCycle
+			}
+		}
+
+		res := tx.Model(&Category{}).Where("id = ?", id).Update("parent_id", parentID)
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return nil
+	})
+}
@@ -0,0 +1,30 @@
+package models
+
+import (
+	"errors"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// uniqueViolation is the SQLSTATE of a unique constraint violation.
+const uniqueViolation = "23505"
+
+// IsDuplicateKey reports whether err is a unique constraint violation. It
+// matches gorm.ErrDuplicatedKey, which dialectors translating errors return,
+// as well as untranslated errors: Postgres drivers by their SQLSTATE, and
+// SQLite by its message, as it has no error codes.
+func IsDuplicateKey(err error) bool {
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		return true
+	}
+
+	var state interface{ SQLState() string }
+	if errors.As(err, &state) && state.SQLState() == uniqueViolation {
+		return true
+	}
+	return strings.Contains(err.Error(), "UNIQUE constraint failed")
+}
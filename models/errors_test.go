@@ -0,0 +1,36 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+type sqlStateError string
+
+func (e sqlStateError) Error() string    { return "sql error " + string(e) }
+func (e sqlStateError) SQLState() string { return string(e) }
+
+func TestIsDuplicateKey(t *testing.T) {
+	tests := []struct {
+		name      string
+		err       error
+		duplicate bool
+	}{
+		{name: "nil", err: nil},
+		{name: "translated", err: fmt.Errorf("creating: %w", gorm.ErrDuplicatedKey), duplicate: true},
+		{name: "postgres unique violation", err: fmt.Errorf("creating: %w", sqlStateError("23505")), duplicate: true},
+		{name: "postgres other violation", err: sqlStateError("23503")},
+		{name: "sqlite", err: errors.New("UNIQUE constraint failed: categories.code"), duplicate: true},
+		{name: "anything else", err: errors.New("connection refused")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.duplicate, IsDuplicateKey(tt.err))
+		})
+	}
+}
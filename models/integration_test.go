@@ -0,0 +1,969 @@
+//go:build integration
+
+package models_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/eya20/hiring_test/app/database"
+	"github.com/eya20/hiring_test/app/testutil"
+	"github.com/eya20/hiring_test/models"
+	"github.com/lib/pq"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// db is shared by all integration tests. It points at INTEGRATION_DATABASE_URL
+// when set, otherwise at a throwaway Postgres container started by TestMain.
+var db *gorm.DB
+
+func TestMain(m *testing.M) {
+	dsn := os.Getenv("INTEGRATION_DATABASE_URL")
+	stop := func() {}
+	if dsn == "" {
+		var err error
+		dsn, stop, err = startPostgres()
+		if err != nil {
+			log.Fatalf("starting postgres: %s", err)
+		}
+	}
+
+	code := func() int {
+		defer stop()
+
+		var err error
+		db, err = connect(dsn, 30*time.Second)
+		if err != nil {
+			log.Printf("connecting to postgres: %s", err)
+			return 1
+		}
+
+		sqlDB, err := db.DB()
+		if err != nil {
+			log.Printf("getting database connection: %s", err)
+			return 1
+		}
+		if err := database.Migrate(sqlDB, "../migrations"); err != nil {
+			log.Printf("applying migrations: %s", err)
+			return 1
+		}
+		return m.Run()
+	}()
+	os.Exit(code)
+}
+
+// startPostgres runs a disposable Postgres container on a random local port.
+func startPostgres() (dsn string, stop func(), err error) {
+	out, err := exec.Command("docker", "run", "-d", "--rm",
+		"-e", "POSTGRES_PASSWORD=password",
+		"-e", "POSTGRES_DB=integration",
+		"-p", "127.0.0.1::5432",
+		"postgres:17.5",
+	).Output()
+	if err != nil {
+		return "", nil, fmt.Errorf("docker run: %w", err)
+	}
+	id := strings.TrimSpace(string(out))
+	stop = func() { _ = exec.Command("docker", "rm", "-f", id).Run() }
+
+	out, err = exec.Command("docker", "port", id, "5432/tcp").Output()
+	if err != nil {
+		stop()
+		return "", nil, fmt.Errorf("docker port: %w", err)
+	}
+	addr := strings.TrimSpace(strings.Split(string(out), "\n")[0])
+
+	return fmt.Sprintf("postgres://postgres:password@%s/integration?sslmode=disable", addr), stop, nil
+}
+
+// connect retries until the database accepts connections or timeout expires.
+func connect(dsn string, timeout time.Duration) (*gorm.DB, error) {
+	deadline := time.Now().Add(timeout)
+	for {
+		db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{Logger: logger.Discard, TranslateError: true})
+		if err == nil {
+			var sqlDB *sql.DB
+			if sqlDB, err = db.DB(); err == nil {
+				if err = sqlDB.Ping(); err == nil {
+					return db, nil
+				}
+			}
+		}
+		if time.Now().After(deadline) {
+			return nil, err
+		}
+		time.Sleep(500 * time.Millisecond)
+	}
+}
+
+// seedCatalog truncates the tables and seeds two categories with five products.
+func seedCatalog(t *testing.T) (categories []models.Category, products []models.Product) {
+	t.Helper()
+	testutil.TruncateAll(t, db)
+
+	categories = []models.Category{
+		{Code: "CLOTHING", Name: "Clothing"},
+		{Code: "SHOES", Name: "Shoes"},
+	}
+	testutil.SeedCategories(t, db, categories)
+
+	clothing, shoes := &categories[0].ID, &categories[1].ID
+	products = []models.Product{
+		{Code: "PROD001", SKU: "SKU001", Price: decimal.RequireFromString("10.99"), Currency: "USD", CategoryID: clothing,
+			Variants: []models.Variant{
+				{Name: "Variant A", SKU: "SKU001A", Price: decimal.RequireFromString("11.99"), Stock: 3},
+				{Name: "Variant B", SKU: "SKU001B"},
+			}},
+		{Code: "PROD002", SKU: "SKU002", Price: decimal.RequireFromString("12.49"), Currency: "USD", CategoryID: shoes, Featured: true, SortOrder: 2},
+		{Code: "PROD003", SKU: "SKU003", Price: decimal.RequireFromString("8.75"), Currency: "USD"},
+		{Code: "PROD004", SKU: "SKU004", Price: decimal.RequireFromString("15.00"), Currency: "USD", CategoryID: clothing, Featured: true, SortOrder: 1},
+		{Code: "PROD005", SKU: "SKU005", Price: decimal.RequireFromString("22.99"), Currency: "USD", CategoryID: clothing},
+	}
+	testutil.SeedProducts(t, db, products)
+	return categories, products
+}
+
+func codes(products []models.Product) []string {
+	codes := make([]string, len(products))
+	for i, p := range products {
+		codes[i] = p.Code
+	}
+	return codes
+}
+
+func TestProductsRepositoryPagination(t *testing.T) {
+	seedCatalog(t)
+	repo := models.NewProductsRepository(db)
+	ctx := context.Background()
+
+	tests := []struct {
+		name   string
+		offset int
+		limit  int
+		codes  []string
+	}{
+		{name: "first page", offset: 0, limit: 2, codes: []string{"PROD001", "PROD002"}},
+		{name: "last partial page", offset: 4, limit: 2, codes: []string{"PROD005"}},
+		{name: "offset at the end", offset: 5, limit: 2, codes: []string{}},
+		{name: "offset past the end", offset: 50, limit: 2, codes: []string{}},
+		{name: "negative offset starts from the first", offset: -1, limit: 2, codes: []string{"PROD001", "PROD002"}},
+		{name: "zero limit returns one", offset: 0, limit: 0, codes: []string{"PROD001"}},
+		{name: "negative limit returns one", offset: 1, limit: -100, codes: []string{"PROD002"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q := models.NewProductQuery()
+			q.Offset, q.Limit = tt.offset, tt.limit
+
+			products, total, err := repo.GetProducts(ctx, q)
+			require.NoError(t, err)
+			assert.Equal(t, tt.codes, codes(products))
+			assert.Equal(t, int64(5), total, "total ignores pagination")
+		})
+	}
+
+	t.Run("deprecated wrappers", func(t *testing.T) {
+		products, err := repo.GetProductsPaginatedWithFilters(ctx, 0, 2, nil, nil, nil, nil, nil, false, nil, "")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"PROD001", "PROD002"}, codes(products))
+
+		products, err = repo.GetProductsPaginatedWithFilters(ctx, -1, -5, nil, nil, nil, nil, nil, false, nil, "")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"PROD001"}, codes(products))
+
+		count, err := repo.GetProductsCountWithFilters(ctx, nil, nil, nil, nil, nil, false, nil)
+		require.NoError(t, err)
+		assert.Equal(t, int64(5), count)
+
+		exact := decimal.RequireFromString("12.49")
+		products, err = repo.GetProductsPaginatedWithFilters(ctx, 0, 10, nil, nil, nil, &exact, nil, false, nil, "")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"PROD002"}, codes(products))
+
+		count, err = repo.GetProductsCountWithFilters(ctx, nil, nil, nil, &exact, nil, false, nil)
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), count)
+	})
+
+	t.Run("count without loading", func(t *testing.T) {
+		q := models.NewProductQuery()
+		q.Categories = []string{"Clothing"}
+		q.Limit = 1
+
+		count, err := repo.CountProducts(ctx, q)
+		require.NoError(t, err)
+		assert.Equal(t, int64(3), count)
+	})
+}
+
+func TestProductsRepositoryEachProduct(t *testing.T) {
+	seedCatalog(t)
+	repo := models.NewProductsRepository(db)
+	ctx := context.Background()
+
+	var products []models.Product
+	err := repo.EachProduct(ctx, 2, func(p models.Product) error {
+		products = append(products, p)
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"PROD001", "PROD002", "PROD003", "PROD004", "PROD005"}, codes(products))
+	assert.Equal(t, "Clothing", products[0].Category.Name)
+	assert.Len(t, products[0].Variants, 2)
+
+	stop := errors.New("stop")
+	calls := 0
+	err = repo.EachProduct(ctx, 2, func(p models.Product) error {
+		calls++
+		return stop
+	})
+	assert.ErrorIs(t, err, stop)
+	assert.Equal(t, 1, calls)
+}
+
+func TestProductsRepositoryFilters(t *testing.T) {
+	seedCatalog(t)
+	repo := models.NewProductsRepository(db)
+	ctx := context.Background()
+
+	price := func(v float64) *float64 { return &v }
+	flag := func(v bool) *bool { return &v }
+	exact := func(v string) *decimal.Decimal { d := decimal.RequireFromString(v); return &d }
+
+	tests := []struct {
+		name  string
+		query models.ProductQuery
+		codes []string
+	}{
+		{name: "category", query: models.ProductQuery{Categories: []string{"Clothing"}}, codes: []string{"PROD001", "PROD004", "PROD005"}},
+		{name: "price", query: models.ProductQuery{PriceLt: price(12.49)}, codes: []string{"PROD001", "PROD003"}},
+		{name: "featured", query: models.ProductQuery{Featured: flag(true)}, codes: []string{"PROD002", "PROD004"}},
+		{name: "exact price", query: models.ProductQuery{PriceEq: exact("12.49")}, codes: []string{"PROD002"}},
+		{name: "exact price without match", query: models.ProductQuery{PriceEq: exact("12.4")}, codes: []string{}},
+		{name: "price range", query: models.ProductQuery{PriceGte: price(10.99), PriceLt: price(20)}, codes: []string{"PROD001", "PROD002", "PROD004"}},
+		{name: "category and price", query: models.ProductQuery{Categories: []string{"Clothing"}, PriceLt: price(20)}, codes: []string{"PROD001", "PROD004"}},
+		{name: "category and featured", query: models.ProductQuery{Categories: []string{"Clothing"}, Featured: flag(true)}, codes: []string{"PROD004"}},
+		{name: "all filters", query: models.ProductQuery{Categories: []string{"Clothing"}, PriceLt: price(15), Featured: flag(false)}, codes: []string{"PROD001"}},
+		{name: "no match", query: models.ProductQuery{Categories: []string{"Shoes"}, Featured: flag(false)}, codes: []string{}},
+		{name: "in stock", query: models.ProductQuery{InStock: true}, codes: []string{"PROD001"}},
+		{name: "in stock and featured", query: models.ProductQuery{InStock: true, Featured: flag(true)}, codes: []string{}},
+		{name: "without variants", query: models.ProductQuery{HasVariants: flag(false)}, codes: []string{"PROD002", "PROD003", "PROD004", "PROD005"}},
+		{name: "with variants", query: models.ProductQuery{HasVariants: flag(true)}, codes: []string{"PROD001"}},
+		{name: "lowercase category", query: models.ProductQuery{Categories: []string{"clothing"}}, codes: []string{"PROD001", "PROD004", "PROD005"}},
+		{name: "uppercase category", query: models.ProductQuery{Categories: []string{"CLOTHING"}}, codes: []string{"PROD001", "PROD004", "PROD005"}},
+		{name: "several categories", query: models.ProductQuery{Categories: []string{"Shoes", "Clothing"}}, codes: []string{"PROD001", "PROD002", "PROD004", "PROD005"}},
+		{name: "sorted by price desc", query: models.ProductQuery{Categories: []string{"Clothing"}, Sort: []models.SortOrder{{Field: "price", Dir: models.SortDesc}}}, codes: []string{"PROD005", "PROD004", "PROD001"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.query.Limit = 10
+
+			products, total, err := repo.GetProducts(ctx, tt.query)
+			require.NoError(t, err)
+			assert.Equal(t, tt.codes, codes(products))
+			assert.Equal(t, int64(len(tt.codes)), total)
+		})
+	}
+}
+
+func TestProductsRepositorySortColumns(t *testing.T) {
+	seedCatalog(t)
+	repo := models.NewProductsRepository(db)
+	ctx := context.Background()
+
+	// Names against the code order, and the later ids created earlier.
+	require.NoError(t, db.Exec(`UPDATE products SET name = CASE code
+		WHEN 'PROD001' THEN 'Shirt' WHEN 'PROD002' THEN 'Boots' WHEN 'PROD003' THEN 'Scarf'
+		WHEN 'PROD004' THEN 'Jacket' ELSE 'Coat' END`).Error)
+	require.NoError(t, db.Exec("UPDATE products SET created_at = NOW() - id * INTERVAL '1 day'").Error)
+
+	tests := []struct {
+		sort  string
+		codes []string
+	}{
+		{sort: "name", codes: []string{"PROD002", "PROD005", "PROD004", "PROD003", "PROD001"}},
+		{sort: "-name", codes: []string{"PROD001", "PROD003", "PROD004", "PROD005", "PROD002"}},
+		{sort: "created_at", codes: []string{"PROD005", "PROD004", "PROD003", "PROD002", "PROD001"}},
+		{sort: "-created_at", codes: []string{"PROD001", "PROD002", "PROD003", "PROD004", "PROD005"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.sort, func(t *testing.T) {
+			sorts, err := models.ParseProductSort(tt.sort)
+			require.NoError(t, err)
+			q := models.NewProductQuery()
+			q.Sort = sorts
+
+			products, _, err := repo.GetProducts(ctx, q)
+			require.NoError(t, err)
+			assert.Equal(t, tt.codes, codes(products))
+		})
+	}
+}
+
+func TestProductsRepositoryLookups(t *testing.T) {
+	seedCatalog(t)
+	repo := models.NewProductsRepository(db)
+	ctx := context.Background()
+
+	t.Run("by code with variants and category", func(t *testing.T) {
+		var product models.Product
+		require.NoError(t, repo.GetProductByCode(ctx, "PROD001", &product))
+
+		assert.Equal(t, "Clothing", product.Category.Name)
+		require.Len(t, product.Variants, 2)
+		assert.Equal(t, "SKU001A", product.Variants[0].SKU)
+		assert.True(t, product.Variants[0].Price.Equal(decimal.RequireFromString("11.99")))
+		assert.True(t, product.Variants[1].Price.IsZero())
+	})
+
+	t.Run("by code not found", func(t *testing.T) {
+		var product models.Product
+		assert.ErrorIs(t, repo.GetProductByCode(ctx, "NOPE", &product), gorm.ErrRecordNotFound)
+	})
+
+	t.Run("by sku", func(t *testing.T) {
+		var product models.Product
+		require.NoError(t, repo.GetProductBySKU(ctx, "SKU002", &product))
+		assert.Equal(t, "PROD002", product.Code)
+	})
+
+	t.Run("by variant sku", func(t *testing.T) {
+		var product models.Product
+		require.NoError(t, repo.GetProductBySKU(ctx, "SKU001B", &product))
+		assert.Equal(t, "PROD001", product.Code)
+		assert.Len(t, product.Variants, 2)
+	})
+
+	t.Run("by sku not found", func(t *testing.T) {
+		var product models.Product
+		assert.ErrorIs(t, repo.GetProductBySKU(ctx, "NOPE", &product), gorm.ErrRecordNotFound)
+	})
+
+	t.Run("all products", func(t *testing.T) {
+		products, err := repo.GetAllProducts(ctx)
+		require.NoError(t, err)
+		assert.Len(t, products, 5)
+	})
+}
+
+func TestGetAllProductsQueryCount(t *testing.T) {
+	seedCatalog(t)
+	ctx := context.Background()
+
+	// A dedicated gorm.DB so the counting callback doesn't leak into other tests.
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	counted, err := gorm.Open(postgres.New(postgres.Config{Conn: sqlDB}), &gorm.Config{Logger: logger.Discard})
+	require.NoError(t, err)
+
+	queries := 0
+	require.NoError(t, counted.Callback().Query().After("gorm:query").Register("test:count_queries", func(*gorm.DB) {
+		queries++
+	}))
+
+	products, err := models.NewProductsRepository(counted).GetAllProducts(ctx)
+	require.NoError(t, err)
+
+	assert.Equal(t, 2, queries, "one query for products and categories, one for variants")
+	require.Len(t, products, 5)
+	assert.Equal(t, "PROD001", products[0].Code)
+	assert.Equal(t, "Clothing", products[0].Category.Name)
+	assert.Len(t, products[0].Variants, 2)
+	assert.Equal(t, "PROD003", products[2].Code)
+	assert.Zero(t, products[2].Category.ID)
+	assert.Empty(t, products[2].Variants)
+}
+
+func TestProductsRepositoryUppercasesCodes(t *testing.T) {
+	seedCatalog(t)
+	repo := models.NewProductsRepository(db)
+	ctx := context.Background()
+
+	product := models.Product{Code: "prod100", Price: decimal.RequireFromString("1.00"), Currency: "USD"}
+	require.NoError(t, repo.CreateProduct(ctx, &product))
+
+	var stored models.Product
+	require.NoError(t, db.First(&stored, product.ID).Error)
+	assert.Equal(t, "PROD100", stored.Code)
+
+	stored.Code = "prod101"
+	require.NoError(t, db.Save(&stored).Error)
+	require.NoError(t, repo.GetProductByCode(ctx, "PROD101", &stored))
+}
+
+func TestProductsRepositoryCaseInsensitiveCodes(t *testing.T) {
+	seedCatalog(t)
+	ctx := context.Background()
+
+	var product models.Product
+	err := models.NewProductsRepository(db).GetProductByCode(ctx, "prod001", &product)
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound, "lookups are case-sensitive by default")
+
+	repo := models.NewProductsRepository(db, models.WithCaseInsensitiveCodes())
+	require.NoError(t, repo.GetProductByCode(ctx, "prod001", &product))
+	assert.Equal(t, "PROD001", product.Code)
+
+	similar, err := repo.GetSimilarProducts(ctx, "Prod001", 5)
+	require.NoError(t, err)
+	assert.NotEmpty(t, similar)
+
+	require.NoError(t, repo.SetProductFeatured(ctx, "prod003", true))
+	require.NoError(t, repo.GetProductByCode(ctx, "PROD003", &product))
+	assert.True(t, product.Featured)
+}
+
+func TestProductsRepositoryUpdateProduct(t *testing.T) {
+	categories, _ := seedCatalog(t)
+	repo := models.NewProductsRepository(db)
+	ctx := context.Background()
+
+	require.NoError(t, repo.UpdateProduct(ctx, "PROD003", 1, map[string]any{
+		"price":       decimal.RequireFromString("9.50"),
+		"category_id": categories[1].ID,
+	}))
+
+	var product models.Product
+	require.NoError(t, repo.GetProductByCode(ctx, "PROD003", &product))
+	assert.True(t, product.Price.Equal(decimal.RequireFromString("9.50")))
+	assert.Equal(t, "Shoes", product.Category.Name)
+	assert.Equal(t, "SKU003", product.SKU, "columns not in the update are untouched")
+	assert.Equal(t, "USD", product.Currency)
+	assert.Equal(t, 2, product.Version)
+
+	require.NoError(t, repo.UpdateProduct(ctx, "PROD003", 2, map[string]any{"category_id": nil}))
+	require.NoError(t, repo.GetProductByCode(ctx, "PROD003", &product))
+	assert.Nil(t, product.CategoryID)
+
+	assert.ErrorIs(t, repo.UpdateProduct(ctx, "NOPE", 1, map[string]any{"featured": true}), gorm.ErrRecordNotFound)
+	assert.ErrorIs(t, repo.UpdateProduct(ctx, "PROD003", 3, map[string]any{"sku": "SKU001"}), gorm.ErrDuplicatedKey)
+
+	t.Run("stale version", func(t *testing.T) {
+		assert.ErrorIs(t, repo.UpdateProduct(ctx, "PROD003", 1, map[string]any{"featured": true}), models.ErrVersionConflict)
+
+		require.NoError(t, repo.GetProductByCode(ctx, "PROD003", &product))
+		assert.False(t, product.Featured)
+		assert.Equal(t, 3, product.Version)
+	})
+}
+
+func TestProductsRepositoryFeatured(t *testing.T) {
+	seedCatalog(t)
+	repo := models.NewProductsRepository(db)
+	ctx := context.Background()
+
+	products, err := repo.GetFeaturedProducts(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"PROD004", "PROD002"}, codes(products))
+
+	require.NoError(t, repo.SetProductFeatured(ctx, "PROD004", false))
+	products, err = repo.GetFeaturedProducts(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"PROD002"}, codes(products))
+
+	assert.ErrorIs(t, repo.SetProductFeatured(ctx, "NOPE", true), gorm.ErrRecordNotFound)
+}
+
+func TestProductsRepositoryPriceHistory(t *testing.T) {
+	seedCatalog(t)
+	repo := models.NewProductsRepository(db)
+	ctx := context.Background()
+
+	start := time.Now().UTC().Truncate(time.Second)
+	changes := []models.PriceChangeEvent{
+		{ProductCode: "PROD001", OldPrice: decimal.RequireFromString("10.99"), NewPrice: decimal.RequireFromString("12.00"), ChangedAt: start, ChangedBy: "alice"},
+		{ProductCode: "PROD001", OldPrice: decimal.RequireFromString("12.00"), NewPrice: decimal.RequireFromString("9.50"), ChangedAt: start.Add(time.Hour)},
+		{ProductCode: "PROD002", OldPrice: decimal.RequireFromString("12.49"), NewPrice: decimal.RequireFromString("13.00"), ChangedAt: start},
+	}
+	for i := range changes {
+		require.NoError(t, repo.CreatePriceChangeEvent(ctx, &changes[i]))
+	}
+
+	history, err := repo.GetPriceHistory(ctx, "PROD001")
+	require.NoError(t, err)
+	require.Len(t, history, 2)
+	assert.True(t, decimal.RequireFromString("9.50").Equal(history[0].NewPrice))
+	assert.Equal(t, "alice", history[1].ChangedBy)
+	assert.True(t, start.Equal(history[1].ChangedAt.UTC()))
+
+	history, err = repo.GetPriceHistory(ctx, "PROD003")
+	require.NoError(t, err)
+	assert.Empty(t, history)
+}
+
+func TestProductsRepositorySetProductCategory(t *testing.T) {
+	categories, _ := seedCatalog(t)
+	repo := models.NewProductsRepository(db)
+	ctx := context.Background()
+	shoes := categories[1]
+
+	require.NoError(t, repo.SetProductCategory(ctx, "PROD001", shoes.ID))
+
+	var product models.Product
+	require.NoError(t, repo.GetProductByCode(ctx, "PROD001", &product))
+	assert.Equal(t, "Shoes", product.Category.Name)
+
+	assert.ErrorIs(t, repo.SetProductCategory(ctx, "NOPE", shoes.ID), gorm.ErrRecordNotFound)
+}
+
+func TestProductsRepositoryLockCategoryProducts(t *testing.T) {
+	categories, _ := seedCatalog(t)
+	ctx := context.Background()
+
+	err := models.NewTransactor(db).WithTransaction(ctx, func(repos models.TxRepositories) error {
+		products, err := repos.Products.LockCategoryProducts(ctx, categories[0].ID)
+		if err != nil {
+			return err
+		}
+		assert.Equal(t, []string{"PROD001", "PROD004", "PROD005"}, codes(products))
+		return nil
+	})
+	require.NoError(t, err)
+}
+
+func TestProductsRepositorySimilarAndRandom(t *testing.T) {
+	seedCatalog(t)
+	repo := models.NewProductsRepository(db)
+	ctx := context.Background()
+
+	similar, err := repo.GetSimilarProducts(ctx, "PROD004", 5)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"PROD001", "PROD005"}, codes(similar))
+
+	similar, err = repo.GetSimilarProducts(ctx, "PROD003", 5)
+	require.NoError(t, err)
+	assert.Empty(t, similar)
+
+	random, err := repo.GetRandomProducts(ctx, 2, "Clothing")
+	require.NoError(t, err)
+	assert.Len(t, random, 2)
+	for _, p := range random {
+		assert.Equal(t, "Clothing", p.Category.Name)
+	}
+}
+
+func TestProductsRepositoryVariants(t *testing.T) {
+	_, products := seedCatalog(t)
+	repo := models.NewProductsRepository(db)
+	ctx := context.Background()
+
+	variant := models.Variant{ProductID: products[1].ID, Name: "Variant A", SKU: "SKU002A", Price: decimal.RequireFromString("13")}
+	require.NoError(t, repo.CreateVariant(ctx, &variant))
+
+	variant.Price = decimal.RequireFromString("14")
+	require.NoError(t, repo.UpdateVariant(ctx, &variant))
+
+	var product models.Product
+	require.NoError(t, repo.GetProductByCode(ctx, "PROD002", &product))
+	require.Len(t, product.Variants, 1)
+	assert.True(t, product.Variants[0].Price.Equal(decimal.RequireFromString("14")))
+
+	duplicate := models.Variant{ProductID: products[1].ID, Name: "Variant B", SKU: "SKU002A"}
+	assert.ErrorIs(t, repo.CreateVariant(ctx, &duplicate), gorm.ErrDuplicatedKey)
+
+	count, err := repo.CountVariants(ctx, products[1].ID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), count)
+}
+
+func TestProductsRepositoryGetVariantStock(t *testing.T) {
+	seedCatalog(t)
+	repo := models.NewProductsRepository(db)
+	ctx := context.Background()
+
+	stock, err := repo.GetVariantStock(ctx, "PROD001", "SKU001A")
+	require.NoError(t, err)
+	assert.Equal(t, 3, stock)
+
+	_, err = repo.GetVariantStock(ctx, "PROD001", "SKU404")
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+
+	_, err = repo.GetVariantStock(ctx, "PROD002", "SKU001A")
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+}
+
+func TestProductsRepositoryVariantOrder(t *testing.T) {
+	_, products := seedCatalog(t)
+	repo := models.NewProductsRepository(db)
+	ctx := context.Background()
+
+	// Inserted out of SKU order, so insertion order can't pass for sorting.
+	for _, sku := range []string{"SKU002C", "SKU002A", "SKU002B"} {
+		require.NoError(t, repo.CreateVariant(ctx, &models.Variant{ProductID: products[1].ID, Name: "Variant " + sku, SKU: sku}))
+	}
+
+	skus := func(variants []models.Variant) []string {
+		skus := make([]string, len(variants))
+		for i, v := range variants {
+			skus[i] = v.SKU
+		}
+		return skus
+	}
+
+	var product models.Product
+	require.NoError(t, repo.GetProductByCode(ctx, "PROD002", &product))
+	assert.Equal(t, []string{"SKU002A", "SKU002B", "SKU002C"}, skus(product.Variants))
+
+	var bySKU models.Product
+	require.NoError(t, repo.GetProductBySKU(ctx, "SKU002", &bySKU))
+	assert.Equal(t, []string{"SKU002A", "SKU002B", "SKU002C"}, skus(bySKU.Variants))
+}
+
+func TestProductsRepositoryImages(t *testing.T) {
+	_, products := seedCatalog(t)
+	repo := models.NewProductsRepository(db)
+	ctx := context.Background()
+
+	images := pq.StringArray{"https://cdn.example.com/1a.png", "https://cdn.example.com/1b.png"}
+	require.NoError(t, repo.UpdateProduct(ctx, "PROD001", 1, map[string]any{"images": images}))
+
+	variant := models.Variant{ProductID: products[0].ID, Name: "Variant C", SKU: "SKU001C", Image: "https://cdn.example.com/1c.png"}
+	require.NoError(t, repo.CreateVariant(ctx, &variant))
+
+	var product models.Product
+	require.NoError(t, repo.GetProductByCode(ctx, "PROD001", &product))
+	assert.Equal(t, images, product.Images)
+	require.Len(t, product.Variants, 3)
+	assert.Equal(t, "https://cdn.example.com/1c.png", product.Variants[2].Image)
+
+	var other models.Product
+	require.NoError(t, repo.GetProductByCode(ctx, "PROD002", &other))
+	assert.Empty(t, other.Images)
+}
+
+func TestCategoriesRepository(t *testing.T) {
+	seedCatalog(t)
+	repo := models.NewCategoriesRepository(db)
+	ctx := context.Background()
+
+	t.Run("code is unique", func(t *testing.T) {
+		err := repo.CreateCategory(ctx, &models.Category{Code: "CLOTHING", Name: "More clothing"})
+		assert.Error(t, err)
+	})
+
+	t.Run("code is stored uppercase", func(t *testing.T) {
+		category := models.Category{Code: "catgory001", Name: "Lowercase"}
+		require.NoError(t, repo.CreateCategory(ctx, &category))
+		t.Cleanup(func() { repo.DeleteCategory(ctx, "CATGORY001") })
+
+		var stored models.Category
+		require.NoError(t, db.First(&stored, category.ID).Error)
+		assert.Equal(t, "CATGORY001", stored.Code)
+	})
+
+	t.Run("concurrent creates of a code", func(t *testing.T) {
+		const creates = 10
+		errs := make(chan error, creates)
+		var wg sync.WaitGroup
+		for range creates {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				errs <- repo.CreateCategory(ctx, &models.Category{Code: "RACE", Name: "Race"})
+			}()
+		}
+		wg.Wait()
+		close(errs)
+		t.Cleanup(func() { repo.DeleteCategory(ctx, "RACE") })
+
+		created := 0
+		for err := range errs {
+			if err == nil {
+				created++
+				continue
+			}
+			assert.True(t, models.IsDuplicateKey(err), err)
+		}
+		assert.Equal(t, 1, created)
+	})
+
+	t.Run("product counts", func(t *testing.T) {
+		categories, err := repo.GetCategoriesWithProductCount(ctx)
+		require.NoError(t, err)
+		require.Len(t, categories, 2)
+		assert.Equal(t, "CLOTHING", categories[0].Code)
+		assert.Equal(t, int64(3), categories[0].ProductCount)
+		assert.Equal(t, int64(1), categories[1].ProductCount)
+	})
+
+	t.Run("create, update and delete", func(t *testing.T) {
+		category := models.Category{Code: "HATS", Name: "Hats"}
+		require.NoError(t, repo.CreateCategory(ctx, &category))
+
+		category.Name = "Caps & Hats"
+		require.NoError(t, repo.UpdateCategory(ctx, &category))
+
+		var found models.Category
+		require.NoError(t, repo.GetCategoryByCode(ctx, "HATS", &found))
+		assert.Equal(t, "Caps & Hats", found.Name)
+
+		require.NoError(t, repo.DeleteCategory(ctx, "HATS"))
+		assert.ErrorIs(t, repo.DeleteCategory(ctx, "HATS"), gorm.ErrRecordNotFound)
+
+		categories, err := repo.GetAllCategories(ctx)
+		require.NoError(t, err)
+		assert.Len(t, categories, 2)
+	})
+
+	t.Run("nesting", func(t *testing.T) {
+		categories, err := repo.GetAllCategories(ctx)
+		require.NoError(t, err)
+		clothing, shoes := categories[0], categories[1]
+
+		boots := models.Category{Code: "BOOTS", Name: "Boots", ParentID: &shoes.ID}
+		require.NoError(t, repo.CreateCategory(ctx, &boots))
+
+		children, err := repo.GetChildCategories(ctx, shoes.ID)
+		require.NoError(t, err)
+		require.Len(t, children, 1)
+		assert.Equal(t, "BOOTS", children[0].Code)
+
+		assert.ErrorIs(t, repo.SetCategoryParent(ctx, shoes.ID, &shoes.ID), models.ErrCategoryCycle)
+		assert.ErrorIs(t, repo.SetCategoryParent(ctx, shoes.ID, &boots.ID), models.ErrCategoryCycle)
+		assert.ErrorIs(t, repo.SetCategoryParent(ctx, 9999, nil), gorm.ErrRecordNotFound)
+
+		require.NoError(t, repo.SetCategoryParent(ctx, boots.ID, &clothing.ID))
+		children, err = repo.GetChildCategories(ctx, shoes.ID)
+		require.NoError(t, err)
+		assert.Empty(t, children)
+
+		require.NoError(t, repo.SetCategoryParent(ctx, boots.ID, nil))
+		require.NoError(t, repo.GetCategoryByCode(ctx, "BOOTS", &boots))
+		assert.Nil(t, boots.ParentID)
+	})
+}
+
+func TestWebhooksRepository(t *testing.T) {
+	testutil.TruncateAll(t, db)
+	repo := models.NewWebhooksRepository(db)
+	ctx := context.Background()
+
+	products := models.Webhook{URL: "https://example.com/products", Secret: "s3cret", Events: []string{"product.created", "product.updated"}}
+	require.NoError(t, repo.CreateWebhook(ctx, &products))
+	categories := models.Webhook{URL: "https://example.com/categories", Secret: "s3cret", Events: []string{"category.created"}}
+	require.NoError(t, repo.CreateWebhook(ctx, &categories))
+
+	webhooks, err := repo.GetWebhooksForEvent(ctx, "product.updated")
+	require.NoError(t, err)
+	require.Len(t, webhooks, 1)
+	assert.Equal(t, products.URL, webhooks[0].URL)
+	assert.Equal(t, []string{"product.created", "product.updated"}, []string(webhooks[0].Events))
+
+	webhooks, err = repo.GetWebhooksForEvent(ctx, "category.deleted")
+	require.NoError(t, err)
+	assert.Empty(t, webhooks)
+
+	require.NoError(t, repo.DeleteWebhook(ctx, products.ID))
+	assert.ErrorIs(t, repo.DeleteWebhook(ctx, products.ID), gorm.ErrRecordNotFound)
+
+	webhooks, err = repo.GetWebhooksForEvent(ctx, "product.created")
+	require.NoError(t, err)
+	assert.Empty(t, webhooks)
+}
+
+func TestRepositoriesHonourContextCancellation(t *testing.T) {
+	seedCatalog(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := models.NewProductsRepository(db).GetAllProducts(ctx)
+	assert.ErrorIs(t, err, context.Canceled)
+
+	_, err = models.NewCategoriesRepository(db).GetAllCategories(ctx)
+	assert.ErrorIs(t, err, context.Canceled)
+
+	t.Run("deadline cancels a running query", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+		defer cancel()
+
+		start := time.Now()
+		err := db.WithContext(ctx).Exec("SELECT pg_sleep(5)").Error
+
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+		assert.Less(t, time.Since(start), 2*time.Second)
+	})
+}
+
+func TestTransactorRollsBack(t *testing.T) {
+	seedCatalog(t)
+	ctx := context.Background()
+
+	err := models.NewTransactor(db).WithTransaction(ctx, func(repos models.TxRepositories) error {
+		product := models.Product{Code: "PROD009", Price: decimal.RequireFromString("20"), Currency: "USD"}
+		if err := repos.Products.CreateProduct(ctx, &product); err != nil {
+			return err
+		}
+		// SKU001A already exists, so this violates the unique constraint.
+		return repos.Products.CreateVariant(ctx, &models.Variant{ProductID: product.ID, Name: "Variant A", SKU: "SKU001A"})
+	})
+	require.Error(t, err)
+
+	var product models.Product
+	assert.ErrorIs(t, models.NewProductsRepository(db).GetProductByCode(ctx, "PROD009", &product), gorm.ErrRecordNotFound)
+}
+
+func TestTransactionManager(t *testing.T) {
+	seedCatalog(t)
+	ctx := context.Background()
+	txm := models.NewTransactionManager(db)
+	products := models.NewProductsRepository(db)
+
+	t.Run("rollback undoes writes", func(t *testing.T) {
+		tx, err := txm.Begin(ctx)
+		require.NoError(t, err)
+		product := models.Product{Code: "PROD009", Price: decimal.RequireFromString("20"), Currency: "USD"}
+		require.NoError(t, products.WithTx(tx).CreateProduct(ctx, &product))
+		txm.Rollback(tx)
+
+		assert.ErrorIs(t, products.GetProductByCode(ctx, "PROD009", &models.Product{}), gorm.ErrRecordNotFound)
+	})
+
+	t.Run("commit keeps writes", func(t *testing.T) {
+		tx, err := txm.Begin(ctx)
+		require.NoError(t, err)
+		product := models.Product{Code: "PROD010", Price: decimal.RequireFromString("20"), Currency: "USD"}
+		require.NoError(t, products.WithTx(tx).CreateProduct(ctx, &product))
+		require.NoError(t, txm.Commit(tx))
+		// The deferred Rollback of the usual pattern must be harmless.
+		txm.Rollback(tx)
+
+		assert.NoError(t, products.GetProductByCode(ctx, "PROD010", &models.Product{}))
+	})
+}
+
+func TestProductsRepositoryWithTxKeepsOptions(t *testing.T) {
+	seedCatalog(t)
+	ctx := context.Background()
+	repo := models.NewProductsRepository(db, models.WithCaseInsensitiveCodes())
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var product models.Product
+		return repo.WithTx(tx).GetProductByCode(ctx, "prod001", &product)
+	})
+	assert.NoError(t, err)
+}
+
+func TestProductsRepositoryPriceTotals(t *testing.T) {
+	repo := models.NewProductsRepository(db)
+	ctx := context.Background()
+
+	testutil.TruncateAll(t, db)
+	totals, err := repo.GetPriceTotals(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, totals)
+
+	seedCatalog(t)
+	totals, err = repo.GetPriceTotals(ctx)
+	require.NoError(t, err)
+	require.Len(t, totals, 1)
+	assert.Equal(t, "USD", totals[0].Currency)
+	assert.Equal(t, int64(5), totals[0].Count)
+	assert.True(t, totals[0].Sum.Equal(decimal.RequireFromString("70.22")))
+}
+
+func TestProductsPriceIndices(t *testing.T) {
+	categories, _ := seedCatalog(t)
+
+	tests := []struct {
+		name  string
+		query string
+		args  []any
+		index string
+	}{
+		{
+			name:  "price filter",
+			query: "SELECT id FROM products WHERE price < ?",
+			args:  []any{20},
+			index: "idx_products_price",
+		},
+		{
+			name:  "category and price filter",
+			query: "SELECT id FROM products WHERE category_id = ? AND price < ?",
+			args:  []any{categories[0].ID, 20},
+			index: "idx_products_category_price",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// The seeded tables are tiny, so the planner would rather scan
+			// them; disable sequential scans to check an index is usable.
+			err := db.Transaction(func(tx *gorm.DB) error {
+				if err := tx.Exec("SET LOCAL enable_seqscan = off").Error; err != nil {
+					return err
+				}
+
+				var plan []string
+				if err := tx.Raw("EXPLAIN "+tt.query, tt.args...).Scan(&plan).Error; err != nil {
+					return err
+				}
+				assert.Contains(t, strings.Join(plan, "\n"), tt.index)
+				return nil
+			})
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestSeedFromFile(t *testing.T) {
+	testutil.TruncateAll(t, db)
+	ctx := context.Background()
+
+	// Seeding twice must not fail nor duplicate rows.
+	require.NoError(t, database.SeedFromFile(db, "../sql/seed.json"))
+	require.NoError(t, database.SeedFromFile(db, "../sql/seed.json"))
+
+	categories, err := models.NewCategoriesRepository(db).GetAllCategories(ctx)
+	require.NoError(t, err)
+	assert.Len(t, categories, 3)
+
+	products, err := models.NewProductsRepository(db).GetAllProducts(ctx)
+	require.NoError(t, err)
+	require.Len(t, products, 3)
+
+	var product models.Product
+	require.NoError(t, models.NewProductsRepository(db).GetProductByCode(ctx, "PROD001", &product))
+	assert.Equal(t, "CLOTHING", product.Category.Code)
+	assert.Len(t, product.Variants, 3)
+}
+
+func TestSeedFromFileRollsBack(t *testing.T) {
+	testutil.TruncateAll(t, db)
+	ctx := context.Background()
+
+	// The second product references a missing category, after the first
+	// one was inserted.
+	path := t.TempDir() + "/seed.json"
+	require.NoError(t, os.WriteFile(path, []byte(`{
+		"categories": [{"code": "CLOTHING", "name": "Clothing"}],
+		"products": [
+			{"code": "PROD001", "price": "10.99", "category": "CLOTHING"},
+			{"code": "PROD002", "price": "12.49", "category": "MISSING"}
+		]
+	}`), 0o600))
+
+	require.Error(t, database.SeedFromFile(db, path))
+
+	products, err := models.NewProductsRepository(db).GetAllProducts(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, products)
+	categories, err := models.NewCategoriesRepository(db).GetAllCategories(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, categories)
+}
@@ -0,0 +1,22 @@
+package models
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// PriceChangeEvent records a change of a product price: its previous and new
+// value, when it happened and who made it. Events are only ever inserted.
+type PriceChangeEvent struct {
+	ID          uint            `gorm:"primaryKey"`
+	ProductCode string          `gorm:"not null"`
+	OldPrice    decimal.Decimal `gorm:"type:decimal(10,2);not null"`
+	NewPrice    decimal.Decimal `gorm:"type:decimal(10,2);not null"`
+	ChangedAt   time.Time       `gorm:"not null"`
+	ChangedBy   string          `gorm:"not null;default:''"`
+}
+
+func (e *PriceChangeEvent) TableName() string {
+	return "price_change_events"
+}
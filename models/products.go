@@ -1,18 +1,62 @@
 package models
 
 import (
+	"strings"
+
+	"github.com/lib/pq"
 	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
 )
 
 // Product represents a product in the catalog.
-// It includes a unique code and a price.
+// It includes a unique code, always stored in uppercase, an optional unique SKU, a price in its currency and the category it belongs to.
+// Featured products are promoted by marketing and ordered by SortOrder.
+// Version is incremented by every UpdateProduct, for optimistic locking.
+// Name is the display name of the product, empty when it has none.
+// Images are the URLs of the product's pictures, in display order.
+// Physical products carry their shipping weight, dimensions and class; the
+// zero values mean there is nothing to ship.
 type Product struct {
-	ID       uint            `gorm:"primaryKey"`
-	Code     string          `gorm:"uniqueIndex;not null"`
-	Price    decimal.Decimal `gorm:"type:decimal(10,2);not null"`
-	Variants []Variant       `gorm:"foreignKey:ProductID"`
+	ID         uint            `gorm:"primaryKey"`
+	Code       string          `gorm:"uniqueIndex;not null"`
+	SKU        string          `gorm:"uniqueIndex;default:null"`
+	Name       string          `gorm:"type:varchar(256);not null;default:''"`
+	Price      decimal.Decimal `gorm:"type:decimal(10,2);not null"`
+	Currency   string          `gorm:"type:varchar(3);not null;default:USD"`
+	Featured   bool            `gorm:"default:false"`
+	SortOrder  int             `gorm:"default:0"`
+	Version    int             `gorm:"default:1"`
+	Images     pq.StringArray  `gorm:"type:text[];not null;default:'{}'"`
+	CategoryID *uint
+	Category   Category  `gorm:"foreignKey:CategoryID"`
+	Variants   []Variant `gorm:"foreignKey:ProductID"`
+
+	WeightGrams   int    `gorm:"not null;default:0"`
+	LengthMm      int    `gorm:"not null;default:0"`
+	WidthMm       int    `gorm:"not null;default:0"`
+	HeightMm      int    `gorm:"not null;default:0"`
+	ShippingClass string `gorm:"type:varchar(32);not null;default:''"`
 }
 
 func (p *Product) TableName() string {
 	return "products"
 }
+
+// BeforeCreate uppercases the code of a new product.
+func (p *Product) BeforeCreate(tx *gorm.DB) error {
+	p.Code = strings.ToUpper(p.Code)
+	return nil
+}
+
+// BeforeSave uppercases the code of a product written with Save.
+func (p *Product) BeforeSave(tx *gorm.DB) error {
+	p.Code = strings.ToUpper(p.Code)
+	return nil
+}
+
+// PriceTotal aggregates the prices of the products sharing a currency.
+type PriceTotal struct {
+	Currency string
+	Count    int64
+	Sum      decimal.Decimal
+}
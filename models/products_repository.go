@@ -1,28 +1,584 @@
 package models
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"strings"
+
+	"github.com/shopspring/decimal"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
+// ErrVersionConflict is returned when a product was updated by someone else
+// since the version an update was based on.
+var ErrVersionConflict = errors.New("version conflict, reload the resource and retry")
+
 // ProductsRepositoryInterface defines the contract for product repository operations
 type ProductsRepositoryInterface interface {
-	GetAllProducts() ([]Product, error)
+	GetAllProducts(ctx context.Context) ([]Product, error)
+	EachProduct(ctx context.Context, batchSize int, fn func(Product) error) error
+	GetProductByCode(ctx context.Context, code string, product *Product) error
+	GetProductBySKU(ctx context.Context, sku string, product *Product) error
+	GetProducts(ctx context.Context, q ProductQuery) ([]Product, int64, error)
+	CountProducts(ctx context.Context, q ProductQuery) (int64, error)
+	GetFeaturedProducts(ctx context.Context) ([]Product, error)
+	SetProductFeatured(ctx context.Context, code string, featured bool) error
+	SetProductCategory(ctx context.Context, code string, categoryID uint) error
+	LockCategoryProducts(ctx context.Context, categoryID uint) ([]Product, error)
+	GetSimilarProducts(ctx context.Context, code string, limit int) ([]Product, error)
+	GetRandomProducts(ctx context.Context, count int, category string) ([]Product, error)
+	GetPriceTotals(ctx context.Context) ([]PriceTotal, error)
+	CreateProduct(ctx context.Context, product *Product) error
+	UpdateProduct(ctx context.Context, code string, version int, updates map[string]any) error
+	CreateVariant(ctx context.Context, variant *Variant) error
+	CountVariants(ctx context.Context, productID uint) (int64, error)
+	GetVariantStock(ctx context.Context, code, sku string) (int, error)
+	UpdateVariant(ctx context.Context, variant *Variant) error
+	CreatePriceChangeEvent(ctx context.Context, event *PriceChangeEvent) error
+	GetPriceHistory(ctx context.Context, code string) ([]PriceChangeEvent, error)
+}
+
+// productSortColumns is the allow-list of sort fields, mapped to the column
+// they order by. Only these ever reach the ORDER BY clause.
+var productSortColumns = map[string]string{
+	"code":       "products.code",
+	"price":      "products.price",
+	"name":       "products.name",
+	"created_at": "products.created_at",
+}
+
+// Sort directions of a SortOrder.
+const (
+	SortAsc  = "asc"
+	SortDesc = "desc"
+)
+
+// SortOrder is one key of a product sort: Field is one of
+// productSortColumns, Dir is SortAsc or SortDesc.
+type SortOrder struct {
+	Field string
+	Dir   string
+}
+
+// ParseProductSort parses a comma separated list of sort fields, each
+// sorting in ascending order unless prefixed with "-", e.g. "price,-code".
+// An empty sort returns no keys, which keeps the default id ordering.
+func ParseProductSort(sort string) ([]SortOrder, error) {
+	if sort == "" {
+		return nil, nil
+	}
+
+	var sorts []SortOrder
+	for _, key := range strings.Split(sort, ",") {
+		order := SortOrder{Field: key, Dir: SortAsc}
+		if field, ok := strings.CutPrefix(key, "-"); ok {
+			order = SortOrder{Field: field, Dir: SortDesc}
+		}
+		if _, ok := productSortColumns[order.Field]; !ok {
+			return nil, fmt.Errorf("invalid sort %q", key)
+		}
+		sorts = append(sorts, order)
+	}
+	return sorts, nil
+}
+
+// orderBy builds the ORDER BY clause of sorts, validating every field and
+// direction. Ties are broken by id so pages are stable.
+func orderBy(sorts []SortOrder) (string, error) {
+	clauses := make([]string, 0, len(sorts)+1)
+	for _, s := range sorts {
+		column, ok := productSortColumns[s.Field]
+		if !ok {
+			return "", fmt.Errorf("invalid sort field %q", s.Field)
+		}
+		switch s.Dir {
+		case SortAsc, "":
+			clauses = append(clauses, column+" ASC")
+		case SortDesc:
+			clauses = append(clauses, column+" DESC")
+		default:
+			return "", fmt.Errorf("invalid sort direction %q for %s", s.Dir, s.Field)
+		}
+	}
+	return strings.Join(append(clauses, "products.id ASC"), ", "), nil
+}
+
+// defaultProductQueryLimit is the page size of NewProductQuery.
+const defaultProductQueryLimit = 10
+
+// ProductQuery selects a sorted page of products. Zero filter values don't
+// filter.
+type ProductQuery struct {
+	// Offset is the number of products skipped; negative offsets skip none.
+	Offset int
+	// Limit is the page size. Limits below 1 return a single product, so
+	// start from NewProductQuery.
+	Limit int
+	// Sort lists the sort keys, the first one taking precedence; ties and
+	// an empty Sort are ordered by id.
+	Sort []SortOrder
+
+	// Categories keeps products in any of the named categories, whatever
+	// the case of the names.
+	Categories []string
+	// PriceLt and PriceGte bound the price to [PriceGte, PriceLt).
+	PriceLt  *float64
+	PriceGte *float64
+	// PriceEq keeps products priced exactly at it.
+	PriceEq  *decimal.Decimal
+	Featured *bool
+	// InStock keeps products with at least one variant in stock.
+	InStock bool
+	// HasVariants keeps products with (true) or without (false) variants.
+	HasVariants *bool
+}
+
+// NewProductQuery returns a query for the first page of every product, in id
+// order.
+func NewProductQuery() ProductQuery {
+	return ProductQuery{Limit: defaultProductQueryLimit}
+}
+
+// page returns the offset and limit of q clamped to a valid page. GORM drops
+// negative values instead, and without a limit the whole table is returned.
+func (q ProductQuery) page() (offset, limit int) {
+	return max(q.Offset, 0), max(q.Limit, 1)
 }
 
 type ProductsRepository struct {
-	db *gorm.DB
+	db                   *gorm.DB
+	caseInsensitiveCodes bool
+}
+
+// ProductsRepositoryOption configures a ProductsRepository.
+type ProductsRepositoryOption func(*ProductsRepository)
+
+// WithCaseInsensitiveCodes makes product code lookups ignore case, so prod001
+// finds PROD001. Lookups then compare UPPER(code), which is backed by the
+// idx_products_code_upper index.
+func WithCaseInsensitiveCodes() ProductsRepositoryOption {
+	return func(r *ProductsRepository) {
+		r.caseInsensitiveCodes = true
+	}
 }
 
-func NewProductsRepository(db *gorm.DB) *ProductsRepository {
-	return &ProductsRepository{
+func NewProductsRepository(db *gorm.DB, opts ...ProductsRepositoryOption) *ProductsRepository {
+	r := &ProductsRepository{
 		db: db,
 	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+// WithTx returns a repository with the same options as r, running its
+// queries in the transaction tx.
+func (r *ProductsRepository) WithTx(tx *gorm.DB) ProductsRepositoryInterface {
+	clone := *r
+	clone.db = tx
+	return &clone
+}
+
+// orderVariants sorts preloaded variants by SKU, so they come back in a
+// stable order.
+func orderVariants(db *gorm.DB) *gorm.DB {
+	return db.Order("sku ASC")
+}
+
+// whereCode filters q on the product code, honouring WithCaseInsensitiveCodes.
+func (r *ProductsRepository) whereCode(q *gorm.DB, code string) *gorm.DB {
+	if r.caseInsensitiveCodes {
+		return q.Where("UPPER(products.code) = UPPER(?)", code)
+	}
+	return q.Where("products.code = ?", code)
+}
+
+// GetAllProducts loads every product with its category and variants in two
+// queries, whatever the number of products: the category is joined, and the
+// variants, which a join would multiply rows for, are preloaded with a single
+// product_id IN (...) query.
+func (r *ProductsRepository) GetAllProducts(ctx context.Context) ([]Product, error) {
+	var products []Product
+	if err := r.db.WithContext(ctx).Joins("Category").Preload("Variants", orderVariants).Order("products.id").Find(&products).Error; err != nil {
+		return nil, err
+	}
+	return products, nil
+}
+
+// EachProduct calls fn with every product, with its category and variants,
+// in id order. The products are loaded batchSize at a time, each batch
+// starting after the last id of the previous one, so memory use doesn't grow
+// with the catalog. It stops at the first error of fn and returns it.
+func (r *ProductsRepository) EachProduct(ctx context.Context, batchSize int, fn func(Product) error) error {
+	var lastID uint
+	for {
+		var batch []Product
+		err := r.db.WithContext(ctx).Joins("Category").Preload("Variants", orderVariants).
+			Where("products.id > ?", lastID).
+			Order("products.id").
+			Limit(batchSize).
+			Find(&batch).Error
+		if err != nil {
+			return err
+		}
+
+		for _, p := range batch {
+			if err := fn(p); err != nil {
+				return err
+			}
+		}
+		if len(batch) < batchSize {
+			return nil
+		}
+		lastID = batch[len(batch)-1].ID
+	}
+}
+
+func (r *ProductsRepository) GetProductByCode(ctx context.Context, code string, product *Product) error {
+	return r.whereCode(r.db.WithContext(ctx).Preload("Category").Preload("Variants", orderVariants), code).First(product).Error
+}
+
+// GetProductBySKU finds the product whose own SKU is sku or, when there is
+// none, the product owning the variant with SKU sku.
+func (r *ProductsRepository) GetProductBySKU(ctx context.Context, sku string, product *Product) error {
+	query := func() *gorm.DB {
+		return r.db.WithContext(ctx).Preload("Category").Preload("Variants", orderVariants)
+	}
+
+	err := query().Where("sku = ?", sku).First(product).Error
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+	return query().Where("id = (SELECT product_id FROM product_variants WHERE sku = ?)", sku).First(product).Error
+}
+
+// GetProducts returns the page of products selected by q, along with the
+// number of products matching its filters across all pages.
+func (r *ProductsRepository) GetProducts(ctx context.Context, q ProductQuery) ([]Product, int64, error) {
+	total, err := r.countProducts(ctx, q)
+	if err != nil {
+		return nil, 0, err
+	}
+	products, err := r.findProducts(ctx, q)
+	if err != nil {
+		return nil, 0, err
+	}
+	return products, total, nil
+}
+
+// CountProducts returns the number of products matching the filters of q,
+// without loading any of them. The page and sort of q are ignored.
+func (r *ProductsRepository) CountProducts(ctx context.Context, q ProductQuery) (int64, error) {
+	return r.countProducts(ctx, q)
+}
+
+// GetProductsPaginatedWithFilters returns a page of products matching the filters.
+//
+// Deprecated: use GetProducts, which doesn't need a new argument per filter.
+func (r *ProductsRepository) GetProductsPaginatedWithFilters(ctx context.Context, offset, limit int, categories []string, priceLt, priceGte *float64, priceEq *decimal.Decimal, featured *bool, inStock bool, hasVariants *bool, sort string) ([]Product, error) {
+	sorts, err := ParseProductSort(sort)
+	if err != nil {
+		return nil, err
+	}
+	return r.findProducts(ctx, ProductQuery{
+		Offset:      offset,
+		Limit:       limit,
+		Sort:        sorts,
+		Categories:  categories,
+		PriceLt:     priceLt,
+		PriceGte:    priceGte,
+		PriceEq:     priceEq,
+		Featured:    featured,
+		InStock:     inStock,
+		HasVariants: hasVariants,
+	})
+}
+
+// GetProductsCountWithFilters returns the number of products matching the filters.
+//
+// Deprecated: use GetProducts, which returns the count along with the page.
+func (r *ProductsRepository) GetProductsCountWithFilters(ctx context.Context, categories []string, priceLt, priceGte *float64, priceEq *decimal.Decimal, featured *bool, inStock bool, hasVariants *bool) (int64, error) {
+	return r.countProducts(ctx, ProductQuery{
+		Categories:  categories,
+		PriceLt:     priceLt,
+		PriceGte:    priceGte,
+		PriceEq:     priceEq,
+		Featured:    featured,
+		InStock:     inStock,
+		HasVariants: hasVariants,
+	})
+}
+
+func (r *ProductsRepository) findProducts(ctx context.Context, q ProductQuery) ([]Product, error) {
+	order, err := orderBy(q.Sort)
+	if err != nil {
+		return nil, err
+	}
+
+	offset, limit := q.page()
+
+	var products []Product
+	err = r.withFilters(ctx, q).
+		Preload("Category").
+		Preload("Variants", orderVariants).
+		Order(order).
+		Offset(offset).
+		Limit(limit).
+		Find(&products).Error
+	if err != nil {
+		return nil, err
+	}
+	return products, nil
 }
 
-func (r *ProductsRepository) GetAllProducts() ([]Product, error) {
+func (r *ProductsRepository) countProducts(ctx context.Context, q ProductQuery) (int64, error) {
+	var count int64
+	if err := r.withFilters(ctx, q).Count(&count).Error; err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+func (r *ProductsRepository) GetFeaturedProducts(ctx context.Context) ([]Product, error) {
 	var products []Product
-	if err := r.db.Preload("Variants").Find(&products).Error; err != nil {
+	err := r.db.WithContext(ctx).Preload("Category").
+		Preload("Variants", orderVariants).
+		Where("featured = ?", true).
+		Order("sort_order ASC").
+		Order("id ASC").
+		Find(&products).Error
+	if err != nil {
 		return nil, err
 	}
 	return products, nil
 }
+
+func (r *ProductsRepository) SetProductFeatured(ctx context.Context, code string, featured bool) error {
+	res := r.whereCode(r.db.WithContext(ctx).Model(&Product{}), code).Update("featured", featured)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// SetProductCategory moves the product identified by code to the category categoryID.
+func (r *ProductsRepository) SetProductCategory(ctx context.Context, code string, categoryID uint) error {
+	res := r.whereCode(r.db.WithContext(ctx).Model(&Product{}), code).Update("category_id", categoryID)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// LockCategoryProducts returns the products of the category categoryID,
+// subcategories excluded, and locks their rows until the end of the
+// transaction. Rows are locked in id order so concurrent callers can't
+// deadlock.
+func (r *ProductsRepository) LockCategoryProducts(ctx context.Context, categoryID uint) ([]Product, error) {
+	products := []Product{}
+	err := r.db.WithContext(ctx).
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("category_id = ?", categoryID).
+		Order("id").
+		Find(&products).Error
+	if err != nil {
+		return nil, err
+	}
+	return products, nil
+}
+
+// GetSimilarProducts returns up to limit other products from the same category as
+// the product identified by code, closest in price first.
+func (r *ProductsRepository) GetSimilarProducts(ctx context.Context, code string, limit int) ([]Product, error) {
+	db := r.db.WithContext(ctx)
+
+	var product Product
+	if err := r.whereCode(db, code).First(&product).Error; err != nil {
+		return nil, err
+	}
+
+	products := []Product{}
+	if product.CategoryID == nil {
+		return products, nil
+	}
+
+	err := db.Preload("Category").
+		Preload("Variants", orderVariants).
+		Where("category_id = ? AND id <> ?", *product.CategoryID, product.ID).
+		Order(clause.OrderBy{Expression: clause.Expr{
+			SQL:  "ABS(price - ?) ASC, id ASC",
+			Vars: []any{product.Price},
+		}}).
+		Limit(limit).
+		Find(&products).Error
+	if err != nil {
+		return nil, err
+	}
+	return products, nil
+}
+
+// GetRandomProducts returns up to count products picked at random, optionally
+// restricted to a category name. The shuffling and limit are done by Postgres.
+func (r *ProductsRepository) GetRandomProducts(ctx context.Context, count int, category string) ([]Product, error) {
+	q := ProductQuery{}
+	if category != "" {
+		q.Categories = []string{category}
+	}
+
+	products := []Product{}
+	err := r.withFilters(ctx, q).
+		Preload("Category").
+		Preload("Variants", orderVariants).
+		Order("RANDOM()").
+		Limit(count).
+		Find(&products).Error
+	if err != nil {
+		return nil, err
+	}
+	return products, nil
+}
+
+// GetPriceTotals returns the number of products and the sum of their prices
+// per currency. An empty catalog yields no rows.
+func (r *ProductsRepository) GetPriceTotals(ctx context.Context) ([]PriceTotal, error) {
+	totals := []PriceTotal{}
+	err := r.db.WithContext(ctx).
+		Model(&Product{}).
+		Select("currency, COUNT(*) AS count, SUM(price) AS sum").
+		Group("currency").
+		Order("currency").
+		Scan(&totals).Error
+	if err != nil {
+		return nil, err
+	}
+	return totals, nil
+}
+
+// CreateProduct inserts product on its own; variants and the category are
+// expected to be created separately.
+func (r *ProductsRepository) CreateProduct(ctx context.Context, product *Product) error {
+	return r.db.WithContext(ctx).Omit(clause.Associations).Create(product).Error
+}
+
+func (r *ProductsRepository) CreateVariant(ctx context.Context, variant *Variant) error {
+	return r.db.WithContext(ctx).Create(variant).Error
+}
+
+// CountVariants returns the number of variants of the product with productID.
+func (r *ProductsRepository) CountVariants(ctx context.Context, productID uint) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&Variant{}).Where("product_id = ?", productID).Count(&count).Error
+	return count, err
+}
+
+// GetVariantStock returns the stock of the variant sku of the product
+// identified by code, or gorm.ErrRecordNotFound when that product has no
+// such variant, including when the SKU belongs to another product.
+func (r *ProductsRepository) GetVariantStock(ctx context.Context, code, sku string) (int, error) {
+	var variant Variant
+	q := r.db.WithContext(ctx).
+		Joins("JOIN products ON products.id = product_variants.product_id").
+		Select("product_variants.stock").
+		Where("product_variants.sku = ?", sku)
+	if err := r.whereCode(q, code).Take(&variant).Error; err != nil {
+		return 0, err
+	}
+	return variant.Stock, nil
+}
+
+func (r *ProductsRepository) UpdateVariant(ctx context.Context, variant *Variant) error {
+	return r.db.WithContext(ctx).Save(variant).Error
+}
+
+// UpdateProduct applies updates to the product identified by code and
+// increments its version, provided the product is still at version. It
+// returns ErrVersionConflict when the product was updated since version was
+// read.
+func (r *ProductsRepository) UpdateProduct(ctx context.Context, code string, version int, updates map[string]any) error {
+	values := maps.Clone(updates)
+	if values == nil {
+		values = map[string]any{}
+	}
+	values["version"] = gorm.Expr("version + 1")
+
+	db := r.db.WithContext(ctx)
+	res := r.whereCode(db.Model(&Product{}), code).Where("products.version = ?", version).Updates(values)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected > 0 {
+		return nil
+	}
+
+	var count int64
+	if err := r.whereCode(db.Model(&Product{}), code).Count(&count).Error; err != nil {
+		return err
+	}
+	if count == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return ErrVersionConflict
+}
+
+func (r *ProductsRepository) CreatePriceChangeEvent(ctx context.Context, event *PriceChangeEvent) error {
+	return r.db.WithContext(ctx).Create(event).Error
+}
+
+// GetPriceHistory returns the price changes of the product code, most recent
+// first. code is matched exactly, whatever WithCaseInsensitiveCodes says, as
+// events store the product's code as it was saved.
+func (r *ProductsRepository) GetPriceHistory(ctx context.Context, code string) ([]PriceChangeEvent, error) {
+	events := []PriceChangeEvent{}
+	if err := r.db.WithContext(ctx).Where("product_code = ?", code).Order("changed_at DESC, id DESC").Find(&events).Error; err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+// withFilters builds the products query shared by the listing and count
+// methods from the filters of q; its pagination and sort are ignored.
+func (r *ProductsRepository) withFilters(ctx context.Context, q ProductQuery) *gorm.DB {
+	db := r.db.WithContext(ctx).Model(&Product{}).Joins("LEFT JOIN categories ON categories.id = products.category_id")
+	if len(q.Categories) > 0 {
+		names := make([]string, len(q.Categories))
+		for i, name := range q.Categories {
+			names[i] = strings.ToLower(name)
+		}
+		db = db.Where("LOWER(categories.name) IN ?", names)
+	}
+	if q.PriceLt != nil {
+		// Backed by idx_products_price, or idx_products_category_price when
+		// combined with a category, see migration 000007.
+		db = db.Where("products.price < ?", *q.PriceLt)
+	}
+	if q.PriceGte != nil {
+		db = db.Where("products.price >= ?", *q.PriceGte)
+	}
+	if q.PriceEq != nil {
+		db = db.Where("products.price = ?", *q.PriceEq)
+	}
+	if q.Featured != nil {
+		db = db.Where("products.featured = ?", *q.Featured)
+	}
+	if q.InStock {
+		db = db.Where("EXISTS (SELECT 1 FROM product_variants WHERE product_variants.product_id = products.id AND product_variants.stock > 0)")
+	}
+	if q.HasVariants != nil {
+		exists := "EXISTS (SELECT 1 FROM product_variants WHERE product_variants.product_id = products.id)"
+		if !*q.HasVariants {
+			exists = "NOT " + exists
+		}
+		db = db.Where(exists)
+	}
+	return db
+}
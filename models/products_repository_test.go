@@ -0,0 +1,93 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProductQueryPage(t *testing.T) {
+	tests := []struct {
+		name           string
+		offset, limit  int
+		expectedOffset int
+		expectedLimit  int
+	}{
+		{name: "valid page", offset: 20, limit: 10, expectedOffset: 20, expectedLimit: 10},
+		{name: "first page", offset: 0, limit: 1, expectedOffset: 0, expectedLimit: 1},
+		{name: "negative offset", offset: -1, limit: 10, expectedOffset: 0, expectedLimit: 10},
+		{name: "zero limit", offset: 0, limit: 0, expectedOffset: 0, expectedLimit: 1},
+		{name: "negative limit", offset: 5, limit: -100, expectedOffset: 5, expectedLimit: 1},
+		{name: "both negative", offset: -1, limit: -5, expectedOffset: 0, expectedLimit: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			offset, limit := ProductQuery{Offset: tt.offset, Limit: tt.limit}.page()
+
+			assert.Equal(t, tt.expectedOffset, offset)
+			assert.Equal(t, tt.expectedLimit, limit)
+		})
+	}
+}
+
+func TestParseProductSort(t *testing.T) {
+	tests := []struct {
+		sort     string
+		expected []SortOrder
+		err      string
+	}{
+		{sort: "", expected: nil},
+		{sort: "price", expected: []SortOrder{{Field: "price", Dir: SortAsc}}},
+		{sort: "-price,code", expected: []SortOrder{{Field: "price", Dir: SortDesc}, {Field: "code", Dir: SortAsc}}},
+		{sort: "name", expected: []SortOrder{{Field: "name", Dir: SortAsc}}},
+		{sort: "-created_at,name", expected: []SortOrder{{Field: "created_at", Dir: SortDesc}, {Field: "name", Dir: SortAsc}}},
+		{sort: "category", err: `invalid sort "category"`},
+		{sort: "price,", err: `invalid sort ""`},
+		{sort: "price;DROP TABLE products", err: `invalid sort "price;DROP TABLE products"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.sort, func(t *testing.T) {
+			sorts, err := ParseProductSort(tt.sort)
+
+			if tt.err != "" {
+				assert.EqualError(t, err, tt.err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, sorts)
+		})
+	}
+}
+
+func TestOrderBy(t *testing.T) {
+	tests := []struct {
+		name     string
+		sorts    []SortOrder
+		expected string
+		err      string
+	}{
+		{name: "default", expected: "products.id ASC"},
+		{name: "single key", sorts: []SortOrder{{Field: "price", Dir: SortDesc}}, expected: "products.price DESC, products.id ASC"},
+		{name: "primary and secondary keys", sorts: []SortOrder{{Field: "price", Dir: SortAsc}, {Field: "code", Dir: SortAsc}}, expected: "products.price ASC, products.code ASC, products.id ASC"},
+		{name: "name", sorts: []SortOrder{{Field: "name", Dir: SortAsc}}, expected: "products.name ASC, products.id ASC"},
+		{name: "creation date", sorts: []SortOrder{{Field: "created_at", Dir: SortDesc}}, expected: "products.created_at DESC, products.id ASC"},
+		{name: "direction defaults to ascending", sorts: []SortOrder{{Field: "code"}}, expected: "products.code ASC, products.id ASC"},
+		{name: "unknown field", sorts: []SortOrder{{Field: "price"}, {Field: "1; DROP TABLE products"}}, err: `invalid sort field "1; DROP TABLE products"`},
+		{name: "unknown direction", sorts: []SortOrder{{Field: "price", Dir: "sideways"}}, err: `invalid sort direction "sideways" for price`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			order, err := orderBy(tt.sorts)
+
+			if tt.err != "" {
+				assert.EqualError(t, err, tt.err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, order)
+		})
+	}
+}
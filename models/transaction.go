@@ -0,0 +1,85 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+
+	"gorm.io/gorm"
+)
+
+// TxRepositories holds repositories bound to a single database transaction.
+type TxRepositories struct {
+	Products   ProductsRepositoryInterface
+	Categories CategoriesRepositoryInterface
+}
+
+// TransactorInterface runs a unit of work atomically.
+type TransactorInterface interface {
+	// WithTransaction calls fn with repositories sharing one transaction. The
+	// transaction is committed when fn returns nil and rolled back otherwise.
+	WithTransaction(ctx context.Context, fn func(TxRepositories) error) error
+}
+
+type Transactor struct {
+	db       *gorm.DB
+	products *ProductsRepository
+}
+
+// NewTransactor returns a Transactor whose products repositories are built
+// with productsOpts, like the non-transactional one.
+func NewTransactor(db *gorm.DB, productsOpts ...ProductsRepositoryOption) *Transactor {
+	return &Transactor{
+		db:       db,
+		products: NewProductsRepository(db, productsOpts...),
+	}
+}
+
+func (t *Transactor) WithTransaction(ctx context.Context, fn func(TxRepositories) error) error {
+	return t.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return fn(TxRepositories{
+			Products:   t.products.WithTx(tx),
+			Categories: NewCategoriesRepository(tx),
+		})
+	})
+}
+
+// TransactionManager begins and ends transactions by hand, for multi-step
+// work that doesn't fit in a WithTransaction callback, such as a bulk import
+// spread over several functions. Repositories join a transaction through
+// their WithTx method.
+//
+// Every Begin must be followed by Commit or Rollback. Deferring Rollback
+// right after Begin is the safe pattern: it undoes partial writes on any
+// early return or panic, and does nothing once Commit succeeded.
+type TransactionManager struct {
+	db *gorm.DB
+}
+
+func NewTransactionManager(db *gorm.DB) *TransactionManager {
+	return &TransactionManager{db: db}
+}
+
+// Begin starts a transaction whose queries run with ctx.
+func (m *TransactionManager) Begin(ctx context.Context) (*gorm.DB, error) {
+	tx := m.db.WithContext(ctx).Begin()
+	if tx.Error != nil {
+		return nil, tx.Error
+	}
+	return tx, nil
+}
+
+// Commit commits tx.
+func (m *TransactionManager) Commit(tx *gorm.DB) error {
+	return tx.Commit().Error
+}
+
+// Rollback rolls tx back, unless it was already committed or rolled back.
+// A failed rollback is only logged: the database discards the transaction
+// anyway once the connection is closed.
+func (m *TransactionManager) Rollback(tx *gorm.DB) {
+	if err := tx.Rollback().Error; err != nil && !errors.Is(err, sql.ErrTxDone) {
+		log.Printf("rolling back transaction: %s", err)
+	}
+}
@@ -5,14 +5,19 @@ import (
 )
 
 // Variant represents a product variant in the catalog.
-// It includes a unique name, SKU, and an optional price.
+// It includes a unique name, SKU, an optional price and an optional sale price.
 // Variants can be used to represent different configurations or options for a product.
+// A nil SalePrice means the variant has no active sale. Stock is the number of units available.
+// Image is the URL of the variant's picture, empty when it has none.
 type Variant struct {
-	ID        uint            `gorm:"primaryKey"`
-	ProductID uint            `gorm:"not null"`
-	Name      string          `gorm:"not null"`
-	SKU       string          `gorm:"uniqueIndex;not null"`
-	Price     decimal.Decimal `gorm:"type:decimal(10,2);null"`
+	ID        uint             `gorm:"primaryKey"`
+	ProductID uint             `gorm:"not null"`
+	Name      string           `gorm:"not null"`
+	SKU       string           `gorm:"uniqueIndex;not null"`
+	Price     decimal.Decimal  `gorm:"type:decimal(10,2);null"`
+	SalePrice *decimal.Decimal `gorm:"type:decimal(10,2);null"`
+	Stock     int              `gorm:"not null;default:0"`
+	Image     string           `gorm:"type:varchar(2048);not null;default:''"`
 }
 
 func (v *Variant) TableName() string {
@@ -0,0 +1,18 @@
+package models
+
+import (
+	"github.com/lib/pq"
+)
+
+// Webhook is a URL notified of catalog changes. Deliveries are signed with
+// Secret, and only sent for the event names listed in Events.
+type Webhook struct {
+	ID     uint           `gorm:"primaryKey"`
+	URL    string         `gorm:"not null"`
+	Secret string         `gorm:"not null"`
+	Events pq.StringArray `gorm:"type:text[];not null"`
+}
+
+func (w *Webhook) TableName() string {
+	return "webhooks"
+}
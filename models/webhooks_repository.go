@@ -0,0 +1,48 @@
+package models
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+)
+
+// WebhooksRepositoryInterface defines the contract for webhook repository operations
+type WebhooksRepositoryInterface interface {
+	GetWebhooksForEvent(ctx context.Context, event string) ([]Webhook, error)
+	CreateWebhook(ctx context.Context, webhook *Webhook) error
+	DeleteWebhook(ctx context.Context, id uint) error
+}
+
+type WebhooksRepository struct {
+	db *gorm.DB
+}
+
+func NewWebhooksRepository(db *gorm.DB) *WebhooksRepository {
+	return &WebhooksRepository{
+		db: db,
+	}
+}
+
+// GetWebhooksForEvent returns the webhooks subscribed to event, ordered by id.
+func (r *WebhooksRepository) GetWebhooksForEvent(ctx context.Context, event string) ([]Webhook, error) {
+	var webhooks []Webhook
+	if err := r.db.WithContext(ctx).Where("? = ANY(events)", event).Order("id").Find(&webhooks).Error; err != nil {
+		return nil, err
+	}
+	return webhooks, nil
+}
+
+func (r *WebhooksRepository) CreateWebhook(ctx context.Context, webhook *Webhook) error {
+	return r.db.WithContext(ctx).Create(webhook).Error
+}
+
+func (r *WebhooksRepository) DeleteWebhook(ctx context.Context, id uint) error {
+	res := r.db.WithContext(ctx).Delete(&Webhook{}, id)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS categories (
+    id SERIAL PRIMARY KEY,
+    code VARCHAR(32) UNIQUE NOT NULL,
+    name VARCHAR(256) NOT NULL,
+    created_at TIMESTAMP DEFAULT NOW(),
+    updated_at TIMESTAMP DEFAULT NOW()
+);
+
+INSERT INTO categories (code, name) VALUES
+('CLOTHING', 'Clothing'),
+('SHOES', 'Shoes'),
+('ACCESSORIES', 'Accessories');
@@ -0,0 +1,3 @@
+ALTER TABLE products ADD COLUMN IF NOT EXISTS sku VARCHAR(32) UNIQUE;
+
+UPDATE products SET sku = REPLACE(code, 'PROD', 'SKU');
@@ -0,0 +1,5 @@
+ALTER TABLE products ADD COLUMN IF NOT EXISTS featured BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE products ADD COLUMN IF NOT EXISTS sort_order INTEGER NOT NULL DEFAULT 0;
+
+UPDATE products SET featured = TRUE, sort_order = 1 WHERE code = 'PROD004';
+UPDATE products SET featured = TRUE, sort_order = 2 WHERE code = 'PROD002';
@@ -0,0 +1,4 @@
+ALTER TABLE product_variants ADD COLUMN IF NOT EXISTS sale_price DECIMAL(10, 2) NULL;
+
+UPDATE product_variants SET sale_price = 9.99 WHERE sku = 'SKU001A';
+UPDATE product_variants SET sale_price = 19.99 WHERE sku = 'SKU005D';
@@ -0,0 +1,4 @@
+ALTER TABLE product_variants ADD COLUMN IF NOT EXISTS stock INTEGER NOT NULL DEFAULT 0;
+
+UPDATE product_variants SET stock = 10 WHERE sku IN ('SKU001A', 'SKU001B', 'SKU004A', 'SKU005D', 'SKU007E');
+UPDATE product_variants SET stock = 2 WHERE sku IN ('SKU002A', 'SKU008A');
@@ -0,0 +1,2 @@
+CREATE INDEX IF NOT EXISTS idx_products_price ON products (price);
+CREATE INDEX IF NOT EXISTS idx_products_category_price ON products (category_id, price);
@@ -0,0 +1 @@
+CREATE INDEX IF NOT EXISTS idx_products_code_upper ON products (UPPER(code));
@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS price_change_events (
+    id SERIAL PRIMARY KEY,
+    product_code VARCHAR(32) NOT NULL,
+    old_price DECIMAL(10, 2) NOT NULL,
+    new_price DECIMAL(10, 2) NOT NULL,
+    changed_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    changed_by VARCHAR(256) NOT NULL DEFAULT ''
+);
+CREATE INDEX IF NOT EXISTS idx_price_change_events_product_code_changed_at ON price_change_events (product_code, changed_at DESC);
@@ -0,0 +1,2 @@
+ALTER TABLE products ADD COLUMN IF NOT EXISTS images TEXT[] NOT NULL DEFAULT '{}';
+ALTER TABLE product_variants ADD COLUMN IF NOT EXISTS image VARCHAR(2048) NOT NULL DEFAULT '';
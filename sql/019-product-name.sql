@@ -0,0 +1 @@
+ALTER TABLE products ADD COLUMN IF NOT EXISTS name VARCHAR(256) NOT NULL DEFAULT '';